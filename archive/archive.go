@@ -0,0 +1,142 @@
+// Package archive opens archive files as read-only file systems, so their
+// contents can be browsed like directories. Zip, 7z and tar archives
+// (plain, gzip- or bzip2-compressed) are built in; other formats can be
+// added with Register.
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownFormat is returned by Open for files of no registered format
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// FS is an opened archive. Close releases the archive file.
+type FS interface {
+	fs.FS
+	io.Closer
+}
+
+// Opener opens the archive at path
+type Opener func(path string) (FS, error)
+
+var (
+	mu      sync.RWMutex
+	formats = map[string]Opener{
+		".zip":     openZip,
+		".jar":     openZip,
+		".tar":     openTar(nil),
+		".tar.gz":  openTar(gzipReader),
+		".tgz":     openTar(gzipReader),
+		".tar.bz2": openTar(bzip2Reader),
+		".tbz2":    openTar(bzip2Reader),
+		".7z":      openSevenZip,
+	}
+)
+
+// Register makes archives whose names end in ext (e.g. ".rar") openable
+// with open, replacing the opener of a built-in format with the same
+// extension
+func Register(ext string, open Opener) {
+	mu.Lock()
+	defer mu.Unlock()
+	formats[strings.ToLower(ext)] = open
+}
+
+// opener returns the opener for name, matching the longest extension so
+// that "a.tar.gz" is a gzipped tar rather than some ".gz" format
+func opener(name string) Opener {
+	mu.RLock()
+	defer mu.RUnlock()
+	name = strings.ToLower(filepath.Base(name))
+	var best string
+	for ext := range formats {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) && len(ext) > len(best) {
+			best = ext
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return formats[best]
+}
+
+// Match reports whether name has the extension of a registered format
+func Match(name string) bool {
+	return opener(name) != nil
+}
+
+// Open opens the archive at path according to its extension
+func Open(path string) (FS, error) {
+	open := opener(path)
+	if open == nil {
+		return nil, ErrUnknownFormat
+	}
+	return open(path)
+}
+
+// openZip opens a zip archive, which the standard library already serves
+// as a file system
+func openZip(path string) (FS, error) {
+	return zip.OpenReader(path)
+}
+
+// WalkFunc is called by Walk for each member of an archive with its
+// fs.FS path. r reads the contents of regular files and is nil otherwise;
+// it is only valid until fn returns.
+type WalkFunc func(name string, info fs.FileInfo, r io.Reader) error
+
+// Walker is implemented by archives that can read all their members in a
+// single pass, which is much faster than opening them one by one when the
+// archive can't seek
+type Walker interface {
+	Walk(fn WalkFunc) error
+}
+
+// Walk calls fn for every member of fsys, parents before their contents. It
+// uses the archive's own Walk if it has one and otherwise opens the members
+// in lexical order. Directories implied by the paths of members may not be
+// reported. An error returned by fn stops the walk and is returned.
+func Walk(fsys FS, fn WalkFunc) error {
+	if w, ok := fsys.(Walker); ok {
+		return w.Walk(fn)
+	}
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fn(name, info, nil)
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return fn(name, info, f)
+	})
+}
+
+// dirEntries lists the entries of a directory of an archive, sorted by name
+func dirEntries(infos []fs.FileInfo) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
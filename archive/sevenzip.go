@@ -0,0 +1,924 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// 7-Zip archives are read here rather than by a library: browsing only
+// needs the header and the methods 7-Zip compresses with, LZMA and LZMA2
+// by default, also Deflate, BZip2 and stored members. Encrypted members
+// and those behind filters such as BCJ can be listed but not read.
+
+// sevenZipSignature starts every 7z archive
+var sevenZipSignature = []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}
+
+// sevenZipHeaderSize is the size of the signature header, which the offsets
+// of the packed streams and the header count from
+const sevenZipHeaderSize = 32
+
+// maxEncodedHeaderSize is the largest compressed header that is decoded,
+// enough for the names of millions of members
+const maxEncodedHeaderSize = 256 << 20
+
+// Property IDs of the 7z header
+const (
+	idEnd                   = 0x00
+	idHeader                = 0x01
+	idArchiveProperties     = 0x02
+	idAdditionalStreamsInfo = 0x03
+	idMainStreamsInfo       = 0x04
+	idFilesInfo             = 0x05
+	idPackInfo              = 0x06
+	idUnpackInfo            = 0x07
+	idSubStreamsInfo        = 0x08
+	idSize                  = 0x09
+	idCRC                   = 0x0A
+	idFolder                = 0x0B
+	idCodersUnpackSize      = 0x0C
+	idNumUnpackStream       = 0x0D
+	idEmptyStream           = 0x0E
+	idEmptyFile             = 0x0F
+	idName                  = 0x11
+	idMTime                 = 0x14
+	idWinAttributes         = 0x15
+	idEncodedHeader         = 0x17
+)
+
+// Methods of 7z coders
+const (
+	methodCopy    = "\x00"
+	methodLZMA2   = "\x21"
+	methodLZMA    = "\x03\x01\x01"
+	methodDeflate = "\x04\x01\x08"
+	methodBZip2   = "\x04\x02\x02"
+	methodAES     = "\x06\xf1\x07\x01"
+)
+
+// errSevenZip is wrapped by the errors of malformed 7z archives
+var errSevenZip = errors.New("invalid 7z archive")
+
+// sevenZipFS serves a 7z archive. Members are compressed together in
+// folders, so opening one decompresses its folder up to it.
+type sevenZipFS struct {
+	path    string
+	root    *node
+	folders []*szFolder
+	files   []*szFile // in the order they are stored
+}
+
+// szFolder is a run of members compressed together by a chain of coders
+type szFolder struct {
+	coders      []szCoder
+	bindPairs   []szBindPair
+	packed      []int   // coder inputs read from packed streams, in order
+	unpackSizes []int64 // sizes of the coder outputs
+	packOffset  int64   // where the first packed stream starts in the file
+	packSizes   []int64
+	crcDefined  bool    // whether the folder's output has a CRC
+	streams     []int64 // sizes of the members in the output
+}
+
+// szCoder is a compression method applied in a folder
+type szCoder struct {
+	method string
+	props  []byte
+}
+
+// szBindPair feeds the output of one coder to the input of another
+type szBindPair struct {
+	in, out int
+}
+
+// szFile is a member of a 7z archive
+type szFile struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	folder  int   // index of the folder holding the contents, -1 if empty
+	offset  int64 // where the contents start in the folder's output
+}
+
+func (f *szFile) Name() string {
+	return f.name[strings.LastIndexByte(f.name, '/')+1:]
+}
+func (f *szFile) Size() int64        { return f.size }
+func (f *szFile) Mode() fs.FileMode  { return f.mode }
+func (f *szFile) ModTime() time.Time { return f.modTime }
+func (f *szFile) IsDir() bool        { return f.mode.IsDir() }
+func (f *szFile) Sys() any           { return nil }
+
+// openSevenZip reads the header of a 7z archive
+func openSevenZip(path string) (FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var start [sevenZipHeaderSize]byte
+	if _, err := io.ReadFull(f, start[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", errSevenZip, err)
+	}
+	if !bytes.Equal(start[:6], sevenZipSignature) {
+		return nil, fmt.Errorf("%w: bad signature", errSevenZip)
+	}
+	if crc32.ChecksumIEEE(start[12:]) != binary.LittleEndian.Uint32(start[8:]) {
+		return nil, fmt.Errorf("%w: start header checksum mismatch", errSevenZip)
+	}
+	offset := binary.LittleEndian.Uint64(start[12:])
+	size := binary.LittleEndian.Uint64(start[20:])
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset > uint64(info.Size()) || size > uint64(info.Size())-offset {
+		return nil, fmt.Errorf("%w: header out of bounds", errSevenZip)
+	}
+
+	header := make([]byte, size)
+	if _, err := f.ReadAt(header, sevenZipHeaderSize+int64(offset)); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(header) != binary.LittleEndian.Uint32(start[28:]) {
+		return nil, fmt.Errorf("%w: header checksum mismatch", errSevenZip)
+	}
+
+	z := &sevenZipFS{path: path, root: newDirNode(".")}
+	// The header is usually compressed itself, into a folder of its own
+	for len(header) > 0 && header[0] == idEncodedHeader {
+		r := &szReader{b: header[1:]}
+		folders := r.streamsInfo()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if len(folders) == 0 {
+			return nil, fmt.Errorf("%w: empty encoded header", errSevenZip)
+		}
+		if folders[0].unpackSize() > maxEncodedHeaderSize {
+			return nil, fmt.Errorf("%w: encoded header too large", errSevenZip)
+		}
+		if header, err = readFolder(f, folders[0]); err != nil {
+			return nil, err
+		}
+	}
+	if err := z.readHeader(header); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// readFolder decompresses all of folder from the archive file f
+func readFolder(f io.ReaderAt, folder *szFolder) ([]byte, error) {
+	r, err := folder.reader(f)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, folder.unpackSize())
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readHeader reads the folders and the members of the archive from its
+// decoded header
+func (z *sevenZipFS) readHeader(header []byte) error {
+	r := &szReader{b: header}
+	if r.byte() != idHeader {
+		return fmt.Errorf("%w: no header", errSevenZip)
+	}
+	id := r.byte()
+	if id == idArchiveProperties {
+		for r.err == nil && r.byte() != idEnd {
+			r.bytes(r.number())
+		}
+		id = r.byte()
+	}
+	if id == idAdditionalStreamsInfo {
+		r.streamsInfo()
+		id = r.byte()
+	}
+	if id == idMainStreamsInfo {
+		z.folders = r.streamsInfo()
+		id = r.byte()
+	}
+	if id == idFilesInfo {
+		z.files = r.filesInfo()
+		id = r.byte()
+	}
+	if r.err == nil && id != idEnd {
+		r.fail("unexpected property %#x in the header", id)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	// Members with contents take the streams of the folders in order
+	folder, stream := 0, 0
+	var offset int64
+	for _, file := range z.files {
+		file.folder = -1
+		if file.size < 0 {
+			// No stream of its own: a directory or an empty file
+			file.size = 0
+		} else {
+			for folder < len(z.folders) && stream == len(z.folders[folder].streams) {
+				folder, stream, offset = folder+1, 0, 0
+			}
+			if folder == len(z.folders) {
+				return fmt.Errorf("%w: more members than streams", errSevenZip)
+			}
+			file.folder, file.offset = folder, offset
+			file.size = z.folders[folder].streams[stream]
+			offset += file.size
+			stream++
+		}
+		if name := memberName(strings.ReplaceAll(file.name, `\`, "/")); name != "" {
+			file.name = name
+			z.root.add(name, file)
+		}
+	}
+	return nil
+}
+
+// reader decompresses the folder, reading its packed streams from f
+func (folder *szFolder) reader(f io.ReaderAt) (io.Reader, error) {
+	packed := make([]io.Reader, len(folder.packSizes))
+	offset := folder.packOffset
+	for i, size := range folder.packSizes {
+		packed[i] = bufio.NewReader(io.NewSectionReader(f, offset, size))
+		offset += size
+	}
+	return folder.output(folder.mainOutput(), packed, 0)
+}
+
+// mainOutput returns the coder whose output is that of the folder, the
+// one not fed to another coder
+func (folder *szFolder) mainOutput() int {
+	for i := range folder.coders {
+		if !slices.ContainsFunc(folder.bindPairs, func(p szBindPair) bool { return p.out == i }) {
+			return i
+		}
+	}
+	return 0
+}
+
+// unpackSize returns the size of the folder's output
+func (folder *szFolder) unpackSize() int64 {
+	if len(folder.unpackSizes) == 0 {
+		return 0
+	}
+	return folder.unpackSizes[folder.mainOutput()]
+}
+
+// output returns the output of coder i, decoding the packed streams or the
+// outputs of other coders it reads
+func (folder *szFolder) output(i int, packed []io.Reader, depth int) (io.Reader, error) {
+	if depth > len(folder.coders) {
+		return nil, fmt.Errorf("%w: coders bound in a cycle", errSevenZip)
+	}
+	var in io.Reader
+	if p := slices.IndexFunc(folder.bindPairs, func(p szBindPair) bool { return p.in == i }); p >= 0 {
+		r, err := folder.output(folder.bindPairs[p].out, packed, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		in = r
+	} else {
+		p := slices.Index(folder.packed, i)
+		if p < 0 || p >= len(packed) {
+			return nil, fmt.Errorf("%w: coder without input", errSevenZip)
+		}
+		in = packed[p]
+	}
+	r, err := folder.coders[i].decode(in, folder.unpackSizes[i])
+	if err != nil {
+		return nil, err
+	}
+	return io.LimitReader(r, folder.unpackSizes[i]), nil
+}
+
+// decode returns the output of the coder reading in, which is size bytes
+func (c szCoder) decode(in io.Reader, size int64) (io.Reader, error) {
+	switch c.method {
+	case methodCopy:
+		return in, nil
+	case methodLZMA:
+		if len(c.props) != 5 {
+			return nil, fmt.Errorf("%w: bad LZMA properties", errSevenZip)
+		}
+		// The classic LZMA header is the properties followed by the size;
+		// the dictionary needn't be larger than the output
+		header := make([]byte, lzma.HeaderLen)
+		copy(header, c.props)
+		dict := binary.LittleEndian.Uint32(c.props[1:])
+		binary.LittleEndian.PutUint32(header[1:], uint32(min(int64(dict), max(size, lzma.MinDictCap))))
+		binary.LittleEndian.PutUint64(header[5:], uint64(size))
+		return lzma.NewReader(io.MultiReader(bytes.NewReader(header), in))
+	case methodLZMA2:
+		if len(c.props) != 1 || c.props[0] > 40 {
+			return nil, fmt.Errorf("%w: bad LZMA2 properties", errSevenZip)
+		}
+		dict := int64(lzma.MaxDictCap)
+		if p := c.props[0]; p < 40 {
+			dict = int64(2|p&1) << (p/2 + 11)
+		}
+		return lzma.Reader2Config{DictCap: int(min(dict, max(size, lzma.MinDictCap)))}.NewReader2(in)
+	case methodDeflate:
+		return flate.NewReader(in), nil
+	case methodBZip2:
+		return bzip2.NewReader(in), nil
+	case methodAES:
+		return nil, errors.New("encrypted 7z archives aren't supported")
+	}
+	return nil, fmt.Errorf("unsupported 7z compression method %x", c.method)
+}
+
+// Open implements fs.FS
+func (z *sevenZipFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	node, ok := z.root.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.children != nil {
+		return node.openDir(), nil
+	}
+
+	file := node.info.(*szFile)
+	if file.folder < 0 {
+		return &memberFile{info: file, r: bytes.NewReader(nil), closer: io.NopCloser(nil)}, nil
+	}
+	f, err := os.Open(z.path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := z.folders[file.folder].reader(f)
+	if err == nil {
+		_, err = io.CopyN(io.Discard, r, file.offset)
+	}
+	if err != nil {
+		f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &memberFile{info: file, r: io.LimitReader(r, file.size), closer: f}, nil
+}
+
+// Walk implements Walker, decompressing each folder once. Directories and
+// empty files come first, in lexical order, then the members with contents
+// in the order they are stored.
+func (z *sevenZipFS) Walk(fn WalkFunc) error {
+	var empty []*szFile
+	for _, file := range z.files {
+		if file.folder < 0 && z.member(file) {
+			empty = append(empty, file)
+		}
+	}
+	slices.SortFunc(empty, func(a, b *szFile) int { return strings.Compare(a.name, b.name) })
+	for _, file := range empty {
+		var r io.Reader
+		if file.mode.IsRegular() {
+			r = bytes.NewReader(nil)
+		}
+		if err := fn(file.name, file, r); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(z.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader
+	folder := -1
+	var offset int64
+	for _, file := range z.files {
+		if file.folder < 0 {
+			continue
+		}
+		if file.folder != folder {
+			folder, offset = file.folder, 0
+			if r, err = z.folders[folder].reader(f); err != nil {
+				return err
+			}
+		}
+		// Members replaced by a later one of the same name are skipped
+		if _, err := io.CopyN(io.Discard, r, file.offset-offset); err != nil {
+			return err
+		}
+		offset = file.offset
+		if !z.member(file) {
+			continue
+		}
+		contents := io.LimitReader(r, file.size)
+		var member io.Reader
+		if file.mode.IsRegular() {
+			member = contents
+		}
+		if err := fn(file.name, file, member); err != nil {
+			return err
+		}
+		// Skip what fn left unread
+		if _, err := io.Copy(io.Discard, contents); err != nil {
+			return err
+		}
+		offset = file.offset + file.size
+	}
+	return nil
+}
+
+// member reports whether file is the member listed under its name, not one
+// replaced by a later member of the same name
+func (z *sevenZipFS) member(file *szFile) bool {
+	node, ok := z.root.lookup(file.name)
+	return ok && node.info == fs.FileInfo(file)
+}
+
+// Close implements FS; the archive is only open while members are read
+func (z *sevenZipFS) Close() error {
+	return nil
+}
+
+// szReader decodes the header of a 7z archive. The first error is kept and
+// ends the decoding: everything read afterwards is zero.
+type szReader struct {
+	b   []byte
+	err error
+}
+
+// fail records an error about a malformed header
+func (r *szReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s", errSevenZip, fmt.Sprintf(format, args...))
+	}
+}
+
+// bytes reads the next n bytes
+func (r *szReader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.b)) {
+		r.fail("header truncated")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *szReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *szReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *szReader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// number reads a variable-length number: the leading one bits of the first
+// byte tell how many more bytes follow, least significant first
+func (r *szReader) number() uint64 {
+	first := r.byte()
+	var n uint64
+	mask := byte(0x80)
+	for i := 0; i < 8; i++ {
+		if first&mask == 0 {
+			return n | uint64(first&(mask-1))<<(8*i)
+		}
+		n |= uint64(r.byte()) << (8 * i)
+		mask >>= 1
+	}
+	return n
+}
+
+// count reads a number of items, refusing more than the header could hold
+func (r *szReader) count() int {
+	n := r.number()
+	if n > uint64(len(r.b))*8+1 {
+		r.fail("too many items")
+		return 0
+	}
+	return int(n)
+}
+
+// bits reads a vector of n bits, most significant first
+func (r *szReader) bits(n int) []bool {
+	b := r.bytes(uint64(n+7) / 8)
+	if b == nil {
+		return make([]bool, n)
+	}
+	bits := make([]bool, n)
+	for i := range bits {
+		bits[i] = b[i/8]&(0x80>>(i%8)) != 0
+	}
+	return bits
+}
+
+// defined reads which of n items have a value: all of them, or those set
+// in a bit vector
+func (r *szReader) defined(n int) []bool {
+	if r.byte() == 0 {
+		return r.bits(n)
+	}
+	all := make([]bool, n)
+	for i := range all {
+		all[i] = true
+	}
+	return all
+}
+
+// digests skips the CRCs of n streams, returning which have one
+func (r *szReader) digests(n int) []bool {
+	defined := r.defined(n)
+	for _, ok := range defined {
+		if ok {
+			r.uint32()
+		}
+	}
+	return defined
+}
+
+// expect reads the property id
+func (r *szReader) expect(id byte) {
+	if got := r.byte(); r.err == nil && got != id {
+		r.fail("property %#x where %#x was expected", got, id)
+	}
+}
+
+// streamsInfo reads where the packed streams are, how the folders decode
+// them and the sizes of the members in the folders
+func (r *szReader) streamsInfo() []*szFolder {
+	var packPos uint64
+	var packSizes []int64
+	var folders []*szFolder
+	substreams := false
+	for r.err == nil {
+		switch id := r.byte(); id {
+		case idEnd:
+			// Folders take the packed streams in order
+			offset := int64(sevenZipHeaderSize + packPos)
+			next := 0
+			for _, folder := range folders {
+				if next+len(folder.packed) > len(packSizes) {
+					r.fail("folders without packed streams")
+					return nil
+				}
+				folder.packOffset = offset
+				folder.packSizes = packSizes[next : next+len(folder.packed)]
+				for _, size := range folder.packSizes {
+					offset += size
+				}
+				next += len(folder.packed)
+				if !substreams {
+					folder.streams = []int64{folder.unpackSize()}
+				}
+			}
+			return folders
+		case idPackInfo:
+			packPos = r.number()
+			packSizes = make([]int64, r.count())
+			for r.err == nil {
+				id := r.byte()
+				if id == idEnd {
+					break
+				}
+				switch id {
+				case idSize:
+					for i := range packSizes {
+						packSizes[i] = r.size()
+					}
+				case idCRC:
+					r.digests(len(packSizes))
+				default:
+					r.fail("unexpected property %#x in the pack info", id)
+				}
+			}
+		case idUnpackInfo:
+			folders = r.unpackInfo()
+		case idSubStreamsInfo:
+			r.subStreamsInfo(folders)
+			substreams = true
+		default:
+			r.fail("unexpected property %#x in the streams info", id)
+		}
+	}
+	return nil
+}
+
+// size reads a size, refusing those too large to handle
+func (r *szReader) size() int64 {
+	n := r.number()
+	if n > 1<<62 {
+		r.fail("size out of range")
+		return 0
+	}
+	return int64(n)
+}
+
+// unpackInfo reads the folders and the sizes of their outputs
+func (r *szReader) unpackInfo() []*szFolder {
+	r.expect(idFolder)
+	folders := make([]*szFolder, r.count())
+	if r.byte() != 0 {
+		r.fail("external folders aren't supported")
+	}
+	for i := range folders {
+		folders[i] = r.folder()
+	}
+	r.expect(idCodersUnpackSize)
+	for _, folder := range folders {
+		for i := range folder.unpackSizes {
+			folder.unpackSizes[i] = r.size()
+		}
+	}
+	for r.err == nil {
+		switch id := r.byte(); id {
+		case idEnd:
+			return folders
+		case idCRC:
+			for i, ok := range r.digests(len(folders)) {
+				folders[i].crcDefined = ok
+			}
+		default:
+			r.fail("unexpected property %#x in the unpack info", id)
+		}
+	}
+	return nil
+}
+
+// folder reads the coders of a folder and how they are connected. Only
+// coders with one input and one output are supported, which covers all
+// but the BCJ2 filter.
+func (r *szReader) folder() *szFolder {
+	folder := &szFolder{coders: make([]szCoder, r.count())}
+	for i := range folder.coders {
+		flags := r.byte()
+		c := szCoder{method: string(r.bytes(uint64(flags & 0x0F)))}
+		if flags&0x10 != 0 {
+			if in, out := r.number(), r.number(); r.err == nil && (in != 1 || out != 1) {
+				r.fail("coders with several streams aren't supported")
+			}
+		}
+		if flags&0x20 != 0 {
+			c.props = r.bytes(r.number())
+		}
+		if flags&0x80 != 0 {
+			r.fail("alternative methods aren't supported")
+		}
+		folder.coders[i] = c
+	}
+	if r.err != nil || len(folder.coders) == 0 {
+		r.fail("folder without coders")
+		return folder
+	}
+	folder.unpackSizes = make([]int64, len(folder.coders))
+	folder.bindPairs = make([]szBindPair, len(folder.coders)-1)
+	for i := range folder.bindPairs {
+		in, out := r.number(), r.number()
+		if r.err == nil && (in >= uint64(len(folder.coders)) || out >= uint64(len(folder.coders))) {
+			r.fail("coder bound out of range")
+			return folder
+		}
+		folder.bindPairs[i] = szBindPair{in: int(in), out: int(out)}
+	}
+
+	// Every input but the one read from the packed stream is bound to an
+	// output of another coder, and every output but the folder's is bound
+	// to one input
+	var ins, outs []int
+	for i := range folder.coders {
+		if !slices.ContainsFunc(folder.bindPairs, func(p szBindPair) bool { return p.in == i }) {
+			ins = append(ins, i)
+		}
+		if !slices.ContainsFunc(folder.bindPairs, func(p szBindPair) bool { return p.out == i }) {
+			outs = append(outs, i)
+		}
+	}
+	if r.err == nil && (len(ins) != 1 || len(outs) != 1) {
+		r.fail("coders bound to %d packed streams and %d outputs", len(ins), len(outs))
+		return folder
+	}
+	folder.packed = ins
+	return folder
+}
+
+// subStreamsInfo reads the sizes of the members in each folder
+func (r *szReader) subStreamsInfo(folders []*szFolder) {
+	counts := make([]int, len(folders))
+	for i := range counts {
+		counts[i] = 1
+	}
+	id := r.byte()
+	if id == idNumUnpackStream {
+		for i := range counts {
+			counts[i] = r.count()
+		}
+		id = r.byte()
+	}
+	for i, folder := range folders {
+		folder.streams = make([]int64, counts[i])
+		if counts[i] == 0 {
+			continue
+		}
+		var sum int64
+		if id == idSize {
+			for j := range counts[i] - 1 {
+				folder.streams[j] = r.size()
+				sum += folder.streams[j]
+			}
+		}
+		last := folder.unpackSize() - sum
+		if last < 0 {
+			r.fail("members larger than their folder")
+			return
+		}
+		folder.streams[counts[i]-1] = last
+	}
+	if id == idSize {
+		id = r.byte()
+	}
+	for r.err == nil && id != idEnd {
+		if id != idCRC {
+			r.fail("unexpected property %#x in the substreams info", id)
+			return
+		}
+		// Folders of one member with a CRC of their own have it already
+		n := 0
+		for i, folder := range folders {
+			if counts[i] != 1 || !folder.crcDefined {
+				n += counts[i]
+			}
+		}
+		r.digests(n)
+		id = r.byte()
+	}
+}
+
+// filesInfo reads the names, times and attributes of the members. Those
+// without a stream are given a size of -1.
+func (r *szReader) filesInfo() []*szFile {
+	files := make([]*szFile, r.count())
+	for i := range files {
+		files[i] = &szFile{}
+	}
+	var emptyStream, emptyFile []bool
+	var attrs []uint32
+	var hasAttrs []bool
+	for r.err == nil {
+		id := r.byte()
+		if id == idEnd {
+			break
+		}
+		// Each property says how long it is, so unknown ones are skipped
+		p := &szReader{b: r.bytes(r.number())}
+		switch id {
+		case idEmptyStream:
+			emptyStream = p.bits(len(files))
+		case idEmptyFile:
+			emptyFile = p.bits(countTrue(emptyStream))
+		case idName:
+			if p.byte() != 0 {
+				p.fail("external names aren't supported")
+			}
+			for _, file := range files {
+				file.name = p.name()
+			}
+		case idMTime:
+			defined := p.defined(len(files))
+			if p.byte() != 0 {
+				p.fail("external times aren't supported")
+			}
+			for i, ok := range defined {
+				if ok {
+					files[i].modTime = filetime(p.uint64())
+				}
+			}
+		case idWinAttributes:
+			hasAttrs = p.defined(len(files))
+			if p.byte() != 0 {
+				p.fail("external attributes aren't supported")
+			}
+			attrs = make([]uint32, len(files))
+			for i, ok := range hasAttrs {
+				if ok {
+					attrs[i] = p.uint32()
+				}
+			}
+		}
+		if p.err != nil {
+			r.err = p.err
+		}
+	}
+
+	empty := 0
+	for i, file := range files {
+		dir := false
+		if i < len(emptyStream) && emptyStream[i] {
+			file.size = -1
+			dir = empty >= len(emptyFile) || !emptyFile[empty]
+			empty++
+		}
+		var attr uint32
+		if i < len(hasAttrs) && hasAttrs[i] {
+			attr = attrs[i]
+		}
+		file.mode = fileMode(attr, dir)
+	}
+	return files
+}
+
+// name reads a zero-terminated UTF-16 name
+func (r *szReader) name() string {
+	var units []uint16
+	for r.err == nil {
+		u := uint16(r.byte()) | uint16(r.byte())<<8
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// countTrue counts the set bits of a bit vector
+func countTrue(bits []bool) int {
+	n := 0
+	for _, b := range bits {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// filetime converts a Windows FILETIME, 100 ns intervals since 1601
+func filetime(ft uint64) time.Time {
+	const epochDiff = 116444736000000000 // from 1601 to 1970
+	return time.Unix(0, 0).Add(time.Duration(int64(ft)-epochDiff) * 100).UTC()
+}
+
+// fileMode converts the attributes of a member: Unix modes are kept in the
+// high 16 bits when the 0x8000 flag is set, otherwise there are only the
+// Windows read-only and directory flags
+func fileMode(attr uint32, dir bool) fs.FileMode {
+	const (
+		readOnly      = 0x01
+		directory     = 0x10
+		unixExtension = 0x8000
+	)
+	dir = dir || attr&directory != 0
+	if attr&unixExtension != 0 {
+		unix := attr >> 16
+		mode := fs.FileMode(unix & 0o777)
+		switch unix & 0o170000 {
+		case 0o040000:
+			mode |= fs.ModeDir
+		case 0o120000:
+			mode |= fs.ModeSymlink
+		}
+		if dir {
+			mode |= fs.ModeDir
+		}
+		return mode
+	}
+	switch {
+	case dir:
+		return fs.ModeDir | 0o755
+	case attr&readOnly != 0:
+		return 0o444
+	}
+	return 0o644
+}
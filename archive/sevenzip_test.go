@@ -0,0 +1,414 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// szMember is a member written by writeSevenZip
+type szMember struct {
+	name  string
+	data  string
+	dir   bool
+	empty bool   // an empty file, which has no stream
+	unix  uint32 // Unix mode kept in the attributes, if not zero
+}
+
+var szTestTime = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+// szNumber appends a 7z variable-length number
+func szNumber(b *bytes.Buffer, n uint64) {
+	for i := 0; i < 8; i++ {
+		if n < 1<<(7*(i+1)) {
+			b.WriteByte(byte(0xFF<<(8-i)) | byte(n>>(8*i)))
+			for j := 0; j < i; j++ {
+				b.WriteByte(byte(n >> (8 * j)))
+			}
+			return
+		}
+	}
+	b.WriteByte(0xFF)
+	binary.Write(b, binary.LittleEndian, n)
+}
+
+// szBits appends a bit vector
+func szBits(b *bytes.Buffer, bits []bool) {
+	v := make([]byte, (len(bits)+7)/8)
+	for i, set := range bits {
+		if set {
+			v[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	b.Write(v)
+}
+
+// szProperty appends a property of the files info with its size
+func szProperty(b *bytes.Buffer, id byte, p []byte) {
+	b.WriteByte(id)
+	szNumber(b, uint64(len(p)))
+	b.Write(p)
+}
+
+// szCompress compresses data with method, returning the coder properties
+func szCompress(t *testing.T, method string, data []byte) (packed, props []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	switch method {
+	case methodCopy:
+		return data, nil
+	case methodLZMA2:
+		w, err := lzma.Writer2Config{DictCap: 1 << 20}.NewWriter2(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes(), []byte{16} // 1 MiB
+	case methodLZMA:
+		w, err := lzma.WriterConfig{DictCap: 1 << 16, SizeInHeader: true, Size: int64(len(data))}.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		b := buf.Bytes()
+		return b[lzma.HeaderLen:], b[:5]
+	}
+	t.Fatalf("can't compress with %x", method)
+	return nil, nil
+}
+
+// szFolderInfo appends the unpack info of a folder of one coder
+func szFolderInfo(b *bytes.Buffer, method string, props []byte, size int, crc *uint32) {
+	b.WriteByte(idUnpackInfo)
+	b.WriteByte(idFolder)
+	szNumber(b, 1)
+	b.WriteByte(0) // not external
+	szNumber(b, 1) // coders
+	flags := byte(len(method))
+	if props != nil {
+		flags |= 0x20
+	}
+	b.WriteByte(flags)
+	b.WriteString(method)
+	if props != nil {
+		szNumber(b, uint64(len(props)))
+		b.Write(props)
+	}
+	b.WriteByte(idCodersUnpackSize)
+	szNumber(b, uint64(size))
+	if crc != nil {
+		b.WriteByte(idCRC)
+		b.WriteByte(1)
+		binary.Write(b, binary.LittleEndian, *crc)
+	}
+	b.WriteByte(idEnd)
+}
+
+// writeSevenZip writes a 7z archive of members, with their contents in a
+// single folder compressed by method, and the header compressed with LZMA
+// if encodeHeader is set
+func writeSevenZip(t *testing.T, path string, members []szMember, method string, encodeHeader bool) {
+	t.Helper()
+	var unpacked []byte
+	var sizes []int
+	var crcs []uint32
+	for _, m := range members {
+		if !m.dir && !m.empty {
+			unpacked = append(unpacked, m.data...)
+			sizes = append(sizes, len(m.data))
+			crcs = append(crcs, crc32.ChecksumIEEE([]byte(m.data)))
+		}
+	}
+	packed, props := szCompress(t, method, unpacked)
+
+	var h bytes.Buffer
+	h.WriteByte(idHeader)
+	h.WriteByte(idMainStreamsInfo)
+	h.WriteByte(idPackInfo)
+	szNumber(&h, 0)
+	szNumber(&h, 1)
+	h.WriteByte(idSize)
+	szNumber(&h, uint64(len(packed)))
+	h.WriteByte(idEnd)
+	szFolderInfo(&h, method, props, len(unpacked), nil)
+	h.WriteByte(idSubStreamsInfo)
+	h.WriteByte(idNumUnpackStream)
+	szNumber(&h, uint64(len(sizes)))
+	h.WriteByte(idSize)
+	for _, size := range sizes[:len(sizes)-1] {
+		szNumber(&h, uint64(size))
+	}
+	h.WriteByte(idCRC)
+	h.WriteByte(1)
+	for _, crc := range crcs {
+		binary.Write(&h, binary.LittleEndian, crc)
+	}
+	h.WriteByte(idEnd)
+	h.WriteByte(idEnd)
+
+	h.WriteByte(idFilesInfo)
+	szNumber(&h, uint64(len(members)))
+	var emptyStream, emptyFile []bool
+	var names, times, attrs bytes.Buffer
+	names.WriteByte(0)
+	times.Write([]byte{1, 0})
+	attrs.Write([]byte{1, 0})
+	for _, m := range members {
+		noStream := m.dir || m.empty
+		emptyStream = append(emptyStream, noStream)
+		if noStream {
+			emptyFile = append(emptyFile, m.empty)
+		}
+		binary.Write(&names, binary.LittleEndian, append(utf16.Encode([]rune(m.name)), 0))
+		binary.Write(&times, binary.LittleEndian, uint64(szTestTime.UnixNano()/100+116444736000000000))
+		var attr uint32
+		if m.dir {
+			attr |= 0x10
+		}
+		if m.unix != 0 {
+			attr |= 0x8000 | m.unix<<16
+		}
+		binary.Write(&attrs, binary.LittleEndian, attr)
+	}
+	var p bytes.Buffer
+	szBits(&p, emptyStream)
+	szProperty(&h, idEmptyStream, p.Bytes())
+	p.Reset()
+	szBits(&p, emptyFile)
+	szProperty(&h, idEmptyFile, p.Bytes())
+	szProperty(&h, 0x19, []byte{0, 0}) // padding, skipped
+	szProperty(&h, idName, names.Bytes())
+	szProperty(&h, idMTime, times.Bytes())
+	szProperty(&h, idWinAttributes, attrs.Bytes())
+	h.WriteByte(idEnd)
+	h.WriteByte(idEnd)
+
+	header := h.Bytes()
+	if encodeHeader {
+		packedHeader, props := szCompress(t, methodLZMA, header)
+		var e bytes.Buffer
+		e.WriteByte(idEncodedHeader)
+		e.WriteByte(idPackInfo)
+		szNumber(&e, uint64(len(packed)))
+		szNumber(&e, 1)
+		e.WriteByte(idSize)
+		szNumber(&e, uint64(len(packedHeader)))
+		e.WriteByte(idEnd)
+		crc := crc32.ChecksumIEEE(header)
+		szFolderInfo(&e, methodLZMA, props, len(header), &crc)
+		e.WriteByte(idEnd)
+		packed = append(packed, packedHeader...)
+		header = e.Bytes()
+	}
+	writeSevenZipHeader(t, path, packed, header)
+}
+
+// writeSevenZipHeader writes a 7z archive of the packed streams followed
+// by header
+func writeSevenZipHeader(t *testing.T, path string, packed, header []byte) {
+	t.Helper()
+	start := make([]byte, sevenZipHeaderSize)
+	copy(start, sevenZipSignature)
+	start[7] = 4
+	binary.LittleEndian.PutUint64(start[12:], uint64(len(packed)))
+	binary.LittleEndian.PutUint64(start[20:], uint64(len(header)))
+	binary.LittleEndian.PutUint32(start[28:], crc32.ChecksumIEEE(header))
+	binary.LittleEndian.PutUint32(start[8:], crc32.ChecksumIEEE(start[12:]))
+
+	archive := slices.Concat(start, packed, header)
+	if err := os.WriteFile(path, archive, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSevenZip(t *testing.T) {
+	members := []szMember{
+		{name: "docs", dir: true},
+		{name: "docs/a.txt", data: "hello"},
+		{name: "docs/b.txt", data: string(bytes.Repeat([]byte("lorem ipsum\n"), 500))},
+		{name: "empty.txt", empty: true},
+		{name: `bin\tool`, data: "#!/bin/sh\n", unix: 0o100755},
+	}
+	tests := []struct {
+		name         string
+		method       string
+		encodeHeader bool
+	}{
+		{"lzma2", methodLZMA2, true},
+		{"lzma", methodLZMA, false},
+		{"copy", methodCopy, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.7z")
+			writeSevenZip(t, path, members, tt.method, tt.encodeHeader)
+			fsys, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fsys.Close()
+
+			if err := fstest.TestFS(fsys, "docs/a.txt", "docs/b.txt", "empty.txt", "bin/tool"); err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range members {
+				if m.dir {
+					continue
+				}
+				// Backslashes of archives made on Windows separate directories too
+				name := strings.ReplaceAll(m.name, `\`, "/")
+				data, err := fs.ReadFile(fsys, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != m.data {
+					t.Errorf("%s holds %q, want %q", name, data, m.data)
+				}
+			}
+			info, err := fs.Stat(fsys, "bin/tool")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode() != 0o755 || !info.ModTime().Equal(szTestTime) {
+				t.Errorf("bin/tool has mode %v and time %v, want %v and %v", info.Mode(), info.ModTime(), fs.FileMode(0o755), szTestTime)
+			}
+			if info, err := fs.Stat(fsys, "docs"); err != nil || !info.IsDir() {
+				t.Errorf("docs isn't a directory: %v", err)
+			}
+
+			// Walking reads every member in one pass
+			walked := map[string]string{}
+			err = Walk(fsys, func(name string, info fs.FileInfo, r io.Reader) error {
+				if r == nil {
+					walked[name] = "(" + info.Mode().Type().String() + ")"
+					return nil
+				}
+				// Leave some unread for Walk to skip
+				b := make([]byte, min(info.Size(), 3))
+				_, err := io.ReadFull(r, b)
+				walked[name] = string(b)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"docs": "(d---------)", "docs/a.txt": "hel", "docs/b.txt": "lor", "empty.txt": "", "bin/tool": "#!/"}
+			for name, w := range want {
+				if walked[name] != w {
+					t.Errorf("walked %s as %q, want %q", name, walked[name], w)
+				}
+			}
+		})
+	}
+}
+
+// szCopyFolders appends the streams info of a folder of copy coders bound
+// by pairs, each coder putting out size bytes
+func szCopyFolders(b *bytes.Buffer, coders int, pairs [][2]uint64, size uint64) {
+	b.WriteByte(idPackInfo)
+	szNumber(b, 0)
+	szNumber(b, 1)
+	b.WriteByte(idSize)
+	szNumber(b, 1)
+	b.WriteByte(idEnd)
+	b.WriteByte(idUnpackInfo)
+	b.WriteByte(idFolder)
+	szNumber(b, 1)
+	b.WriteByte(0)
+	szNumber(b, uint64(coders))
+	for range coders {
+		b.WriteByte(byte(len(methodCopy)))
+		b.WriteString(methodCopy)
+	}
+	for _, p := range pairs {
+		szNumber(b, p[0])
+		szNumber(b, p[1])
+	}
+	b.WriteByte(idCodersUnpackSize)
+	for range coders {
+		szNumber(b, size)
+	}
+	b.WriteByte(idEnd)
+	b.WriteByte(idEnd)
+}
+
+func TestSevenZipCorrupt(t *testing.T) {
+	// A member whose header is damaged after it was written
+	corrupt := func(t *testing.T, path string) {
+		writeSevenZip(t, path, []szMember{{name: "a", data: "a"}}, methodLZMA2, true)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[len(data)-1] ^= 0xFF
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A folder of coders bound by pairs, holding one member
+	bound := func(pairs [][2]uint64) func(*testing.T, string) {
+		return func(t *testing.T, path string) {
+			var h bytes.Buffer
+			h.WriteByte(idHeader)
+			h.WriteByte(idMainStreamsInfo)
+			szCopyFolders(&h, 3, pairs, 1)
+			h.WriteByte(idFilesInfo)
+			szNumber(&h, 1)
+			var names bytes.Buffer
+			names.WriteByte(0)
+			binary.Write(&names, binary.LittleEndian, []uint16{'a', 0})
+			szProperty(&h, idName, names.Bytes())
+			h.WriteByte(idEnd)
+			h.WriteByte(idEnd)
+			writeSevenZipHeader(t, path, []byte("a"), h.Bytes())
+		}
+	}
+	tests := []struct {
+		name  string
+		write func(*testing.T, string)
+	}{
+		{"header checksum", corrupt},
+		{"bound out of range", bound([][2]uint64{{0, 7}, {1, 0}})},
+		{"input bound twice", bound([][2]uint64{{0, 1}, {0, 2}})},
+		{"output bound twice", bound([][2]uint64{{0, 1}, {2, 1}})},
+		{"huge encoded header", func(t *testing.T, path string) {
+			var h bytes.Buffer
+			h.WriteByte(idEncodedHeader)
+			szCopyFolders(&h, 1, nil, 1<<62)
+			writeSevenZipHeader(t, path, []byte{idHeader}, h.Bytes())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.7z")
+			tt.write(t, path)
+			fsys, err := Open(path)
+			if err == nil {
+				// Reading the member mustn't panic either
+				_, err = fs.ReadFile(fsys, "a")
+				fsys.Close()
+			}
+			if err == nil {
+				t.Error("read a corrupt archive")
+			}
+		})
+	}
+}
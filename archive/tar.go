@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// decompressor unwraps the compression around a tar stream
+type decompressor func(io.Reader) (io.Reader, error)
+
+func gzipReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func bzip2Reader(r io.Reader) (io.Reader, error) {
+	return bzip2.NewReader(r), nil
+}
+
+// tarFS serves a tar archive. The member index is read once when the
+// archive is opened; since compressed streams can't seek, opening a member
+// reads the archive again up to it.
+type tarFS struct {
+	path       string
+	decompress decompressor // nil for plain tar files
+	root       *node
+}
+
+// openTar returns an opener of tar archives compressed with decompress
+func openTar(decompress decompressor) Opener {
+	return func(path string) (FS, error) {
+		t := &tarFS{path: path, decompress: decompress, root: newDirNode(".")}
+		tr, closer, err := t.open()
+		if err != nil {
+			return nil, err
+		}
+		defer closer.Close()
+
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return t, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if name := memberName(hdr.Name); name != "" {
+				t.root.add(name, hdr.FileInfo())
+			}
+		}
+	}
+}
+
+// open starts reading the archive from the beginning
+func (t *tarFS) open() (*tar.Reader, io.Closer, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r io.Reader = bufio.NewReader(f)
+	if t.decompress != nil {
+		if r, err = t.decompress(r); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	return tar.NewReader(r), f, nil
+}
+
+// Open implements fs.FS
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	node, ok := t.root.lookup(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.children != nil {
+		return node.openDir(), nil
+	}
+
+	tr, closer, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			closer.Close()
+			if err == io.EOF {
+				err = fs.ErrNotExist
+			}
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		// A member added again later replaces the earlier one, as with tar -x
+		if memberName(hdr.Name) == name && hdr.ModTime.Equal(node.info.ModTime()) && hdr.Size == node.info.Size() {
+			return &memberFile{info: node.info, r: tr, closer: closer}, nil
+		}
+	}
+}
+
+// Walk implements Walker, reading the archive once from start to end
+func (t *tarFS) Walk(fn WalkFunc) error {
+	tr, closer, err := t.open()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := memberName(hdr.Name)
+		if name == "" {
+			continue
+		}
+		// Skip members replaced by a later one of the same name, as Open does
+		node, ok := t.root.lookup(name)
+		if !ok || !hdr.ModTime.Equal(node.info.ModTime()) || hdr.Size != node.info.Size() {
+			continue
+		}
+		var r io.Reader
+		if node.info.Mode().IsRegular() {
+			r = tr
+		}
+		if err := fn(name, node.info, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Close implements FS; the archive is only open while members are read
+func (t *tarFS) Close() error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// node is a member of an archive, or a directory implied by the paths of
+// the members. Archives that can't list a directory by themselves keep
+// their members in a tree of nodes read when they are opened.
+type node struct {
+	info     fs.FileInfo
+	children map[string]*node // nil for anything but directories
+}
+
+// newDirNode creates a directory node that has no member of its own
+func newDirNode(name string) *node {
+	return &node{info: dirInfo(name), children: map[string]*node{}}
+}
+
+// memberName turns the name of an archive member into an fs.FS path, or ""
+// for names that don't denote anything below the root
+func memberName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !fs.ValidPath(name) || name == "." {
+		return ""
+	}
+	return name
+}
+
+// add records a member below root, creating the directories leading to it
+func (root *node) add(name string, info fs.FileInfo) {
+	dir := root
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := dir.children[part]
+		if !ok || child.children == nil {
+			child = newDirNode(part)
+			dir.children[part] = child
+		}
+		dir = child
+	}
+
+	last := parts[len(parts)-1]
+	if info.IsDir() {
+		if existing, ok := dir.children[last]; ok && existing.children != nil {
+			existing.info = info
+			return
+		}
+		dir.children[last] = &node{info: info, children: map[string]*node{}}
+		return
+	}
+	dir.children[last] = &node{info: info}
+}
+
+// lookup finds the node of an fs.FS path below root
+func (root *node) lookup(name string) (*node, bool) {
+	n := root
+	if name == "." {
+		return n, true
+	}
+	for _, part := range strings.Split(name, "/") {
+		child, ok := n.children[part]
+		if !ok {
+			return nil, false
+		}
+		n = child
+	}
+	return n, true
+}
+
+// openDir opens the directory n
+func (n *node) openDir() fs.File {
+	infos := make([]fs.FileInfo, 0, len(n.children))
+	for _, child := range n.children {
+		infos = append(infos, child.info)
+	}
+	return &memberDir{info: n.info, entries: dirEntries(infos)}
+}
+
+// memberFile is an open member of an archive read from a stream
+type memberFile struct {
+	info   fs.FileInfo
+	r      io.Reader
+	closer io.Closer
+}
+
+func (f *memberFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memberFile) Close() error               { return f.closer.Close() }
+
+// Read implements io.Reader. Decompressors may report the end of their
+// stream early to empty reads, so those don't reach them.
+func (f *memberFile) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	return f.r.Read(b)
+}
+
+// memberDir is an open directory of an archive
+type memberDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memberDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memberDir) Close() error               { return nil }
+
+func (d *memberDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *memberDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	rest = rest[:min(n, len(rest))]
+	d.offset += len(rest)
+	return rest, nil
+}
+
+// dirInfo describes a directory implied by the paths of members
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
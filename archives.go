@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/archive"
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// errReadOnly is returned for changes to the contents of archives
+var errReadOnly = errors.New("archives are read-only")
+
+// splitArchivePath splits a path leading into an archive into the archive
+// file and the path of the member, e.g. "/x/a.zip/doc/f.txt" into
+// "/x/a.zip" and "doc/f.txt". The archive itself is member ".". ok is false
+// for paths outside archives. Archives within archives aren't supported,
+// nor are archives on other file systems than the local disk.
+func splitArchivePath(fsys vfs.FS, path string) (file, member string, ok bool) {
+	if !vfs.IsLocal(fsys) {
+		return "", "", false
+	}
+	path = filepath.Clean(path)
+	for p := path; ; {
+		if archive.Match(p) {
+			if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+				rel, _ := filepath.Rel(p, path)
+				return p, filepath.ToSlash(rel), true
+			}
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", "", false
+		}
+		p = parent
+	}
+}
+
+// inArchive reports whether path is an archive or leads into one
+func inArchive(fsys vfs.FS, path string) bool {
+	_, _, ok := splitArchivePath(fsys, path)
+	return ok
+}
+
+// isArchiveFile reports whether info describes an archive that can be
+// browsed, rather than something inside one
+func isArchiveFile(fsys vfs.FS, path string, info fs.FileInfo) bool {
+	return vfs.IsLocal(fsys) && info.Mode().IsRegular() && archive.Match(path) && !inArchive(fsys, filepath.Dir(path))
+}
+
+// refuseInArchive reports the current directory being inside an archive,
+// which operations that change files can't handle
+func (ui *FileExplorerUI) refuseInArchive() bool {
+	if !inArchive(ui.fsys, ui.pane.path) {
+		return false
+	}
+	ui.showError(errReadOnly)
+	return true
+}
+
+// statPath is statFile for paths that may lead into archives
+func statPath(fsys vfs.FS, path string) (fs.FileInfo, error) {
+	file, member, ok := splitArchivePath(fsys, path)
+	if !ok || member == "." {
+		return core.Stat(fsys, path)
+	}
+	afs, err := archive.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer afs.Close()
+	return fs.Stat(afs, member)
+}
+
+// streamArchiveDir lists a directory inside an archive like streamDirContext
+func streamArchiveDir(ctx context.Context, path string, emit func([]fs.DirEntry)) error {
+	file, member, _ := splitArchivePath(vfs.OS(), path)
+	fsys, err := archive.Open(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	defer fsys.Close()
+
+	entries, err := fs.ReadDir(fsys, member)
+	if err != nil {
+		return err
+	}
+	for len(entries) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := min(core.BatchSize, len(entries))
+		emit(entries[:n])
+		entries = entries[n:]
+	}
+	return nil
+}
+
+// renderArchivePreview is renderPreview for archives and their members
+func renderArchivePreview(ctx context.Context, path string, mode previewMode) (r renderedPreview, stable bool) {
+	file, member, _ := splitArchivePath(vfs.OS(), path)
+	fsys, err := archive.Open(file)
+	if err != nil {
+		return renderedPreview{text: fmt.Sprintf("Error: %s", err)}, false
+	}
+	defer fsys.Close()
+
+	info, err := fs.Stat(fsys, member)
+	if err != nil {
+		return renderedPreview{text: fmt.Sprintf("Error: %s", err)}, false
+	}
+	if info.IsDir() {
+		entries, err := fs.ReadDir(fsys, member)
+		if err != nil {
+			return renderedPreview{text: fmt.Sprintf("Error: %s", err)}, false
+		}
+		if member == "." {
+			return renderedPreview{text: fmt.Sprintf("Archive: %s\nContains %d items, Enter browses it",
+				path, len(entries))}, false
+		}
+		return renderedPreview{text: fmt.Sprintf("Directory: %s\nContains %d items", path, len(entries))}, false
+	}
+	if !info.Mode().IsRegular() {
+		return renderedPreview{text: fmt.Sprintf("Archive member: %s\nMode: %s", path, formatPermissions(info.Mode()))}, false
+	}
+	if info.Size() > mode.limit {
+		return renderedPreview{text: fmt.Sprintf("File is too large to preview (%s)", formatSize(info.Size()))}, false
+	}
+
+	f, err := fsys.Open(member)
+	if err != nil {
+		return renderedPreview{text: fmt.Sprintf("Error reading file: %s", err)}, false
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, mode.limit))
+	if err != nil {
+		return renderedPreview{text: fmt.Sprintf("Error reading file: %s", err)}, false
+	}
+	if ctx.Err() != nil {
+		return r, false
+	}
+	r.text, r.encoding = renderContent(path, info.Size(), content, mode)
+	return r, false
+}
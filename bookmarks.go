@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// bookmarksPage is the name of the bookmark picker
+const bookmarksPage = "bookmarks"
+
+// Bookmark is a named directory
+type Bookmark struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+}
+
+// Bookmarks is a list of bookmarked directories stored in a TOML file.
+// Changes are only written to the file by Save.
+type Bookmarks struct {
+	path  string
+	items []Bookmark
+}
+
+// bookmarksFile is the layout of the bookmarks file
+type bookmarksFile struct {
+	Bookmarks []Bookmark `toml:"bookmark"`
+}
+
+// DefaultBookmarksPath returns the location of the user's bookmarks file,
+// next to the config file
+func DefaultBookmarksPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofiles", "bookmarks.toml")
+}
+
+// LoadBookmarks reads the bookmarks file at path. A missing file is not an
+// error, it holds no bookmarks yet.
+func LoadBookmarks(path string) (*Bookmarks, error) {
+	b := &Bookmarks{path: path}
+	if path == "" {
+		return b, nil
+	}
+
+	var file bookmarksFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return b, nil
+		}
+		return b, fmt.Errorf("bookmarks %s: %w", path, err)
+	}
+	b.items = file.Bookmarks
+	return b, nil
+}
+
+// List returns the bookmarks in the order they were added
+func (b *Bookmarks) List() []Bookmark {
+	return slices.Clone(b.items)
+}
+
+// Add bookmarks dir under name, renaming the bookmark if dir already has one
+func (b *Bookmarks) Add(name, dir string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("bookmark name is empty")
+	}
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("bookmark %s is not an absolute path", dir)
+	}
+	dir = filepath.Clean(dir)
+	if i := b.index(dir); i >= 0 {
+		b.items[i].Name = name
+		return nil
+	}
+	b.items = append(b.items, Bookmark{Name: name, Path: dir})
+	return nil
+}
+
+// Remove deletes the bookmark of dir, reporting whether there was one
+func (b *Bookmarks) Remove(dir string) bool {
+	i := b.index(filepath.Clean(dir))
+	if i < 0 {
+		return false
+	}
+	b.items = slices.Delete(b.items, i, i+1)
+	return true
+}
+
+// index returns the position of the bookmark of dir, or -1
+func (b *Bookmarks) index(dir string) int {
+	return slices.IndexFunc(b.items, func(bm Bookmark) bool { return bm.Path == dir })
+}
+
+// Save writes the bookmarks to their file, replacing it in one step so a
+// failed write leaves the previous bookmarks intact
+func (b *Bookmarks) Save() error {
+	if b.path == "" {
+		return errors.New("no bookmarks file")
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(bookmarksFile{b.items}); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.path, buf.Bytes())
+}
+
+// writeFileAtomic replaces the file at path with data in one step, creating
+// its directory if needed, so a failed write leaves the old contents intact
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// addBookmark asks for a name and bookmarks the current directory. The file
+// is read again first so bookmarks added elsewhere are kept.
+func (ui *FileExplorerUI) addBookmark() {
+	dir := ui.pane.path
+	if ui.pane.inPathList() {
+		ui.setFooterError("Only directories can be bookmarked")
+		return
+	}
+	ui.prompt("Bookmark "+dir+" as", filepath.Base(dir), func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		bookmarks, err := LoadBookmarks(ui.bookmarksPath)
+		if err == nil {
+			err = bookmarks.Add(name, dir)
+		}
+		if err == nil {
+			err = bookmarks.Save()
+		}
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Bookmarked %s as %q", dir, name)))
+	})
+}
+
+// showBookmarks opens a picker of the bookmarked directories. Enter goes to
+// the selected one and Delete removes its bookmark.
+func (ui *FileExplorerUI) showBookmarks() {
+	bookmarks, err := LoadBookmarks(ui.bookmarksPath)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	if len(bookmarks.List()) == 0 {
+		ui.setFooterStatus("No bookmarks yet")
+		return
+	}
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle(fmt.Sprintf("Bookmarks - %s Go | %s Remove | %s Close",
+		ui.titleKey("Enter"), ui.titleKey("Delete"), ui.titleKey("Esc")))
+	fill := func() {
+		list.Clear()
+		for _, bm := range bookmarks.List() {
+			list.AddItem(tview.Escape(bm.Name), tview.Escape(bm.Path), 0, nil)
+		}
+	}
+	fill()
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.closePage(bookmarksPage)
+		ui.navigate(bookmarks.List()[index].Path)
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(bookmarksPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyDelete {
+			return event
+		}
+		bm := bookmarks.List()[list.GetCurrentItem()]
+		bookmarks.Remove(bm.Path)
+		if err := bookmarks.Save(); err != nil {
+			ui.showError(err)
+			return nil
+		}
+		ui.setFooterStatus(tview.Escape("Removed bookmark " + bm.Name))
+		if len(bookmarks.List()) == 0 {
+			ui.closePage(bookmarksPage)
+			return nil
+		}
+		current := list.GetCurrentItem()
+		fill()
+		list.SetCurrentItem(min(current, list.GetItemCount()-1))
+		return nil
+	})
+
+	ui.showPage(bookmarksPage, centered(list, 70, min(2*len(bookmarks.List())+2, 24)))
+}
@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// crumb is a segment of the path in the header and the directory it leads to
+type crumb struct {
+	label string
+	dir   string
+	x     int // screen column of the label at the last draw
+	width int
+}
+
+// breadcrumb is the header. It shows the directory of the active pane as a
+// row of its ancestors, each of which goes to that directory when clicked,
+// or when selected with the arrows and Enter once the header has the focus.
+// Path lists are shown by their title instead.
+type breadcrumb struct {
+	*tview.Box
+	ui       *FileExplorerUI
+	title    string  // shown instead of crumbs, for path lists
+	crumbs   []crumb // from the root down to the directory shown
+	selected int     // crumb Enter goes to while focused
+}
+
+// newBreadcrumb creates an empty header
+func newBreadcrumb(ui *FileExplorerUI) *breadcrumb {
+	return &breadcrumb{Box: tview.NewBox(), ui: ui}
+}
+
+// setPath shows path as crumbs, from the root down
+func (b *breadcrumb) setPath(path string) {
+	b.title = ""
+	b.crumbs = b.crumbs[:0]
+	for dir := path; ; {
+		parent := filepath.Dir(dir)
+		label := filepath.Base(dir)
+		if parent == dir {
+			label = dir // the root, with its separator
+		}
+		b.crumbs = append([]crumb{{label: label, dir: dir}}, b.crumbs...)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	b.selected = len(b.crumbs) - 1
+}
+
+// setTitle shows title in place of a path
+func (b *breadcrumb) setTitle(title string) {
+	b.title = title
+	b.crumbs = nil
+}
+
+// Draw implements tview.Primitive
+func (b *breadcrumb) Draw(screen tcell.Screen) {
+	b.Box.DrawForSubclass(screen, b)
+	x, y, width, _ := b.GetInnerRect()
+	theme := b.ui.theme
+
+	banner := fmt.Sprintf("[%s::b]File Explorer - ", theme.HeaderText)
+	if b.ui.config.RootWarning && os.Geteuid() == 0 {
+		banner = styleTag(theme.RootBannerText, theme.RootBanner, "b") + " RUNNING AS ROOT [-:-:-] " + banner
+	}
+
+	// Lay the crumbs out after the banner, centered while they fit
+	total := tview.TaggedStringWidth(banner)
+	if b.title != "" {
+		total += tview.TaggedStringWidth(tview.Escape(b.title))
+	}
+	for i := range b.crumbs {
+		b.crumbs[i].width = tview.TaggedStringWidth(tview.Escape(b.crumbs[i].label))
+		total += b.crumbs[i].width
+		if b.separated(i) {
+			total++
+		}
+	}
+	left := x + max((width-total)/2, 0)
+
+	_, w := tview.Print(screen, banner, left, y, x+width-left, tview.AlignLeft, theme.HeaderText)
+	left += w
+	if b.title != "" {
+		tview.Print(screen, "[::b]"+tview.Escape(b.title), left, y, x+width-left, tview.AlignLeft, theme.HeaderText)
+		return
+	}
+	style := tcell.StyleDefault.Background(theme.Selection)
+	for i := range b.crumbs {
+		c := &b.crumbs[i]
+		c.x = left
+		if i == b.selected && b.HasFocus() {
+			for col := left; col < min(left+c.width, x+width); col++ {
+				screen.SetContent(col, y, ' ', nil, style)
+			}
+		}
+		color := theme.HeaderText
+		if i == b.selected && b.HasFocus() {
+			color = theme.SelectionText
+		}
+		_, w := tview.Print(screen, "[::b]"+tview.Escape(c.label), left, y, x+width-left, tview.AlignLeft, color)
+		left += w
+		if b.separated(i) {
+			_, w := tview.Print(screen, "[::b]"+string(filepath.Separator), left, y, x+width-left, tview.AlignLeft, theme.HeaderText)
+			left += w
+		}
+	}
+}
+
+// separated reports whether a separator follows crumb i; the root's label
+// already ends in one
+func (b *breadcrumb) separated(i int) bool {
+	return i > 0 && i < len(b.crumbs)-1 || i == 0 && len(b.crumbs) > 1 && !os.IsPathSeparator(b.crumbs[0].label[len(b.crumbs[0].label)-1])
+}
+
+// activate goes to the directory of crumb i and hands the focus back to the
+// listing
+func (b *breadcrumb) activate(i int) {
+	dir := b.crumbs[i].dir
+	b.ui.setFocus(b.ui.pane.table)
+	if dir != b.ui.pane.path {
+		b.ui.navigate(dir)
+	}
+}
+
+// InputHandler implements tview.Primitive: Left and Right select a crumb,
+// Enter goes there and Escape returns to the listing
+func (b *breadcrumb) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			b.selected = max(b.selected-1, 0)
+		case tcell.KeyRight:
+			b.selected = min(b.selected+1, len(b.crumbs)-1)
+		case tcell.KeyHome:
+			b.selected = 0
+		case tcell.KeyEnd:
+			b.selected = len(b.crumbs) - 1
+		case tcell.KeyEnter:
+			if b.selected < len(b.crumbs) {
+				b.activate(b.selected)
+			}
+		case tcell.KeyEscape, tcell.KeyTab, tcell.KeyDown:
+			setFocus(b.ui.pane.table)
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'h':
+				b.selected = max(b.selected-1, 0)
+			case 'l':
+				b.selected = min(b.selected+1, len(b.crumbs)-1)
+			}
+		}
+	})
+}
+
+// MouseHandler implements tview.Primitive: clicking a crumb goes there,
+// leaving the focus where it is
+func (b *breadcrumb) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return b.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+		if action != tview.MouseLeftClick || !b.InRect(x, y) {
+			return false, nil
+		}
+		for i, c := range b.crumbs {
+			if x >= c.x && x < c.x+c.width {
+				b.activate(i)
+				break
+			}
+		}
+		return true, nil
+	})
+}
+
+// focusBreadcrumb moves the focus to the header, selecting the current
+// directory, so an ancestor can be picked from the keyboard
+func (ui *FileExplorerUI) focusBreadcrumb() {
+	if len(ui.header.crumbs) == 0 {
+		return
+	}
+	ui.header.selected = len(ui.header.crumbs) - 1
+	ui.setFocus(ui.header)
+}
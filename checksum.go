@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// checksumPage is the name of the checksum results
+const checksumPage = "checksum"
+
+// checksumAlgorithms are the digests computed, in the order shown
+var checksumAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"MD5", md5.New},
+	{"SHA1", sha1.New},
+	{"SHA256", sha256.New},
+}
+
+// checksum is a digest of a file
+type checksum struct {
+	path      string
+	algorithm string
+	digest    string
+}
+
+// hashFile computes every checksum of the file at path in fsys in one read,
+// adding the bytes read to done as it goes
+func hashFile(ctx context.Context, fsys vfs.FS, path string, done *ops.Counter) ([]checksum, error) {
+	f, err := core.Open(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make([]hash.Hash, len(checksumAlgorithms))
+	writers := make([]io.Writer, len(checksumAlgorithms))
+	for i, a := range checksumAlgorithms {
+		hashes[i] = a.new()
+		writers[i] = hashes[i]
+	}
+	w := io.MultiWriter(writers...)
+
+	chunk := make([]byte, 256*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := f.Read(chunk)
+		w.Write(chunk[:n])
+		done.Add(int64(n))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sums := make([]checksum, len(hashes))
+	for i, h := range hashes {
+		sums[i] = checksum{path, checksumAlgorithms[i].name, hex.EncodeToString(h.Sum(nil))}
+	}
+	return sums, nil
+}
+
+// showChecksums computes the MD5, SHA1 and SHA256 checksums of the selected
+// files in the background and lists them as they are done, with the
+// progress through the files in a dialog and in the title. Enter copies the
+// selected digest to the clipboard; Esc stops a running computation, or else
+// closes the list.
+func (ui *FileExplorerUI) showChecksums() {
+	if ui.refuseInArchive() {
+		return
+	}
+	var paths []string
+	var total int64
+	for _, path := range ui.SelectedPaths() {
+		if info, err := core.Stat(ui.fsys, path); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+			total += info.Size()
+		}
+	}
+	if len(paths) == 0 {
+		ui.setFooterError("Select files to compute checksums of")
+		return
+	}
+
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetBorderColor(ui.theme.ResultsBorder)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.Selection).Foreground(ui.theme.SelectionText))
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	var sums []checksum
+	done := ops.NewCounter(total)
+	running := true
+	status := "computing..., Esc stops"
+
+	setTitle := func() {
+		progress := status
+		if running && total > 0 {
+			progress = fmt.Sprintf("%d%%, %s", done.Stats().Done*100/total, status)
+		}
+		table.SetTitle(fmt.Sprintf("Checksums of %s - %s Copy %s(%s)[-]",
+			plural(int64(len(paths)), "file", "files"), ui.titleKey("Enter"), colorTag(ui.theme.Muted), progress))
+	}
+	setTitle()
+
+	table.SetSelectedFunc(func(row, _ int) {
+		if row >= len(sums) {
+			return
+		}
+		sum := sums[row]
+		ui.copyText(sum.digest, fmt.Sprintf("the %s of %s", sum.algorithm, filepath.Base(sum.path)))
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyEscape {
+			return event
+		}
+		if running {
+			cancel()
+			return nil
+		}
+		ui.closePage(checksumPage)
+		return nil
+	})
+
+	dialog := ui.newProgressDialog("Computing the checksums of "+plural(int64(len(paths)), "file", "files"),
+		cancel, func() {})
+
+	ui.goBackground(func() {
+		defer cancel()
+		ui.goBackground(func() {
+			for stats := range done.Watch(ctx, progressInterval) {
+				ui.queueUpdateDraw(func() {
+					setTitle()
+					dialog.update("", stats)
+				})
+			}
+		})
+
+		var errs []error
+		for _, path := range paths {
+			fileSums, err := hashFile(ctx, ui.fsys, path, done)
+			if errors.Is(err, context.Canceled) {
+				break
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			ui.queueUpdateDraw(func() {
+				for i, sum := range fileSums {
+					row := len(sums)
+					name := ""
+					if i == 0 {
+						name = tview.Escape(filepath.Base(sum.path))
+					}
+					table.SetCell(row, 0, tview.NewTableCell(name).SetTextColor(ui.theme.Directory))
+					table.SetCell(row, 1, tview.NewTableCell(sum.algorithm).SetTextColor(ui.theme.Dim))
+					table.SetCell(row, 2, tview.NewTableCell(sum.digest).SetExpansion(1))
+					sums = append(sums, sum)
+				}
+			})
+		}
+		err := errors.Join(errs...)
+		stopped := ctx.Err() != nil
+		ui.queueUpdateDraw(func() {
+			running = false
+			dialog.close()
+			switch {
+			case stopped:
+				status = "stopped"
+			case err != nil:
+				status = "failed for some files"
+				ui.showError(err)
+			default:
+				status = "done"
+			}
+			setTitle()
+		})
+	})
+
+	ui.showPage(checksumPage, panel(table))
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// clipboardLimit bounds the size of the files whose contents are copied
+const clipboardLimit = 1 << 20
+
+// errNoClipboard is reported when no clipboard tool is installed
+var errNoClipboard = errors.New("no clipboard tool found (pbcopy, wl-copy, xclip or xsel)")
+
+// clipboardCommands returns the commands that may put their input on the
+// system clipboard, in order of preference
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+	cmds := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append([][]string{{"wl-copy"}}, cmds...)
+	}
+	return cmds
+}
+
+// pasteCommands returns the commands that may print the contents of the
+// system clipboard, in order of preference
+func pasteCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	}
+	cmds := [][]string{{"xclip", "-o", "-selection", "clipboard"}, {"xsel", "--clipboard", "--output"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append([][]string{{"wl-paste", "-n"}}, cmds...)
+	}
+	return cmds
+}
+
+// inSSHSession reports whether the explorer runs over ssh, where clipboard
+// tools would reach the remote machine's clipboard rather than the user's
+func inSSHSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// copyToClipboard puts text on the system clipboard with the first
+// clipboard tool that is installed. Its output isn't captured: xclip
+// leaves a daemon serving the selection that would keep the pipe open.
+func copyToClipboard(ctx context.Context, text string) error {
+	for _, args := range clipboardCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		cmd.WaitDelay = time.Second
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		return nil
+	}
+	return errNoClipboard
+}
+
+// readClipboard returns the contents of the system clipboard, read with
+// the first clipboard tool that is installed
+func readClipboard() (string, error) {
+	for _, args := range pasteCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", args[0], err)
+		}
+		return string(out), nil
+	}
+	return "", errNoClipboard
+}
+
+// copyText puts text on the clipboard and reports what was copied. The
+// clipboard tool runs in the background. Over ssh, or without a clipboard
+// tool, it asks the terminal to do it with an OSC 52 escape sequence,
+// which terminals may ignore. The text is also remembered for pasting into
+// prompts when the clipboard can't be read.
+func (ui *FileExplorerUI) copyText(text, what string) {
+	ui.clipboard = text
+	if inSSHSession() {
+		ui.copyThroughTerminal(text, what)
+		return
+	}
+	ui.goBackground(func() {
+		err := copyToClipboard(ui.ctx, text)
+		ui.queueUpdateDraw(func() {
+			switch {
+			case err == nil:
+				ui.setFooterStatus(tview.Escape("Copied " + what))
+			case errors.Is(err, errNoClipboard):
+				ui.copyThroughTerminal(text, what)
+			default:
+				ui.showError(err)
+			}
+		})
+	})
+}
+
+// copyThroughTerminal asks the terminal to put text on the clipboard with
+// an OSC 52 escape sequence
+func (ui *FileExplorerUI) copyThroughTerminal(text, what string) {
+	if ui.screen == nil {
+		ui.showError(errNoClipboard)
+		return
+	}
+	ui.screen.SetClipboard([]byte(text))
+	ui.setFooterStatus(tview.Escape("Copied " + what + " through the terminal"))
+}
+
+// pasteText returns the text on the clipboard, or else the last text copied
+// in the explorer
+func (ui *FileExplorerUI) pasteText() (string, error) {
+	if !inSSHSession() {
+		text, err := readClipboard()
+		if err == nil || ui.clipboard == "" {
+			return text, err
+		}
+	}
+	if ui.clipboard == "" {
+		return "", errors.New("nothing copied yet")
+	}
+	return ui.clipboard, nil
+}
+
+// pasteInto inserts the first line on the clipboard into input at the cursor
+func (ui *FileExplorerUI) pasteInto(input *tview.InputField) {
+	text, err := ui.pasteText()
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	text, _, _ = strings.Cut(strings.TrimRight(text, "\r\n"), "\n")
+	input.PasteHandler()(strings.TrimSuffix(text, "\r"), nil)
+}
+
+// pasteCapture makes Ctrl-V paste into input
+func (ui *FileExplorerUI) pasteCapture(input *tview.InputField) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlV {
+			ui.pasteInto(input)
+			return nil
+		}
+		return event
+	}
+}
+
+// copySelectedPaths copies the full paths of the selected entries, one per line
+func (ui *FileExplorerUI) copySelectedPaths() {
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+	what := paths[0]
+	if len(paths) > 1 {
+		what = plural(int64(len(paths)), "path", "paths")
+	}
+	ui.copyText(strings.Join(paths, "\n"), what)
+}
+
+// copySelectedNames copies the names of the selected entries, one per line
+func (ui *FileExplorerUI) copySelectedNames() {
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	what := names[0]
+	if len(names) > 1 {
+		what = plural(int64(len(names)), "name", "names")
+	}
+	ui.copyText(strings.Join(names, "\n"), what)
+}
+
+// copyContents copies the contents of the selected text file, up to
+// clipboardLimit
+func (ui *FileExplorerUI) copyContents() {
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	if ui.refuseInArchive() {
+		return
+	}
+	info, err := core.Stat(ui.fsys, path)
+	switch {
+	case err != nil:
+		ui.showError(err)
+		return
+	case !info.Mode().IsRegular():
+		ui.setFooterError(tview.Escape(filepath.Base(path) + " is not a file"))
+		return
+	case info.Size() > clipboardLimit:
+		ui.setFooterError(tview.Escape(fmt.Sprintf("%s is over %s, too large to copy", filepath.Base(path), formatSize(clipboardLimit))))
+		return
+	}
+	content, err := core.ReadFile(ui.ctx, ui.fsys, path)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	if core.IsBinary(content) {
+		ui.setFooterError(tview.Escape(filepath.Base(path) + " is not a text file"))
+		return
+	}
+	ui.copyText(string(content), "the contents of "+filepath.Base(path))
+}
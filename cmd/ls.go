@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	f "github.com/aktagon/gofiles"
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// lsEntry is an entry of the listing as printed by gofiles ls
+type lsEntry struct {
+	Name  string    `json:"name"`
+	Path  string    `json:"path"`
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	Type  string    `json:"type"`
+	Mode  string    `json:"mode"`
+	Dir   bool      `json:"dir"`
+}
+
+// runLs prints the listing of a directory without starting the explorer,
+// for scripts: as a table, a JSON array or one JSON object per line
+func runLs(args []string) error {
+	flags := flag.NewFlagSet("gofiles ls", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: gofiles ls [flags] [path | sftp://... | s3://... | davs://... | ftp://... | docker://...]\n\n")
+		fmt.Fprintln(flags.Output(), "Prints the listing of path, by default the working directory, ordered and")
+		fmt.Fprintln(flags.Output(), "filtered as the explorer would list it.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", f.DefaultConfigPath(), "read the config from `file`")
+	asJSON := flags.Bool("json", false, "print a JSON array of the entries")
+	asNDJSON := flags.Bool("ndjson", false, "print each entry as a JSON object on a line of its own")
+	filter := flags.String("filter", "", "list only names containing `term`")
+	showHidden := flags.Bool("show-hidden", false, "list dotfiles, overriding the config; -show-hidden=false hides them")
+	sort := flags.String("sort", "", "order the listing by `key`: name, size, modified or type")
+	reverse := flags.Bool("reverse", false, "order the listing descending, overriding the config")
+	flags.Parse(args)
+	if flags.NArg() > 1 || *asJSON && *asNDJSON {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := f.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *sort != "" {
+		cfg.SortKey = f.SortKey(*sort)
+	}
+	flags.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "show-hidden":
+			cfg.ShowHidden = *showHidden
+		case "reverse":
+			cfg.SortReverse = *reverse
+		}
+	})
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	fsys, dir := vfs.OS(), flags.Arg(0)
+	if isRemote(dir) {
+		remote, remoteDir, err := openRemote(dir)
+		if err != nil {
+			return err
+		}
+		if c, ok := remote.(io.Closer); ok {
+			defer c.Close()
+		}
+		fsys, dir = remote, remoteDir
+	} else if dir, err = filepath.Abs(dir); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	entries, err := core.List(ctx, fsys, dir, core.ListOptions{
+		ShowHidden: cfg.ShowHidden,
+		Filter:     *filter,
+		Order:      core.Order{Key: cfg.SortKey, Reverse: cfg.SortReverse, DirsFirst: cfg.DirsFirst},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remote file systems separate names with slashes on any platform
+	join := path.Join
+	if vfs.IsLocal(fsys) {
+		join = filepath.Join
+	}
+	listing := make([]lsEntry, len(entries))
+	for i, e := range entries {
+		listing[i] = lsEntry{
+			Name:  e.Name(),
+			Path:  join(dir, e.Name()),
+			Size:  e.Size(),
+			Mtime: e.Info.ModTime(),
+			Type:  core.TypeName(e),
+			Mode:  e.Info.Mode().String(),
+			Dir:   e.IsDir(),
+		}
+	}
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing)
+	case *asNDJSON:
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range listing {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range listing {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", e.Mode, e.Size, e.Mtime.Format("2006-01-02 15:04"), e.Name)
+	}
+	return w.Flush()
+}
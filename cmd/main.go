@@ -1,10 +1,163 @@
 package main
 
-import f "github.com/aktagon/gofiles"
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+
+	f "github.com/aktagon/gofiles"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3";
+// otherwise the module version is reported
+var version string
 
 func main() {
-	ui := f.NewFileExplorerUI()
+	if len(os.Args) > 1 && os.Args[1] == "ls" {
+		if err := runLs(os.Args[2:]); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), "Usage: gofiles [flags] [path | sftp://[user@]host[:port][/path] | s3://bucket[/prefix] | davs://host/path | ftp://[user@]host[/path] | docker://container[/path]]\n")
+		fmt.Fprint(flag.CommandLine.Output(), "       gofiles ls [flags] [path | ...]\n\n")
+		fmt.Fprintln(flag.CommandLine.Output(), "Starts in path, a directory or a file to select, or else restores the last session.")
+		fmt.Fprintln(flag.CommandLine.Output(), "sftp:// browses a remote host, in the home directory unless a path is given.")
+		fmt.Fprintln(flag.CommandLine.Output(), "s3:// browses a bucket with the AWS credentials of the environment or ~/.aws.")
+		fmt.Fprintln(flag.CommandLine.Output(), "dav:// and davs:// browse a WebDAV server over HTTP and HTTPS.")
+		fmt.Fprintln(flag.CommandLine.Output(), "ftp:// browses an FTP server, ftpes:// with AUTH TLS and ftps:// with implicit TLS.")
+		fmt.Fprintln(flag.CommandLine.Output(), "docker:// browses a running container through the Docker daemon of DOCKER_HOST.")
+		fmt.Fprintln(flag.CommandLine.Output(), "ls prints the listing instead, as text or JSON; see gofiles ls -h.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	configPath := flag.String("config", f.DefaultConfigPath(), "read the config from `file`")
+	filter := flag.String("filter", "", "start with the listing filtered to names containing `term`")
+	theme := flag.String("theme", "", "use the named built-in theme or the YAML theme `file` instead of the configured one")
+	showHidden := flag.Bool("show-hidden", false, "list dotfiles, overriding the config; -show-hidden=false hides them")
+	sort := flag.String("sort", "", "order the listing by `key`: name, size, modified or type")
+	stdin := flag.Bool("stdin", false, "list the newline-separated paths read from standard input instead of a directory")
+	noRestore := flag.Bool("no-restore", false, "start fresh in the working directory instead of restoring the last session")
+	pick := flag.Bool("pick", false, "choose a file with Enter and print its path, exiting with status 1 if none is chosen; implies -no-restore")
+	multi := flag.Bool("multi", false, "with -pick, choose the marked entries, printing a path per line")
+	dirsOnly := flag.Bool("dirs-only", false, "with -pick, list and choose only directories, picked with Alt-Enter")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildVersion())
+		return
+	}
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := f.LoadConfig(*configPath)
+	if err != nil {
+		fail(err)
+	}
+	if *theme != "" {
+		cfg.Theme = *theme
+	}
+	if *sort != "" {
+		cfg.SortKey = f.SortKey(*sort)
+	}
+	flag.Visit(func(fl *flag.Flag) {
+		if fl.Name == "show-hidden" {
+			cfg.ShowHidden = *showHidden
+		}
+	})
+	if err := cfg.Validate(); err != nil {
+		fail(err)
+	}
+
+	opts := []f.Option{f.WithConfig(cfg), f.WithConfigPath(*configPath)}
+	// Remote file systems are connected to before the explorer takes over
+	// the terminal, which may be needed to log in
+	start := flag.Arg(0)
+	if isRemote(start) {
+		fsys, dir, err := openRemote(start)
+		if err != nil {
+			fail(err)
+		}
+		if c, ok := fsys.(io.Closer); ok {
+			defer c.Close()
+		}
+		opts = append(opts, f.WithFS(fsys))
+		start = dir
+	}
+	opts = append(opts, f.WithStartPath(start))
+	if *pick {
+		opts = append(opts, f.WithPicker(f.Picker{Multi: *multi, DirsOnly: *dirsOnly}))
+	}
+
+	ui := f.NewFileExplorerUI(opts...)
+	// A path given starts there rather than where the last session was
+	sessionPath := f.DefaultSessionPath()
+	if !*noRestore && !*pick && flag.NArg() == 0 {
+		// A damaged session file is reported and browsing starts afresh,
+		// as without one
+		session, err := f.LoadSession(sessionPath)
+		if err != nil {
+			ui.ReportError(err)
+		}
+		// Flags given on the command line win over the session
+		if session != nil && *sort != "" {
+			session.Sort, session.SortReverse = "", false
+		}
+		ui.RestoreSession(session)
+	}
+	if *stdin {
+		paths, err := f.ReadPaths(os.Stdin)
+		if err != nil {
+			fail(err)
+		}
+		ui.ShowPaths(paths)
+	}
+	// An invalid term is reported in the footer and browsing starts unfiltered
+	_ = ui.SetFilter(*filter)
 	if err := ui.Start(); err != nil {
-		panic(err)
+		fail(err)
+	}
+	// Choosing leaves the session as it was
+	if *pick {
+		picked := ui.Picked()
+		for _, path := range picked {
+			fmt.Println(path)
+		}
+		if len(picked) == 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	// The session is of the local disk, which may have been switched to or
+	// from in the sources picker
+	if !ui.Local() {
+		return
 	}
+	if err := ui.Session().Save(sessionPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// buildVersion returns the version set at build time, or that of the module
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// fail reports err and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "gofiles:", err)
+	os.Exit(1)
 }
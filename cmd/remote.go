@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/aktagon/gofiles/vfs/davfs"
+	"github.com/aktagon/gofiles/vfs/dockerfs"
+	"github.com/aktagon/gofiles/vfs/ftpfs"
+	"github.com/aktagon/gofiles/vfs/s3fs"
+	"github.com/aktagon/gofiles/vfs/sftpfs"
+	"golang.org/x/term"
+)
+
+// isRemote reports whether location names a remote file system, like
+// sftp://user@host/path, rather than a local path
+func isRemote(location string) bool {
+	scheme, _, ok := strings.Cut(location, "://")
+	return ok && !strings.ContainsAny(scheme, `/\`)
+}
+
+// openRemote connects to the file system at location and returns it with
+// the path to start in there. Questions asked while connecting, such as
+// passwords, are asked on the terminal. File systems that hold connections
+// are io.Closers.
+func openRemote(location string) (vfs.FS, string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, "", err
+	}
+	switch u.Scheme {
+	case "sftp":
+		fsys, err := sftpfs.Dial(u.Host, u.User.Username(), sftpfs.Config{Prompt: prompt})
+		if err != nil {
+			return nil, "", err
+		}
+		dir := u.Path
+		if dir == "" {
+			if dir, err = fsys.Home(); err != nil {
+				fsys.Close()
+				return nil, "", err
+			}
+		}
+		return fsys, dir, nil
+	case "ftp", "ftps", "ftpes":
+		password, _ := u.User.Password()
+		cfg := ftpfs.Config{Password: password, Prompt: prompt}
+		if u.Scheme != "ftp" {
+			cfg.TLS = &tls.Config{}
+			cfg.ImplicitTLS = u.Scheme == "ftps"
+		}
+		fsys, err := ftpfs.Dial(u.Host, u.User.Username(), cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		dir := u.Path
+		if dir == "" {
+			if dir, err = fsys.Home(); err != nil {
+				fsys.Close()
+				return nil, "", err
+			}
+		}
+		return fsys, dir, nil
+	case "docker":
+		client, err := dockerfs.NewClient("")
+		if err != nil {
+			return nil, "", err
+		}
+		fsys, err := client.FS(u.Host)
+		if err != nil {
+			return nil, "", err
+		}
+		return fsys, "/" + strings.Trim(u.Path, "/"), nil
+	case "s3":
+		cfg, err := s3fs.LoadConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		fsys, err := s3fs.Open(u.Host, cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		return fsys, "/" + strings.Trim(u.Path, "/"), nil
+	case "dav", "davs":
+		password, _ := u.User.Password()
+		cfg := davfs.Config{User: u.User.Username(), Password: password, Prompt: prompt}
+		root := *u
+		root.Scheme = strings.Replace(u.Scheme, "dav", "http", 1)
+		root.User = nil
+		fsys, err := davfs.Open(root.String(), cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		return fsys, "/", nil
+	}
+	return nil, "", fmt.Errorf("%s: unsupported scheme %q", location, u.Scheme)
+}
+
+// stdin reads the answers to prompts
+var stdin = bufio.NewReader(os.Stdin)
+
+// prompt asks question on the terminal, hiding the answer as it is typed
+// unless echo is set
+func prompt(question string, echo bool) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+	if fd := int(os.Stdin.Fd()); !echo && term.IsTerminal(fd) {
+		answer, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(answer), err
+	}
+	answer, err := stdin.ReadString('\n')
+	return strings.TrimRight(answer, "\r\n"), err
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+)
+
+// ColorsConfig customizes the colors of names in the listing
+type ColorsConfig struct {
+	// LSColors imports the LS_COLORS environment variable as a base
+	LSColors bool `toml:"ls_colors"`
+	// Types maps entry types (dir, symlink, exec, fifo, socket, blockdev,
+	// chardev, setuid, setgid, sticky, file) to color names or #rrggbb
+	Types map[string]string `toml:"types"`
+	// Extensions maps extensions such as ".go" to color names or #rrggbb
+	Extensions map[string]string `toml:"extensions"`
+}
+
+// lsColorTypes maps LS_COLORS type codes to the type names used in the config
+var lsColorTypes = map[string]string{
+	"di": "dir",
+	"ln": "symlink",
+	"ex": "exec",
+	"pi": "fifo",
+	"so": "socket",
+	"bd": "blockdev",
+	"cd": "chardev",
+	"su": "setuid",
+	"sg": "setgid",
+	"st": "sticky",
+	"fi": "file",
+}
+
+// nameStyle is the color and attributes of a name cell
+type nameStyle struct {
+	color tcell.Color
+	attrs tcell.AttrMask
+}
+
+// colorScheme resolves the style of a listing entry by type and extension
+type colorScheme struct {
+	types map[string]nameStyle
+	exts  map[string]nameStyle
+}
+
+// newColorScheme builds the scheme from LS_COLORS (if enabled) overlaid
+// with the colors set in the config
+func newColorScheme(cfg ColorsConfig) (colorScheme, error) {
+	scheme := colorScheme{
+		types: map[string]nameStyle{},
+		exts:  map[string]nameStyle{},
+	}
+	if cfg.LSColors {
+		scheme.importLSColors(os.Getenv("LS_COLORS"))
+	}
+
+	for typ, name := range cfg.Types {
+		color, err := parseColorName(name)
+		if err != nil {
+			return scheme, fmt.Errorf("colors.types.%s: %w", typ, err)
+		}
+		scheme.types[typ] = nameStyle{color: color}
+	}
+	for ext, name := range cfg.Extensions {
+		color, err := parseColorName(name)
+		if err != nil {
+			return scheme, fmt.Errorf("colors.extensions.%s: %w", ext, err)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		scheme.exts[strings.ToLower(ext)] = nameStyle{color: color}
+	}
+	return scheme, nil
+}
+
+// parseColorName converts a W3C color name or #rrggbb value into a color
+func parseColorName(name string) (tcell.Color, error) {
+	color := tcell.GetColor(name)
+	if color == tcell.ColorDefault && name != "default" {
+		return color, fmt.Errorf("unknown color %q", name)
+	}
+	return color, nil
+}
+
+// importLSColors parses the GNU LS_COLORS format, e.g. "di=01;34:*.go=36"
+func (c colorScheme) importLSColors(value string) {
+	for _, field := range strings.Split(value, ":") {
+		key, sgr, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		style, ok := parseSGR(sgr)
+		if !ok {
+			continue
+		}
+		if ext, isExt := strings.CutPrefix(key, "*"); isExt {
+			c.exts[strings.ToLower(ext)] = style
+		} else if typ, known := lsColorTypes[key]; known {
+			c.types[typ] = style
+		}
+	}
+}
+
+// parseSGR converts the foreground color and bold attribute of an ANSI SGR
+// sequence such as "01;38;5;208" into a style. Background colors are ignored.
+func parseSGR(sgr string) (nameStyle, bool) {
+	var style nameStyle
+	found := false
+
+	codes := strings.Split(sgr, ";")
+	for i := 0; i < len(codes); i++ {
+		n, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 1:
+			style.attrs |= tcell.AttrBold
+		case n >= 30 && n <= 37:
+			style.color, found = tcell.PaletteColor(n-30), true
+		case n >= 90 && n <= 97:
+			style.color, found = tcell.PaletteColor(n-90+8), true
+		case n == 38 && i+2 < len(codes) && codes[i+1] == "5":
+			if idx, err := strconv.Atoi(codes[i+2]); err == nil {
+				style.color, found = tcell.PaletteColor(idx), true
+			}
+			i += 2
+		case n == 38 && i+4 < len(codes) && codes[i+1] == "2":
+			r, _ := strconv.Atoi(codes[i+2])
+			g, _ := strconv.Atoi(codes[i+3])
+			b, _ := strconv.Atoi(codes[i+4])
+			style.color, found = tcell.NewRGBColor(int32(r), int32(g), int32(b)), true
+			i += 4
+		case n >= 40 && n <= 49, n >= 100 && n <= 107:
+			// Background: skip, including the arguments of 48;5;n and 48;2;r;g;b
+			if n == 48 && i+1 < len(codes) {
+				if codes[i+1] == "5" {
+					i += 2
+				} else if codes[i+1] == "2" {
+					i += 4
+				}
+			}
+		}
+	}
+	return style, found || style.attrs != 0
+}
+
+// styleFor returns the configured style for an entry, if any. Type colors
+// win over extension colors except for plain files, as with ls.
+func (c colorScheme) styleFor(name string, mode fs.FileMode) (nameStyle, bool) {
+	typ := core.EntryType(mode)
+	if typ != "file" {
+		if style, ok := c.types[typ]; ok {
+			return style, true
+		}
+		if typ == "sticky" {
+			if style, ok := c.types["dir"]; ok {
+				return style, true
+			}
+		}
+		if typ != "exec" {
+			return nameStyle{}, false
+		}
+	}
+	if style, ok := c.exts[strings.ToLower(filepath.Ext(name))]; ok {
+		return style, true
+	}
+	if style, ok := c.types[typ]; ok {
+		return style, true
+	}
+	return nameStyle{}, false
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ColumnPreset selects which columns the listing shows
+type ColumnPreset string
+
+const (
+	// ColumnsName shows names only
+	ColumnsName ColumnPreset = "name"
+	// ColumnsSize shows names and sizes
+	ColumnsSize ColumnPreset = "size"
+	// ColumnsDate shows names, sizes and modification times
+	ColumnsDate ColumnPreset = "date"
+	// ColumnsFull shows every column, including permissions and ownership
+	ColumnsFull ColumnPreset = "full"
+)
+
+// columnPresets is the order in which presets are cycled through
+var columnPresets = []ColumnPreset{ColumnsName, ColumnsSize, ColumnsDate, ColumnsFull}
+
+// column describes a listing column after the Name column
+type column struct {
+	title   string
+	sortKey SortKey // "" for columns the listing can't be sorted by
+	text    func(e dirEntry) string
+}
+
+var (
+	sizeColumn = column{"Size", SortSize, func(e dirEntry) string {
+		switch {
+		case core.SpecialKind(e.Info.Mode()) != "":
+			return "<" + core.EntryType(e.Info.Mode()) + ">"
+		case e.IsDir() && !e.Sized:
+			return "-"
+		}
+		return formatSize(e.Size())
+	}}
+	typeColumn = column{"Type", SortType, func(e dirEntry) string {
+		return core.TypeName(e.Entry)
+	}}
+	mimeColumn = column{"MIME", "", func(e dirEntry) string {
+		if e.mime == "" {
+			return "-"
+		}
+		return e.mime
+	}}
+	permissionsColumn = column{"Permissions", "", func(e dirEntry) string {
+		return formatPermissions(e.Info.Mode())
+	}}
+)
+
+// columns returns the columns the pane shows after Name: the active ones,
+// the Git column inside a work tree, and those added by plugins unless only
+// names are shown
+func (p *Pane) columns() []column {
+	cols := p.ui.activeColumns()
+	if p.gitRepo {
+		cols = append(cols, gitColumn)
+	}
+	if p.ui.columnPreset != ColumnsName {
+		cols = append(cols, p.ui.plugins.listingColumns(p.path)...)
+	}
+	return cols
+}
+
+// Formats of the Modified column, with and without full timestamps
+const (
+	modifiedFormat     = "2006-01-02 15:04:05"
+	fullModifiedFormat = "2006-01-02 15:04:05.000000000 -07:00"
+)
+
+// modifiedColumn shows modification times, to the nanosecond and with the
+// UTC offset when full timestamps are on
+func (ui *FileExplorerUI) modifiedColumn() column {
+	format := modifiedFormat
+	if ui.fullTimes {
+		format = fullModifiedFormat
+	}
+	return column{"Modified", SortModified, func(e dirEntry) string {
+		return e.Info.ModTime().Format(format)
+	}}
+}
+
+// activeColumns returns the columns shown after Name for the current preset.
+// The permissions, mode and ownership columns are added to the size and date
+// presets when enabled.
+func (ui *FileExplorerUI) activeColumns() []column {
+	var cols []column
+	switch ui.columnPreset {
+	case ColumnsSize:
+		cols = []column{sizeColumn}
+	case ColumnsDate:
+		cols = []column{sizeColumn, ui.modifiedColumn()}
+	case ColumnsFull:
+		cols = []column{sizeColumn, ui.modifiedColumn(), typeColumn, permissionsColumn, modeColumn}
+		cols = append(cols, ownerColumns()...)
+		if ui.config.ShowMIME {
+			cols = append(cols, mimeColumn)
+		}
+		return cols
+	default:
+		return nil
+	}
+	if ui.config.ShowPermissions {
+		cols = append(cols, permissionsColumn)
+	}
+	if ui.config.ShowMode {
+		cols = append(cols, modeColumn)
+	}
+	if ui.config.ShowOwner {
+		cols = append(cols, ownerColumns()...)
+	}
+	if ui.config.ShowMIME {
+		cols = append(cols, mimeColumn)
+	}
+	return cols
+}
+
+// nameCell renders the Name cell of an entry, colored by type
+func (p *Pane) nameCell(e dirEntry) *tview.TableCell {
+	mode := e.Info.Mode()
+
+	cell := tview.NewTableCell(p.highlightFilter(e.Name()))
+	cell.SetReference(e.Name())
+	theme := p.ui.theme
+	if e.IsDir() {
+		cell.SetTextColor(theme.Directory)
+	} else if core.SpecialKind(mode) != "" {
+		cell.SetTextColor(theme.Special)
+	} else {
+		cell.SetTextColor(theme.Text)
+	}
+	if style, ok := p.ui.colors.styleFor(e.Name(), mode); ok {
+		if style.color != tcell.ColorDefault {
+			cell.SetTextColor(style.color)
+		}
+		cell.SetAttributes(style.attrs)
+	}
+	if p.ui.config.HighlightSpecialBits && hasSpecialBits(mode) {
+		cell.SetTextColor(theme.SpecialBits)
+	}
+	return cell
+}
+
+// cycleColumns switches to the next column preset
+func (ui *FileExplorerUI) cycleColumns() {
+	next := columnPresets[0]
+	for i, preset := range columnPresets {
+		if preset == ui.columnPreset {
+			next = columnPresets[(i+1)%len(columnPresets)]
+			break
+		}
+	}
+	ui.columnPreset = next
+	ui.pane.reload()
+	ui.setFooterStatus("Columns: " + string(next))
+}
+
+// toggleFullTimes switches the Modified column between seconds and full timestamps
+func (ui *FileExplorerUI) toggleFullTimes() {
+	ui.fullTimes = !ui.fullTimes
+	ui.pane.reload()
+	if ui.fullTimes {
+		ui.setFooterStatus("Showing full timestamps")
+	} else {
+		ui.setFooterStatus("Showing timestamps to the second")
+	}
+}
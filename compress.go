@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/ops"
+)
+
+// compressSelected asks for the name of an archive and packs the selected
+// entries into it. The format follows the extension, zip by default.
+func (ui *FileExplorerUI) compressSelected() {
+	if ui.refuseInArchive() || ui.refuseNonLocal() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+
+	dir, _ := ui.transferDir()
+	name := filepath.Base(ui.pane.path)
+	title := fmt.Sprintf("Compress %d entries to", len(paths))
+	if len(paths) == 1 {
+		name = filepath.Base(paths[0])
+		title = "Compress to"
+	}
+	ui.prompt(title+" (.zip, .tar.gz)", filepath.Join(dir, name+".zip"), func(dst string) {
+		dst = strings.TrimSpace(dst)
+		if dst == "" {
+			return
+		}
+		if !filepath.IsAbs(dst) {
+			dst = filepath.Join(ui.pane.path, dst)
+		}
+		if !ops.CanCompress(dst) {
+			ui.showError(ops.ErrFormat)
+			return
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			ui.showError(fmt.Errorf("%s: %w", dst, ops.ErrExists))
+			return
+		}
+		ui.pane.clearMarks()
+		job := ops.Job{Kind: ops.Compress, Srcs: paths, Dst: dst}
+		ui.queueJob(job)
+		ui.showJobDialog([]ops.Job{job})
+	})
+}
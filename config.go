@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/rivo/tview"
+)
+
+// DirOpenMode controls what happens when a directory row is activated
+type DirOpenMode string
+
+const (
+	// DirOpenEnter enters the directory on the first activation
+	DirOpenEnter DirOpenMode = "enter"
+	// DirOpenConfirm previews the directory on the first activation and
+	// enters it when the same row is activated a second time
+	DirOpenConfirm DirOpenMode = "confirm"
+	// DirOpenPreview enters the directory and keeps its summary in the preview pane
+	DirOpenPreview DirOpenMode = "preview"
+)
+
+// Config holds the user-tunable settings of the file explorer
+type Config struct {
+	DirOpenMode DirOpenMode `toml:"dir_open_mode"`
+
+	// OpenExternal makes Enter on a file that isn't text open it with Opener,
+	// or the system's opener (xdg-open, open or start) if Opener is empty
+	OpenExternal bool   `toml:"open_external"`
+	Opener       string `toml:"opener"`
+	// Editor is the command editing files (e), defaulting to $VISUAL or $EDITOR
+	Editor string `toml:"editor"`
+
+	// ShowHidden lists dotfiles (toggle with .)
+	ShowHidden bool `toml:"show_hidden"`
+	// DirCountLimit caps the item count in directory previews ("500+"); 0 counts everything
+	DirCountLimit int `toml:"dir_count_limit"`
+	// DirSizes shows the total size of the files below directories in the
+	// Size column, computed in the background
+	DirSizes bool `toml:"dir_sizes"`
+
+	// SearchMaxDepth limits how many directory levels a name search (Ctrl-F)
+	// or a search in files (Ctrl-G) descends and SearchLimit how many matches
+	// it collects; 0 is unlimited
+	SearchMaxDepth int `toml:"search_max_depth"`
+	SearchLimit    int `toml:"search_limit"`
+
+	// RefreshInterval reloads the listing periodically, for filesystems
+	// where changes aren't noticed otherwise; 0 disables it
+	RefreshInterval time.Duration `toml:"refresh_interval"`
+	// Watch reloads the listing as soon as entries are created, removed or
+	// renamed in the directories shown
+	Watch bool `toml:"watch"`
+
+	// Git shows the git status of entries in a Git column inside work
+	// trees; HideGitIgnored leaves out the entries git ignores (toggle
+	// with I)
+	Git            bool `toml:"git"`
+	HideGitIgnored bool `toml:"hide_git_ignored"`
+
+	// SortKey and SortReverse set the initial order of the listing (cycle
+	// with s, reverse with S); DirsFirst lists directories before files
+	SortKey     SortKey `toml:"sort"`
+	SortReverse bool    `toml:"sort_reverse"`
+	DirsFirst   bool    `toml:"dirs_first"`
+
+	// DualPane starts with two directory panes side by side instead of the
+	// listing and the preview (toggle with Ctrl-O)
+	DualPane bool `toml:"dual_pane"`
+
+	// ShowTree starts with the directory tree shown left of the listing
+	// (toggle with Ctrl-E); TreeWidth is its width in columns
+	ShowTree  bool `toml:"show_tree"`
+	TreeWidth int  `toml:"tree_width"`
+
+	// CompactWidth is the terminal width below which the listing and the
+	// preview are shown one at a time (toggle with v); 0 disables it
+	CompactWidth int `toml:"compact_width"`
+
+	// Mouse enables mouse support: clicking rows and column headers, double
+	// clicks opening entries, the wheel scrolling and clicks on the header
+	// path going to ancestors
+	Mouse bool `toml:"mouse"`
+
+	// Colors customizes the colors of names in the listing
+	Colors ColorsConfig `toml:"colors"`
+
+	// SyntaxHighlight colors source code in the preview using SyntaxStyle,
+	// one of the chroma styles; slow terminals may prefer it off
+	SyntaxHighlight bool   `toml:"syntax_highlight"`
+	SyntaxStyle     string `toml:"syntax_style"`
+
+	// ImageProtocol selects how images are drawn in the preview: auto,
+	// kitty, sixel or blocks
+	ImageProtocol ImageProtocol `toml:"image_protocol"`
+
+	// Theme is the name of a built-in theme (dark, light, solarized,
+	// monochrome) or the path of a YAML theme file
+	Theme string `toml:"theme"`
+
+	// Columns selects the initial column preset (cycle with C)
+	Columns ColumnPreset `toml:"columns"`
+	// ShowPermissions adds an ls-style permissions column to the size and date presets
+	ShowPermissions bool `toml:"show_permissions"`
+	// ShowMode adds the octal mode, and ShowOwner the owner and group where
+	// the platform has them, to the size and date presets
+	ShowMode  bool `toml:"show_mode"`
+	ShowOwner bool `toml:"show_owner"`
+	// ShowMIME adds the MIME type, detected from the start of each file, to
+	// every preset but name
+	ShowMIME bool `toml:"show_mime"`
+	// HighlightSpecialBits colors entries with setuid, setgid or sticky bits
+	HighlightSpecialBits bool `toml:"highlight_special_bits"`
+
+	// FilterOnNavigate decides what happens to an active filter on directory change
+	FilterOnNavigate FilterPolicy `toml:"filter_on_navigate"`
+
+	// RenameStyle selects between renaming in place and in a dialog
+	RenameStyle RenameStyle `toml:"rename_style"`
+
+	// DuplicateName picks the default name when duplicating a file
+	DuplicateName DuplicateName `toml:"duplicate_name"`
+
+	// PermanentDelete makes the delete action remove entries for good
+	// instead of moving them to the trash
+	PermanentDelete bool `toml:"permanent_delete"`
+
+	// Dialogs tunes the confirmation dialogs of destructive operations
+	Dialogs DialogConfig `toml:"dialogs"`
+
+	// Templates maps template names to the contents of files created from them
+	Templates map[string]string `toml:"templates"`
+
+	// RootWarning shows a banner in the header when running as root
+	RootWarning bool `toml:"root_warning"`
+
+	// Keys maps actions to keys, replacing their default bindings; an empty
+	// key unbinds the action
+	Keys map[string]string `toml:"keys"`
+
+	// ShowFooterHints shows the key hints in the footer at startup
+	ShowFooterHints bool `toml:"show_footer_hints"`
+	// FooterHints selects which key hints appear, in order; nil shows all
+	FooterHints []string `toml:"footer_hints"`
+}
+
+// DefaultConfig returns the settings used when no config file is present
+func DefaultConfig() Config {
+	return Config{
+		DirOpenMode:      DirOpenEnter,
+		ShowHidden:       true,
+		DirCountLimit:    500,
+		SearchLimit:      1000,
+		Watch:            true,
+		Git:              true,
+		SortKey:          SortName,
+		Mouse:            true,
+		Theme:            "dark",
+		CompactWidth:     80,
+		TreeWidth:        30,
+		SyntaxHighlight:  true,
+		SyntaxStyle:      "monokai",
+		ImageProtocol:    ImageAuto,
+		Columns:          ColumnsDate,
+		FilterOnNavigate: FilterClear,
+		RenameStyle:      RenameInline,
+		DuplicateName:    DuplicateCopy,
+		Dialogs: DialogConfig{
+			FocusCancel:  true,
+			DontAskAgain: true,
+		},
+		RootWarning:     true,
+		ShowFooterHints: true,
+	}
+}
+
+// DefaultConfigPath returns the location of the user's config file
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofiles", "config.toml")
+}
+
+// LoadConfig reads the config file at path on top of the defaults.
+// A missing file is not an error.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultConfig(), nil
+		}
+		return DefaultConfig(), fmt.Errorf("config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return DefaultConfig(), fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that enumerated settings hold known values, for configs
+// not read with LoadConfig
+func (c Config) Validate() error {
+	switch c.DirOpenMode {
+	case DirOpenEnter, DirOpenConfirm, DirOpenPreview:
+	default:
+		return fmt.Errorf("unknown dir_open_mode %q", c.DirOpenMode)
+	}
+	if c.SearchMaxDepth < 0 {
+		return fmt.Errorf("negative search_max_depth %d", c.SearchMaxDepth)
+	}
+	if c.SearchLimit < 0 {
+		return fmt.Errorf("negative search_limit %d", c.SearchLimit)
+	}
+	if c.TreeWidth < 1 {
+		return fmt.Errorf("tree_width %d is not positive", c.TreeWidth)
+	}
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("negative refresh_interval %s", c.RefreshInterval)
+	}
+	switch c.SortKey {
+	case SortName, SortSize, SortModified, SortType:
+	default:
+		return fmt.Errorf("unknown sort %q", c.SortKey)
+	}
+	switch c.Columns {
+	case ColumnsName, ColumnsSize, ColumnsDate, ColumnsFull:
+	default:
+		return fmt.Errorf("unknown columns %q", c.Columns)
+	}
+	switch c.FilterOnNavigate {
+	case FilterClear, FilterKeep, FilterAsk:
+	default:
+		return fmt.Errorf("unknown filter_on_navigate %q", c.FilterOnNavigate)
+	}
+	switch c.RenameStyle {
+	case RenameInline, RenamePrompt:
+	default:
+		return fmt.Errorf("unknown rename_style %q", c.RenameStyle)
+	}
+	switch c.ImageProtocol {
+	case ImageAuto, ImageKitty, ImageSixel, ImageBlocks:
+	default:
+		return fmt.Errorf("unknown image_protocol %q", c.ImageProtocol)
+	}
+	if _, ok := styles.Registry[c.SyntaxStyle]; !ok {
+		return fmt.Errorf("unknown syntax_style %q", c.SyntaxStyle)
+	}
+	if _, err := newColorScheme(c.Colors); err != nil {
+		return err
+	}
+	switch c.DuplicateName {
+	case DuplicateCopy, DuplicateBak:
+	default:
+		return fmt.Errorf("unknown duplicate_name %q", c.DuplicateName)
+	}
+	if _, err := LoadTheme(c.Theme); err != nil {
+		return err
+	}
+	if c.Dialogs.DangerColor != "" {
+		if _, err := parseColorName(c.Dialogs.DangerColor); err != nil {
+			return fmt.Errorf("dialogs.danger_color: %w", err)
+		}
+	}
+	for _, key := range c.Dialogs.Skip {
+		if !slices.Contains(questionKeys, key) {
+			return fmt.Errorf("dialogs.skip: unknown question %q", key)
+		}
+	}
+	if _, err := DefaultKeymap().withOverrides(c.Keys); err != nil {
+		return fmt.Errorf("keys: %w", err)
+	}
+	for _, name := range c.FooterHints {
+		if _, ok := findKeyHint(name); !ok {
+			return fmt.Errorf("unknown footer hint %q", name)
+		}
+	}
+	return nil
+}
+
+// applyConfig makes cfg the active config, resetting the runtime state it
+// seeds (hidden files, sort order, key bindings and hints, mouse, theme)
+func (ui *FileExplorerUI) applyConfig(cfg Config) {
+	ui.config = cfg
+	// The config was validated on load, so only programmatic configs can fail here
+	ui.colors, _ = newColorScheme(cfg.Colors)
+	theme, err := LoadTheme(cfg.Theme)
+	if err != nil {
+		theme = themes["dark"]
+	}
+	ui.theme = theme
+	keymap, err := DefaultKeymap().withOverrides(cfg.Keys)
+	if err != nil {
+		keymap = DefaultKeymap()
+	}
+	ui.keymap = keymap
+	ui.showHints = cfg.ShowFooterHints
+	ui.showHidden = cfg.ShowHidden
+	ui.hideIgnored = cfg.HideGitIgnored
+	ui.sortKey = cfg.SortKey
+	ui.sortReverse = cfg.SortReverse
+	ui.columnPreset = cfg.Columns
+	// The mouse is up to the application embedding the explorer
+	if !ui.embedded {
+		ui.app.EnableMouse(cfg.Mouse)
+	}
+}
+
+// reloadConfig re-reads the config file in the background and applies it.
+// On a parse error the running config is kept and the error is reported.
+func (ui *FileExplorerUI) reloadConfig() {
+	path := ui.configPath
+	ui.goBackground(func() {
+		cfg, err := LoadConfig(path)
+		ui.queueUpdateDraw(func() {
+			if err != nil {
+				ui.showError(err)
+				return
+			}
+			ui.applyConfig(cfg)
+			ui.applyTheme()
+			ui.startAutoRefresh()
+			ui.startWatcher()
+			for _, p := range ui.visiblePanes() {
+				p.reload()
+			}
+			ui.setFooterStatus(tview.Escape("Reloaded " + path))
+		})
+	})
+}
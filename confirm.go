@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// answer is the choice made in a confirmation dialog
+type answer int
+
+const (
+	// answerNo cancels the action, and the rest of a series
+	answerNo answer = iota
+	// answerYes goes ahead with the action
+	answerYes
+	// answerAll goes ahead with the action and with the rest of a series
+	// without asking again
+	answerAll
+	// answerSkip leaves this one out and goes on with the rest of a series
+	answerSkip
+	// answerOther goes ahead the other way a question offers
+	answerOther
+)
+
+// Questions that can be skipped with dialogs.skip or "Don't ask again"
+const (
+	questionDelete    = "delete"    // deleting permanently
+	questionTrash     = "trash"     // moving to the trash
+	questionPurge     = "purge"     // deleting from the trash
+	questionOverwrite = "overwrite" // replacing entries by copies, moves and extraction
+	questionTransfer  = "transfer"  // copying or moving directories
+	questionQuit      = "quit"      // quitting while jobs are running
+	questionFilter    = "filter"    // keeping the filter in another directory
+)
+
+// questionKeys are the questions that can be skipped
+var questionKeys = []string{questionDelete, questionTrash, questionPurge, questionOverwrite, questionTransfer, questionQuit, questionFilter}
+
+// question is what a confirmation dialog asks
+type question struct {
+	// key names the question for dialogs.skip and "Don't ask again"; ""
+	// always asks
+	key    string
+	text   string // may contain color tags
+	action string // the label of the button going ahead, "Yes" if empty
+	// other is the label of a button going ahead another way, if any
+	other string
+	// many offers All and Skip besides the action and Cancel, for one of
+	// a series of questions
+	many bool
+	// danger colors the action button and the border with the danger color
+	danger bool
+}
+
+// confirmPage is the name of the page of the confirmation dialog asking q
+func confirmPage(q question) string {
+	return "confirm " + q.key
+}
+
+// ask shows a confirmation dialog and calls done with the answer. Esc
+// answers no. A question the user chose not to be asked again, in the
+// config or in an earlier dialog, is answered yes, or all in a series,
+// without showing the dialog.
+func (ui *FileExplorerUI) ask(q question, done func(answer)) {
+	if ui.skipQuestion(q.key) {
+		if q.many {
+			done(answerAll)
+		} else {
+			done(answerYes)
+		}
+		return
+	}
+
+	name := confirmPage(q)
+	action := q.action
+	if action == "" {
+		action = "Yes"
+	}
+	border, actionColor := tview.Styles.BorderColor, ui.theme.Selection
+	if q.danger {
+		border, actionColor = ui.dangerColor(), ui.dangerColor()
+	}
+
+	text := tview.NewTextView().SetDynamicColors(true).SetText(q.text)
+	form := tview.NewForm().SetButtonsAlign(tview.AlignCenter)
+	form.SetFieldBackgroundColor(ui.theme.Input)
+
+	dontAsk := false
+	if q.key != "" && ui.config.Dialogs.DontAskAgain {
+		form.AddCheckbox("Don't ask again", false, func(checked bool) { dontAsk = checked })
+	}
+	answerWith := func(a answer) func() {
+		return func() {
+			ui.closePage(name)
+			if dontAsk && (a == answerYes || a == answerAll) {
+				ui.dontAsk[q.key] = true
+			}
+			done(a)
+		}
+	}
+
+	addCancel := func() {
+		form.AddButton("Cancel", answerWith(answerNo))
+	}
+	if ui.config.Dialogs.CancelFirst {
+		addCancel()
+	}
+	form.AddButton(action, answerWith(answerYes))
+	form.GetButton(form.GetButtonCount() - 1).
+		SetStyle(tcell.StyleDefault.Background(actionColor).Foreground(ui.theme.Text)).
+		SetActivatedStyle(tcell.StyleDefault.Background(ui.theme.Text).Foreground(actionColor).Bold(true))
+	if q.other != "" {
+		form.AddButton(q.other, answerWith(answerOther))
+	}
+	if q.many {
+		form.AddButton("All", answerWith(answerAll))
+		form.AddButton("Skip", answerWith(answerSkip))
+	}
+	if !ui.config.Dialogs.CancelFirst {
+		addCancel()
+	}
+
+	focus := action
+	if ui.config.Dialogs.FocusCancel {
+		focus = "Cancel"
+	}
+	form.SetFocus(form.GetFormItemCount() + form.GetButtonIndex(focus))
+	form.SetCancelFunc(answerWith(answerNo))
+
+	formHeight := 3
+	if form.GetFormItemCount() > 0 {
+		formHeight = 5
+	}
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 0, 1, false).
+		AddItem(form, formHeight, 0, true)
+	layout.SetBorder(true)
+	layout.SetBorderColor(border)
+	layout.SetTitle(" " + action + " ")
+
+	lines := strings.Count(q.text, "\n") + 1
+	ui.showPage(name, centered(layout, 80, min(lines, 22)+formHeight+2))
+}
+
+// skipQuestion reports whether the question named key is not to be asked
+func (ui *FileExplorerUI) skipQuestion(key string) bool {
+	return key != "" && (ui.dontAsk[key] || slices.Contains(ui.config.Dialogs.Skip, key))
+}
+
+// dangerColor returns the color of destructive actions: dialogs.danger_color,
+// or else the theme's
+func (ui *FileExplorerUI) dangerColor() tcell.Color {
+	if ui.config.Dialogs.DangerColor != "" {
+		if color, err := parseColorName(ui.config.Dialogs.DangerColor); err == nil {
+			return color
+		}
+	}
+	return ui.theme.Danger
+}
+
+// quit stops the explorer, first asking whether to cancel the jobs still
+// running, if any. Quitting again while asked quits at once.
+func (ui *FileExplorerUI) quit() {
+	n := ui.jobs.Len()
+	text := fmt.Sprintf("%d jobs are still running.\n\nQuit and cancel them?", n)
+	if n == 1 {
+		text = "A job is still running.\n\nQuit and cancel it?"
+	}
+	q := question{key: questionQuit, text: text, action: "Quit"}
+	if n == 0 || ui.pages.HasPage(confirmPage(q)) {
+		ui.exit()
+		return
+	}
+	ui.ask(q, func(a answer) {
+		if a == answerYes {
+			ui.exit()
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// The encodings of text DetectEncoding recognizes besides UTF-8
+const (
+	UTF16LE     = "UTF-16LE"
+	UTF16BE     = "UTF-16BE"
+	ShiftJIS    = "Shift_JIS"
+	Windows1252 = "Windows-1252"
+	Latin1      = "ISO-8859-1"
+)
+
+// Byte order marks of UTF-16 text, which is full of NUL bytes
+var (
+	utf16LE = []byte{0xff, 0xfe}
+	utf16BE = []byte{0xfe, 0xff}
+)
+
+// DetectEncoding returns the encoding of the text data starts with, judging
+// by its first SniffSize bytes: "" for UTF-8, and so ASCII, or one of the
+// encodings above. ok is false for binary data: NUL bytes outside UTF-16,
+// or control characters that text doesn't have.
+func DetectEncoding(data []byte) (enc string, ok bool) {
+	sample := data[:min(len(data), SniffSize)]
+	switch {
+	case bytes.HasPrefix(sample, utf16LE):
+		return UTF16LE, true
+	case bytes.HasPrefix(sample, utf16BE):
+		return UTF16BE, true
+	case bytes.IndexByte(sample, 0) >= 0:
+		return detectUTF16(sample)
+	case utf8.Valid(trimPartialRune(sample)):
+		return "", true
+	case hasBinaryControls(sample):
+		return "", false
+	case isShiftJIS(sample):
+		return ShiftJIS, true
+	}
+	// Latin-1 has control characters where Windows-1252 has quotes, dashes
+	// and the euro sign
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9f {
+			return Windows1252, true
+		}
+	}
+	return Latin1, true
+}
+
+// Decode transcodes text in the encoding enc, as returned by
+// DetectEncoding, to UTF-8, dropping a byte order mark. Bytes that aren't
+// valid in enc, such as a character cut off at the end, become U+FFFD.
+func Decode(data []byte, enc string) ([]byte, error) {
+	var e encoding.Encoding
+	switch enc {
+	case "":
+		return data, nil
+	case UTF16LE:
+		e = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case UTF16BE:
+		e = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case ShiftJIS:
+		e = japanese.ShiftJIS
+	case Windows1252:
+		e = charmap.Windows1252
+	case Latin1:
+		e = charmap.ISO8859_1
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", enc)
+	}
+	return e.NewDecoder().Bytes(data)
+}
+
+// detectUTF16 recognizes UTF-16 without a byte order mark by its NUL
+// bytes, the high halves of ASCII characters, which are all at odd offsets
+// in little-endian text and at even ones in big-endian text
+func detectUTF16(sample []byte) (string, bool) {
+	var even, odd int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	switch half := len(sample) / 2; {
+	case even == 0 && odd*3 >= half:
+		return UTF16LE, true
+	case odd == 0 && even*3 >= half:
+		return UTF16BE, true
+	}
+	return "", false
+}
+
+// trimPartialRune drops a UTF-8 character cut off at the end of data,
+// which is often the start of a file
+func trimPartialRune(data []byte) []byte {
+	for i := len(data) - 1; i >= max(len(data)-utf8.UTFMax, 0); i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// hasBinaryControls reports whether data has C0 control characters other
+// than the whitespace and escape sequences of text
+func hasBinaryControls(data []byte) bool {
+	for _, b := range data {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\v' && b != 0x1b {
+			return true
+		}
+	}
+	return false
+}
+
+// isShiftJIS reports whether data is valid Shift_JIS with Japanese in it.
+// Western text in 8-bit encodings can pass for Shift_JIS too, so double-
+// byte characters only count as Japanese with a trail byte outside ASCII,
+// as kana have.
+func isShiftJIS(data []byte) bool {
+	japanese := false
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80, b >= 0xa1 && b <= 0xdf: // ASCII and half-width katakana
+			continue
+		case b >= 0x81 && b <= 0x9f, b >= 0xe0 && b <= 0xfc:
+			if i+1 == len(data) {
+				return japanese // cut off at the end
+			}
+			i++
+			trail := data[i]
+			if trail < 0x40 || trail == 0x7f || trail > 0xfc {
+				return false
+			}
+			japanese = japanese || trail >= 0x80
+		default:
+			return false
+		}
+	}
+	return japanese
+}
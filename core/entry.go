@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// Entry is a directory entry together with its file info
+type Entry struct {
+	fs.DirEntry
+	Info fs.FileInfo
+
+	DirSize int64 // total size of the files below a directory, once sized
+	Sized   bool
+}
+
+// NewEntry stats d for an Entry
+func NewEntry(d fs.DirEntry) (Entry, error) {
+	info, err := d.Info()
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{DirEntry: d, Info: info}, nil
+}
+
+// Size returns the size listings show and sort by: the total of the files
+// below a directory once computed
+func (e Entry) Size() int64 {
+	if e.Sized {
+		return e.DirSize
+	}
+	return e.Info.Size()
+}
+
+// SortKey identifies the column a listing is ordered by
+type SortKey string
+
+// Supported sort keys
+const (
+	SortName     SortKey = "name"
+	SortSize     SortKey = "size"
+	SortModified SortKey = "modified"
+	SortType     SortKey = "type"
+)
+
+// SortKeys lists the sort keys, in the order they are cycled through
+var SortKeys = []SortKey{SortName, SortSize, SortModified, SortType}
+
+// Order is how a listing is ordered: by Key, falling back to the name for
+// ties. With DirsFirst, directories come before everything else in either
+// direction.
+type Order struct {
+	Key       SortKey
+	Reverse   bool
+	DirsFirst bool
+}
+
+// Less reports whether a comes before b
+func (o Order) Less(a, b Entry) bool {
+	if o.DirsFirst && a.IsDir() != b.IsDir() {
+		return a.IsDir()
+	}
+	if o.Reverse {
+		a, b = b, a
+	}
+	switch o.Key {
+	case SortType:
+		if ta, tb := TypeName(a), TypeName(b); ta != tb {
+			return ta < tb
+		}
+	case SortSize:
+		if a.Size() != b.Size() {
+			return a.Size() < b.Size()
+		}
+	case SortModified:
+		if !a.Info.ModTime().Equal(b.Info.ModTime()) {
+			return a.Info.ModTime().Before(b.Info.ModTime())
+		}
+	}
+	return a.Name() < b.Name()
+}
+
+// Sort orders entries
+func (o Order) Sort(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return o.Less(entries[i], entries[j])
+	})
+}
+
+// ListOptions are what List lists and how
+type ListOptions struct {
+	ShowHidden bool   // list dotfiles
+	Filter     string // list only names containing this, ignoring case
+	Order      Order
+}
+
+// List reads the directory at path in fsys and returns its entries in
+// order. Entries that can't be stated, having gone since they were read,
+// are left out.
+func List(ctx context.Context, fsys vfs.FS, path string, opts ListOptions) ([]Entry, error) {
+	var entries []Entry
+	err := StreamDir(ctx, fsys, path, func(batch []fs.DirEntry) {
+		for _, d := range batch {
+			if !opts.ShowHidden && IsHidden(d.Name()) || !MatchesFilter(d.Name(), opts.Filter) {
+				continue
+			}
+			if e, err := NewEntry(d); err == nil {
+				entries = append(entries, e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts.Order.Sort(entries)
+	return entries, nil
+}
+
+// IsHidden reports whether name is a dotfile
+func IsHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != ".."
+}
+
+// MatchesFilter reports whether name contains filter, ignoring case. Every
+// name matches an empty filter.
+func MatchesFilter(name, filter string) bool {
+	return filter == "" || strings.Contains(strings.ToLower(name), strings.ToLower(filter))
+}
+
+// TypeName describes the type of an entry for sorting by type: "dir", the
+// kind of special files, or else the lower-cased extension of files
+func TypeName(e Entry) string {
+	switch {
+	case e.IsDir():
+		return "dir"
+	case SpecialKind(e.Info.Mode()) != "":
+		return EntryType(e.Info.Mode())
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(e.Name()), "."); ext != "" {
+		return strings.ToLower(ext)
+	}
+	return "-"
+}
+
+// SpecialKind describes files that are neither regular nor directories,
+// such as named pipes and devices, and returns "" for everything else
+func SpecialKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular(), mode.IsDir(), mode&fs.ModeSymlink != 0:
+		return ""
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "block device"
+	}
+	return "special file"
+}
+
+// EntryType classifies a mode the way ls does for coloring, most specific
+// first
+func EntryType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeDir != 0:
+		if mode&fs.ModeSticky != 0 {
+			return "sticky"
+		}
+		return "dir"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "chardev"
+	case mode&fs.ModeDevice != 0:
+		return "blockdev"
+	case mode&fs.ModeSetuid != 0:
+		return "setuid"
+	case mode&fs.ModeSetgid != 0:
+		return "setgid"
+	case mode.Perm()&0o111 != 0:
+		return "exec"
+	}
+	return "file"
+}
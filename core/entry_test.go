@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// testFS is a directory of files and subdirectories of different sizes,
+// times and types
+func testFS() vfs.FS {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return vfs.ReadOnly(fstest.MapFS{
+		"dir/b.txt":      {Data: []byte("bb"), ModTime: t0.Add(3 * time.Hour)},
+		"dir/a.go":       {Data: []byte("aaaa"), ModTime: t0.Add(1 * time.Hour)},
+		"dir/C.md":       {Data: []byte("c"), ModTime: t0.Add(2 * time.Hour)},
+		"dir/.hidden":    {Data: []byte("h"), ModTime: t0},
+		"dir/zsub":       {Mode: fs.ModeDir | 0o755, ModTime: t0},
+		"dir/asub":       {Mode: fs.ModeDir | 0o755, ModTime: t0.Add(4 * time.Hour)},
+		"dir/zsub/x.txt": {Data: []byte("x")},
+	})
+}
+
+// names returns the names of entries
+func names(entries []Entry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"by name", ListOptions{}, []string{"C.md", "a.go", "asub", "b.txt", "zsub"}},
+		{"hidden", ListOptions{ShowHidden: true}, []string{".hidden", "C.md", "a.go", "asub", "b.txt", "zsub"}},
+		{"filter", ListOptions{Filter: "SUB"}, []string{"asub", "zsub"}},
+		{"dirs first", ListOptions{Order: Order{Key: SortName, DirsFirst: true}}, []string{"asub", "zsub", "C.md", "a.go", "b.txt"}},
+		{"by size", ListOptions{Order: Order{Key: SortSize}, Filter: "."}, []string{"C.md", "b.txt", "a.go"}},
+		{"by modified", ListOptions{Order: Order{Key: SortModified}, Filter: "."}, []string{"a.go", "C.md", "b.txt"}},
+		{"by type", ListOptions{Order: Order{Key: SortType}}, []string{"asub", "zsub", "a.go", "C.md", "b.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := List(context.Background(), testFS(), "/dir", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListMissing(t *testing.T) {
+	if _, err := List(context.Background(), testFS(), "/missing", ListOptions{}); err == nil {
+		t.Error("List() of a missing directory succeeded")
+	}
+}
+
+func TestOrderLess(t *testing.T) {
+	entries, err := List(context.Background(), testFS(), "/dir", ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]Entry{}
+	for _, e := range entries {
+		byName[e.Name()] = e
+	}
+	dir, file, bigger := byName["zsub"], byName["a.go"], byName["b.txt"]
+
+	tests := []struct {
+		name  string
+		order Order
+		a, b  Entry
+		want  bool
+	}{
+		{"name", Order{}, file, bigger, true},
+		{"name reversed", Order{Reverse: true}, file, bigger, false},
+		{"size", Order{Key: SortSize}, bigger, file, true},
+		{"size reversed", Order{Key: SortSize, Reverse: true}, bigger, file, false},
+		{"mixed by name", Order{}, dir, file, false},
+		{"dirs first", Order{DirsFirst: true}, dir, file, true},
+		{"dirs first after files", Order{DirsFirst: true}, file, dir, false},
+		{"dirs first reversed", Order{DirsFirst: true, Reverse: true}, dir, file, true},
+		{"dirs first reversed after files", Order{DirsFirst: true, Reverse: true}, file, dir, false},
+		{"equal", Order{}, file, file, false},
+	}
+	for _, tt := range tests {
+		if got := tt.order.Less(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Less(%s, %s) = %v, want %v", tt.name, tt.a.Name(), tt.b.Name(), got, tt.want)
+		}
+	}
+}
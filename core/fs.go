@@ -0,0 +1,135 @@
+// Package core is the file explorer without a user interface: it lists
+// directories, orders their entries, reads files for previews and creates,
+// renames, copies, removes and downloads entries, on any vfs.FS. The
+// explorer's UI renders what it returns, and other frontends can do the
+// same. Background jobs on the local disk, such as recursive copies with
+// their progress, are run by package ops.
+//
+// Paths are absolute local paths, converted to file system names with
+// vfs.Name.
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"sort"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// BatchSize is the number of directory entries read at a time
+const BatchSize = 256
+
+// Stat is os.Stat for path in fsys
+func Stat(fsys vfs.FS, path string) (fs.FileInfo, error) {
+	return fsys.Stat(vfs.Name(path))
+}
+
+// Lstat is os.Lstat for path in fsys
+func Lstat(fsys vfs.FS, path string) (fs.FileInfo, error) {
+	return fsys.Lstat(vfs.Name(path))
+}
+
+// Open is os.Open for path in fsys
+func Open(fsys vfs.FS, path string) (fs.File, error) {
+	return fsys.Open(vfs.Name(path))
+}
+
+// ReadFile reads a whole file of fsys in chunks, stopping as soon as ctx is
+// cancelled
+func ReadFile(ctx context.Context, fsys vfs.FS, path string) ([]byte, error) {
+	f, err := Open(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := f.Read(chunk)
+		buf.Write(chunk[:n])
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadHead reads up to n bytes from the start of the file at path in fsys
+func ReadHead(fsys vfs.FS, path string, n int) ([]byte, error) {
+	f, err := Open(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, int64(n)))
+}
+
+// ReadDir reads a directory of fsys in batches, like os.ReadDir, but checks
+// ctx between batches so huge or slow directories can be abandoned
+func ReadDir(ctx context.Context, fsys vfs.FS, path string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := StreamDir(ctx, fsys, path, func(batch []fs.DirEntry) {
+		entries = append(entries, batch...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// StreamDir reads the directory at path in fsys in batches of BatchSize,
+// in directory order, handing each batch to emit as soon as it has been
+// read. File systems whose directories can't be read in parts are read
+// whole.
+func StreamDir(ctx context.Context, fsys vfs.FS, path string, emit func([]fs.DirEntry)) error {
+	file, err := Open(fsys, path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	f, ok := file.(fs.ReadDirFile)
+	if !ok {
+		entries, err := fsys.ReadDir(vfs.Name(path))
+		if err != nil {
+			return err
+		}
+		for len(entries) > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n := min(BatchSize, len(entries))
+			emit(entries[:n])
+			entries = entries[n:]
+		}
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := f.ReadDir(BatchSize)
+		if len(batch) > 0 {
+			emit(batch)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// readDirOnly is a file system whose directories can only be read whole
+type readDirOnly struct {
+	vfs.FS
+}
+
+func (r readDirOnly) Open(name string) (fs.File, error) {
+	f, err := r.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestStreamDir(t *testing.T) {
+	const n = 2*BatchSize + 10
+	files := fstest.MapFS{}
+	for i := range n {
+		files[fmt.Sprintf("big/%04d", i)] = &fstest.MapFile{}
+	}
+
+	for _, fsys := range []vfs.FS{vfs.ReadOnly(files), readDirOnly{vfs.ReadOnly(files)}} {
+		var sizes []int
+		seen := map[string]bool{}
+		err := StreamDir(context.Background(), fsys, "/big", func(batch []fs.DirEntry) {
+			sizes = append(sizes, len(batch))
+			for _, d := range batch {
+				seen[d.Name()] = true
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seen) != n {
+			t.Errorf("%T: read %d entries, want %d", fsys, len(seen), n)
+		}
+		for i, size := range sizes {
+			if size > BatchSize || size == 0 {
+				t.Errorf("%T: batch %d has %d entries", fsys, i, size)
+			}
+		}
+		if len(sizes) != 3 {
+			t.Errorf("%T: read in %d batches, want 3", fsys, len(sizes))
+		}
+	}
+}
+
+func TestStreamDirCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := StreamDir(ctx, testFS(), "/dir", func([]fs.DirEntry) {
+		t.Error("batch emitted after cancellation")
+	})
+	if err != context.Canceled {
+		t.Errorf("StreamDir() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	entries, err := ReadDir(context.Background(), testFS(), "/dir/zsub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "x.txt" {
+		t.Errorf("ReadDir() = %v, want x.txt", entries)
+	}
+}
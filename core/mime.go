@@ -0,0 +1,152 @@
+package core
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// SniffSize is how much of the start of a file DetectType and IsBinary
+// look at
+const SniffSize = 8 * 1024
+
+// extensionTypes are the types of common files that the system's MIME
+// tables may lack or disagree on, looked up before them
+var extensionTypes = map[string]string{
+	".go":   "text/x-go",
+	".rs":   "text/x-rust",
+	".py":   "text/x-python",
+	".rb":   "text/x-ruby",
+	".c":    "text/x-c",
+	".h":    "text/x-c",
+	".java": "text/x-java",
+	".sh":   "text/x-shellscript",
+	".md":   "text/markdown",
+	".csv":  "text/csv",
+	".txt":  "text/plain",
+	".log":  "text/plain",
+	".json": "application/json",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".toml": "application/toml",
+	".xml":  "application/xml",
+	".svg":  "image/svg+xml",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".zip":  "application/zip",
+	".jar":  "application/java-archive",
+	".tar":  "application/x-tar",
+	".tgz":  "application/gzip",
+	".gz":   "application/gzip",
+	".bz2":  "application/x-bzip2",
+	".xz":   "application/x-xz",
+	".7z":   "application/x-7z-compressed",
+}
+
+// textTypes are the types outside text/ whose files are text
+var textTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/toml":       true,
+	"application/javascript": true,
+	"application/x-sh":       true,
+}
+
+// DetectType returns the MIME type, without parameters, of a file named
+// name whose contents start with head. The contents decide, by their magic
+// numbers or else by IsBinary, and the extension refines what they can't
+// tell apart: text/plain becomes the extension's text type, and
+// unrecognized binary data or a zip container the extension's binary type.
+// Only the first SniffSize bytes of head are looked at.
+func DetectType(name string, head []byte) string {
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || sniffed == "text/plain" || sniffed == "application/octet-stream" {
+		sniffed = "text/plain"
+		if IsBinary(head) {
+			sniffed = "application/octet-stream"
+		}
+	}
+	byExt := TypeByExtension(name)
+	if byExt == "" || byExt == sniffed {
+		return sniffed
+	}
+	switch {
+	case sniffed == "text/plain" && IsTextType(byExt):
+		return byExt
+	case (sniffed == "application/octet-stream" || sniffed == "application/zip") && !IsTextType(byExt):
+		return byExt
+	}
+	return sniffed
+}
+
+// TypeByExtension returns the MIME type, without parameters, of files with
+// the extension of name, or "" if it isn't known
+func TypeByExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		return ""
+	}
+	if t, ok := extensionTypes[ext]; ok {
+		return t
+	}
+	t, _, err := mime.ParseMediaType(mime.TypeByExtension(ext))
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+// IsTextType reports whether files of the MIME type t are text
+func IsTextType(t string) bool {
+	return strings.HasPrefix(t, "text/") || textTypes[t] ||
+		strings.HasSuffix(t, "+xml") || strings.HasSuffix(t, "+json")
+}
+
+// IsBinary reports whether data is binary rather than text, judging by
+// its first SniffSize bytes. Text in the encodings DetectEncoding
+// recognizes isn't binary.
+func IsBinary(data []byte) bool {
+	_, ok := DetectEncoding(data)
+	return !ok
+}
+
+// FileType returns the MIME type of the file at path in fsys, whose lstat
+// info is info, reading the start of regular files and following
+// symlinks. Directories and other files that aren't regular have the
+// inode/ types of the shared MIME-info database, such as inode/directory;
+// "" is returned for files that can't be read.
+func FileType(fsys vfs.FS, path string, info fs.FileInfo) string {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := Stat(fsys, path)
+		if err != nil {
+			return "inode/symlink"
+		}
+		info = target
+	}
+	mode := info.Mode()
+	switch {
+	case mode.IsDir():
+		return "inode/directory"
+	case mode&fs.ModeNamedPipe != 0:
+		return "inode/fifo"
+	case mode&fs.ModeSocket != 0:
+		return "inode/socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "inode/chardevice"
+	case mode&fs.ModeDevice != 0:
+		return "inode/blockdevice"
+	case !mode.IsRegular():
+		return ""
+	}
+	head, err := ReadHead(fsys, path, SniffSize)
+	if err != nil {
+		return ""
+	}
+	return DetectType(filepath.Base(path), head)
+}
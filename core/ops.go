@@ -0,0 +1,274 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// ValidateName rejects names of new entries that would escape their
+// directory
+func ValidateName(name string) error {
+	switch {
+	case name == "." || name == "..":
+		return errors.New("invalid name " + name)
+	case strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator):
+		return errors.New("name must not contain a path separator")
+	}
+	return nil
+}
+
+// CreateFile creates the file name in the directory dir of fsys holding
+// contents, refusing to replace an existing entry, and returns its path
+func CreateFile(fsys vfs.FS, dir, name string, contents []byte) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	f, err := fsys.Create(vfs.Name(path), 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("%s already exists", name)
+		}
+		return "", err
+	}
+	_, err = f.Write(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Mkdir creates the directory name in the directory dir of fsys, refusing
+// to replace an existing entry, and returns its path
+func Mkdir(fsys vfs.FS, dir, name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := fsys.Mkdir(vfs.Name(path), 0o755); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("%s already exists", name)
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// Rename renames path in fsys to newName within the same directory,
+// refusing invalid names and existing targets, and returns the new path
+func Rename(fsys vfs.FS, path, newName string) (string, error) {
+	if err := ValidateName(newName); err != nil {
+		return "", err
+	}
+	target := filepath.Join(filepath.Dir(path), newName)
+	if _, err := Lstat(fsys, target); err == nil {
+		return "", fmt.Errorf("%s already exists", newName)
+	}
+	if err := fsys.Rename(vfs.Name(path), vfs.Name(target)); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// CopyFile copies the regular file src to dst in fsys, which must not
+// exist, preserving the permission bits
+func CopyFile(fsys vfs.FS, src, dst string) (err error) {
+	in, err := Open(fsys, src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := fsys.Create(vfs.Name(dst), info.Mode().Perm())
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists", filepath.Base(dst))
+		}
+		return err
+	}
+	defer func() {
+		if err != nil {
+			fsys.Remove(vfs.Name(dst))
+		}
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// Create is subject to the umask; apply the source mode exactly, where
+	// there are modes
+	if err := fsys.Chmod(vfs.Name(dst), info.Mode().Perm()); !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
+
+// WalkDir is filepath.WalkDir for root in fsys
+func WalkDir(fsys vfs.FS, root string, fn fs.WalkDirFunc) error {
+	rootName := vfs.Name(root)
+	return fs.WalkDir(fsys, rootName, func(name string, d fs.DirEntry, err error) error {
+		if name == rootName {
+			return fn(root, d, err)
+		}
+		return fn(vfs.Path(name), d, err)
+	})
+}
+
+// RemoveAll removes path in fsys with everything in it, the deepest
+// entries first
+func RemoveAll(ctx context.Context, fsys vfs.FS, path string) error {
+	var paths []string
+	err := WalkDir(fsys, path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range slices.Backward(paths) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fsys.Remove(vfs.Name(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Seek moves the reading position of f to offset, reading its way there
+// on file systems whose files can't seek
+func Seek(f fs.File, offset int64) error {
+	if s, ok := f.(io.Seeker); ok {
+		_, err := s.Seek(offset, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, f, offset)
+	return err
+}
+
+// Download copies the file or directory src in fsys to the local path
+// dst, calling copied with the number of bytes copied as they are.
+// Downloading it again after a failure resumes it: existing directories
+// are filled in and files that are already there in full are skipped.
+// Entries that are neither regular files nor directories are skipped too.
+func Download(ctx context.Context, fsys vfs.FS, src, dst string, copied func(n int64)) error {
+	return WalkDir(fsys, src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			// A directory may be left by a download being resumed
+			if err := os.Mkdir(target, info.Mode().Perm()|0o700); err != nil {
+				if local, statErr := os.Stat(target); statErr != nil || !local.IsDir() {
+					return err
+				}
+			}
+			return nil
+		case info.Mode().IsRegular():
+			if local, err := os.Lstat(target); err == nil && local.Mode().IsRegular() && local.Size() == info.Size() {
+				return nil
+			}
+			return downloadFile(ctx, fsys, path, target, info.Mode().Perm()|0o600, copied)
+		}
+		return nil
+	})
+}
+
+// downloadFile copies the regular file src in fsys to the new local file
+// dst. It is written as dst.part, renamed once complete; a .part left by a
+// download that failed or was cancelled is resumed from where it stopped.
+func downloadFile(ctx context.Context, fsys vfs.FS, src, dst string, perm fs.FileMode, copied func(n int64)) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	in, err := Open(fsys, src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	part := dst + ".part"
+	out, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// Resume a partial download that the file is still longer than
+	var offset int64
+	if partInfo, err := out.Stat(); err == nil && partInfo.Size() < info.Size() {
+		offset = partInfo.Size()
+	}
+	if offset > 0 && Seek(in, offset) != nil {
+		offset = 0
+	}
+	if err := out.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 256*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, rerr := in.Read(chunk)
+		if _, err := out.Write(chunk[:n]); err != nil {
+			return err
+		}
+		copied(int64(n))
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, dst)
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"a.txt", ".hidden", "with space", "..."} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{".", "..", "a/b", "../up"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) succeeded", name)
+		}
+	}
+}
+
+func TestOpsOnReadOnly(t *testing.T) {
+	fsys := vfs.ReadOnly(fstest.MapFS{"a.txt": {Data: []byte("a")}})
+	if _, err := Mkdir(fsys, "/", "new"); !errors.Is(err, vfs.ErrReadOnly) {
+		t.Errorf("Mkdir() = %v, want %v", err, vfs.ErrReadOnly)
+	}
+	if _, err := Rename(fsys, "/a.txt", "b.txt"); !errors.Is(err, vfs.ErrReadOnly) {
+		t.Errorf("Rename() = %v, want %v", err, vfs.ErrReadOnly)
+	}
+	if _, err := Rename(fsys, "/a.txt", "a.txt"); err == nil {
+		t.Error("Rename() onto an existing entry succeeded")
+	}
+	if _, err := CreateFile(fsys, "/", "../x", nil); err == nil {
+		t.Error("CreateFile() with a path separator succeeded")
+	}
+}
+
+func TestOpsOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	fsys := vfs.OS()
+
+	path, err := CreateFile(fsys, dir, "a.txt", []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateFile(fsys, dir, "a.txt", nil); err == nil {
+		t.Error("CreateFile() replaced an existing file")
+	}
+	if err := CopyFile(fsys, path, filepath.Join(dir, "copy.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "copy.txt")); string(data) != "hello" {
+		t.Errorf("copy holds %q, want %q", data, "hello")
+	}
+	sub, err := Mkdir(fsys, dir, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := Rename(fsys, path, "b.txt")
+	if err != nil || moved != filepath.Join(dir, "b.txt") {
+		t.Fatalf("Rename() = %q, %v", moved, err)
+	}
+	if err := CopyFile(fsys, moved, filepath.Join(sub, "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveAll(context.Background(), fsys, sub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(sub); !os.IsNotExist(err) {
+		t.Errorf("%s left after RemoveAll(): %v", sub, err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	fsys := vfs.ReadOnly(fstest.MapFS{
+		"src/a.txt":     {Data: []byte("aaa")},
+		"src/sub/b.txt": {Data: []byte("bb")},
+	})
+	dst := filepath.Join(t.TempDir(), "dst")
+	var copied int64
+	count := func(n int64) { copied += n }
+
+	if err := Download(context.Background(), fsys, "/src", dst, count); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{"a.txt": "aaa", "sub/b.txt": "bb"} {
+		if data, err := os.ReadFile(filepath.Join(dst, path)); err != nil || string(data) != want {
+			t.Errorf("%s holds %q, %v; want %q", path, data, err, want)
+		}
+	}
+	if copied != 5 {
+		t.Errorf("copied %d bytes, want 5", copied)
+	}
+
+	// Downloading again skips what is there and resumes partial files
+	os.Remove(filepath.Join(dst, "sub/b.txt"))
+	os.WriteFile(filepath.Join(dst, "sub/b.txt.part"), []byte("b"), 0o644)
+	copied = 0
+	if err := Download(context.Background(), fsys, "/src", dst, count); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub/b.txt")); string(data) != "bb" || copied != 1 {
+		t.Errorf("resumed file holds %q after copying %d bytes, want %q after 1", data, copied, "bb")
+	}
+}
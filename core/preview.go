@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// Kind is what a file turned out to be when read for a preview
+type Kind int
+
+const (
+	// Text is a file of text
+	Text Kind = iota
+	// Binary is a file of binary data, including images, documents and
+	// anything else whose MIME type isn't text
+	Binary
+	// TooLarge is a file of text larger than the limit
+	TooLarge
+	// Special is neither a regular file nor a directory, such as a named
+	// pipe or a device, which could block forever if read
+	Special
+	// Directory is a directory
+	Directory
+)
+
+// Preview is what a file shows in a preview
+type Preview struct {
+	Kind Kind
+	Info fs.FileInfo
+	// Type is the MIME type of files, detected by DetectType from the
+	// start of their contents and their name
+	Type string
+	// Data is the contents of text and binary files no larger than the
+	// limit, and the start of larger files; nothing is read of special
+	// files and directories
+	Data []byte
+	// Encoding is the encoding of text files, "" for UTF-8
+	Encoding string
+	// Partial reports that Data is only the start of the file
+	Partial bool
+}
+
+// PreviewOptions are how much ReadPreview reads
+type PreviewOptions struct {
+	Limit    int64 // files up to this size are read whole
+	HeadSize int   // the start of larger files read to tell binary files from text
+}
+
+// ReadPreview reads what a preview of the file at path in fsys shows,
+// giving up early once ctx is cancelled
+func ReadPreview(ctx context.Context, fsys vfs.FS, path string, opts PreviewOptions) (Preview, error) {
+	info, err := Stat(fsys, path)
+	if err != nil {
+		return Preview{}, err
+	}
+	p := Preview{Info: info}
+	switch {
+	case info.IsDir():
+		p.Kind = Directory
+		return p, nil
+	case SpecialKind(info.Mode()) != "":
+		p.Kind = Special
+		return p, nil
+	}
+
+	if info.Size() > opts.Limit {
+		if p.Data, err = ReadHead(fsys, path, opts.HeadSize); err != nil {
+			return Preview{}, err
+		}
+		p.Partial = true
+	} else if p.Data, err = ReadFile(ctx, fsys, path); err != nil {
+		return Preview{}, err
+	}
+
+	p.Type = DetectType(filepath.Base(path), p.Data)
+	switch {
+	case !IsTextType(p.Type):
+		p.Kind = Binary
+		return p, nil
+	case p.Partial:
+		p.Kind = TooLarge
+	default:
+		p.Kind = Text
+	}
+	p.Encoding, _ = DetectEncoding(p.Data)
+	return p, nil
+}
+
+// Text returns the text of a text file, transcoded to UTF-8
+func (p Preview) Text() ([]byte, error) {
+	return Decode(p.Data, p.Encoding)
+}
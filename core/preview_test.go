@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+func TestReadPreview(t *testing.T) {
+	large := bytes.Repeat([]byte("line of text\n"), 100)
+	fsys := vfs.ReadOnly(fstest.MapFS{
+		"text.go":   {Data: []byte("package main\n")},
+		"latin.txt": {Data: []byte("caf\xe9\n")},
+		"data.bin":  {Data: []byte{0x7f, 'E', 'L', 'F', 0, 0, 1, 2}},
+		"large.txt": {Data: large},
+		"dir":       {Mode: fs.ModeDir | 0o755},
+		"pipe":      {Mode: fs.ModeNamedPipe | 0o644},
+	})
+	opts := PreviewOptions{Limit: 512, HeadSize: 64}
+
+	tests := []struct {
+		path     string
+		kind     Kind
+		typ      string
+		encoding string
+		data     int // length of Data
+		partial  bool
+	}{
+		{"/text.go", Text, "text/x-go", "", 13, false},
+		{"/latin.txt", Text, "text/plain", Latin1, 5, false},
+		{"/data.bin", Binary, "application/octet-stream", "", 8, false},
+		{"/large.txt", TooLarge, "text/plain", "", 64, true},
+		{"/dir", Directory, "", "", 0, false},
+		{"/pipe", Special, "", "", 0, false},
+	}
+	for _, tt := range tests {
+		p, err := ReadPreview(context.Background(), fsys, tt.path, opts)
+		if err != nil {
+			t.Errorf("ReadPreview(%s): %v", tt.path, err)
+			continue
+		}
+		if p.Kind != tt.kind || p.Type != tt.typ || p.Encoding != tt.encoding || len(p.Data) != tt.data || p.Partial != tt.partial {
+			t.Errorf("ReadPreview(%s) = kind %v, type %q, encoding %q, %d bytes, partial %v; want %v, %q, %q, %d, %v",
+				tt.path, p.Kind, p.Type, p.Encoding, len(p.Data), p.Partial, tt.kind, tt.typ, tt.encoding, tt.data, tt.partial)
+		}
+	}
+
+	if _, err := ReadPreview(context.Background(), fsys, "/missing", opts); err == nil {
+		t.Error("ReadPreview() of a missing file succeeded")
+	}
+}
+
+func TestPreviewText(t *testing.T) {
+	fsys := vfs.ReadOnly(fstest.MapFS{"latin.txt": {Data: []byte("caf\xe9\n")}})
+	p, err := ReadPreview(context.Background(), fsys, "/latin.txt", PreviewOptions{Limit: 512, HeadSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := p.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "café\n" {
+		t.Errorf("Text() = %q, want %q", text, "café\n")
+	}
+}
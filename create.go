@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+// newFile asks for a name and creates an empty file in the current directory
+func (ui *FileExplorerUI) newFile() {
+	if ui.refuseInArchive() || ui.pane.inPathList() {
+		return
+	}
+	ui.prompt("New file", "", func(name string) {
+		if err := ui.createFile(name, nil); err != nil {
+			ui.showError(err)
+		}
+	})
+}
+
+// newDir asks for a name and creates a directory in the current directory
+func (ui *FileExplorerUI) newDir() {
+	if ui.refuseInArchive() || ui.pane.inPathList() {
+		return
+	}
+	ui.prompt("New directory", "", func(name string) {
+		if err := ui.createDir(name); err != nil {
+			ui.showError(err)
+		}
+	})
+}
+
+// createDir creates the directory name in the current directory, refusing
+// to replace an existing entry, and selects it
+func (ui *FileExplorerUI) createDir(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	path, err := core.Mkdir(ui.fsys, ui.pane.path, name)
+	if err != nil {
+		return err
+	}
+	ui.undoLog.Add(ops.Entry{Op: ops.OpCreate, Dst: path})
+
+	ui.pane.loadDirectory(ui.pane.path)
+	ui.pane.selectName(name)
+	ui.setFooterStatus(tview.Escape("Created " + name))
+	return nil
+}
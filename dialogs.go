@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// mainPage is the name of the page holding the explorer grid
+const mainPage = "main"
+
+// centered wraps p so it is drawn in the middle of the screen with the given size
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// panel places p over the middle row of the explorer, leaving the header and
+// footer visible underneath
+func panel(p tview.Primitive) tview.Primitive {
+	return tview.NewGrid().
+		SetRows(1, 0, 1).
+		AddItem(p, 1, 0, 1, 1, 0, 0, true)
+}
+
+// showPage displays p on top of the explorer and gives it focus
+func (ui *FileExplorerUI) showPage(name string, p tview.Primitive) {
+	ui.pages.AddPage(name, p, true, true)
+	ui.setFocus(p)
+}
+
+// closePage removes an overlay and returns focus to whatever is now on top,
+// which is the directory pane once the last overlay is gone
+func (ui *FileExplorerUI) closePage(name string) {
+	ui.pages.RemovePage(name)
+	if front, p := ui.pages.GetFrontPage(); front != mainPage && p != nil {
+		ui.setFocus(p)
+		return
+	}
+	ui.setFocus(ui.pane.table)
+}
+
+// prompt asks for a single line of text. done is only called when the
+// input is confirmed with Enter; Escape dismisses the prompt.
+func (ui *FileExplorerUI) prompt(title, initial string, done func(text string)) {
+	const name = "prompt"
+
+	input := tview.NewInputField().SetText(initial)
+	input.SetBorder(true)
+	input.SetTitle(title)
+	input.SetFieldBackgroundColor(ui.theme.Input)
+	input.SetInputCapture(ui.pasteCapture(input))
+	input.SetDoneFunc(func(key tcell.Key) {
+		text := input.GetText()
+		ui.closePage(name)
+		if key == tcell.KeyEnter {
+			done(text)
+		}
+	})
+
+	ui.showPage(name, centered(input, 60, 3))
+}
+
+// DialogConfig tunes the confirmation dialogs of destructive operations
+type DialogConfig struct {
+	// FocusCancel makes Cancel the button selected when the dialog opens
+	FocusCancel bool `toml:"focus_cancel"`
+	// CancelFirst places Cancel before the destructive button
+	CancelFirst bool `toml:"cancel_first"`
+	// DangerColor is the color of the destructive button and the dialog
+	// border; "" uses the theme's
+	DangerColor string `toml:"danger_color"`
+	// Skip lists the questions not to ask, taken as answered yes: "delete",
+	// "trash", "purge", "overwrite", "transfer", "quit" and "filter"
+	Skip []string `toml:"skip"`
+	// DontAskAgain offers to stop asking a question for the rest of the
+	// session
+	DontAskAgain bool `toml:"dont_ask_again"`
+}
+
+// maxListedPaths caps how many affected paths a danger dialog spells out
+const maxListedPaths = 10
+
+// confirmDanger asks before a destructive action on paths. Directories are
+// scanned first, with the option to cancel, so the dialog can state how many
+// files and bytes are affected. done is called only if the user picks the
+// action button. key names the question as for ask; skipped questions
+// aren't scanned for either.
+func (ui *FileExplorerUI) confirmDanger(key, text, action string, paths []string, done func()) {
+	if ui.skipQuestion(key) {
+		done()
+		return
+	}
+	ui.scanPaths(action, paths, func(summary treeSummary) {
+		ui.showDangerDialog(key, text, action, paths, summary, done)
+	})
+}
+
+// scanPaths totals the files below paths and passes the totals to done.
+// Directories are walked in the background, showing the running totals
+// with the option to cancel the action, which is then reported in the
+// footer and done isn't called.
+func (ui *FileExplorerUI) scanPaths(action string, paths []string, done func(treeSummary)) {
+	if !anyDir(ui.fsys, paths) {
+		var scan treeScan
+		summary, _ := scan.run(ui.ctx, ui.fsys, paths)
+		done(summary)
+		return
+	}
+
+	const name = "scanning"
+	ctx, cancel := context.WithCancel(ui.ctx)
+	var scan treeScan
+
+	modal := tview.NewModal().
+		SetText("Scanning...").
+		AddButtons([]string{"Cancel"}).
+		SetDoneFunc(func(int, string) {
+			cancel()
+			ui.closePage(name)
+			ui.setFooterStatus(action + " cancelled")
+		})
+	ui.showPage(name, modal)
+
+	// Show the running totals while the scan is in progress
+	ui.goBackground(func() {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				text := "Scanning...\n" + scan.progress().String()
+				ui.queueUpdateDraw(func() { modal.SetText(text) })
+			}
+		}
+	})
+
+	ui.goBackground(func() {
+		summary, err := scan.run(ctx, ui.fsys, paths)
+		ui.queueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return // cancelled by the user
+			}
+			cancel()
+			ui.closePage(name)
+			if err != nil {
+				ui.showError(err)
+				return
+			}
+			done(summary)
+		})
+	})
+}
+
+// showDangerDialog shows the confirmation for confirmDanger, listing the
+// affected paths in full with the scanned totals
+func (ui *FileExplorerUI) showDangerDialog(key, text, action string, paths []string, summary treeSummary, done func()) {
+	var b strings.Builder
+	b.WriteString(tview.Escape(text) + "\n\n")
+	b.WriteString(ui.listPaths(paths))
+	fmt.Fprintf(&b, "\n[::b]This will %s %s.[::-]", strings.ToLower(action), summary)
+
+	ui.ask(question{key: key, text: b.String(), action: action, danger: true}, func(a answer) {
+		if a == answerYes {
+			done()
+		}
+	})
+}
+
+// listPaths spells out up to maxListedPaths of paths for a dialog, a line
+// each, marking directories
+func (ui *FileExplorerUI) listPaths(paths []string) string {
+	var b strings.Builder
+	for i, path := range paths {
+		if i == maxListedPaths {
+			fmt.Fprintf(&b, "%s...and %d more[-]\n", colorTag(ui.theme.Muted), len(paths)-maxListedPaths)
+			break
+		}
+		b.WriteString(tview.Escape(path))
+		if info, err := core.Lstat(ui.fsys, path); err == nil && info.IsDir() {
+			b.WriteString(" " + colorTag(ui.theme.Warning) + "(directory)[-]")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// dirSizeTTL is how long a computed directory size is trusted. A change
+// deep below a directory doesn't touch its modification time, so sizes are
+// computed again after a while even when it looks unchanged.
+const dirSizeTTL = time.Minute
+
+// dirSize is the computed total size of the files below a directory
+type dirSize struct {
+	modTime  time.Time // of the directory when it was totalled
+	bytes    int64
+	computed time.Time
+}
+
+// dirSizeCache keeps the sizes of the directories totalled for the Size
+// column, so reloading a listing doesn't walk them again. It is safe for
+// use by the sizing goroutines.
+type dirSizeCache struct {
+	mu    sync.Mutex
+	sizes map[string]dirSize
+}
+
+func newDirSizeCache() *dirSizeCache {
+	return &dirSizeCache{sizes: map[string]dirSize{}}
+}
+
+// get returns the size of the directory at path, if it was totalled
+// recently and hasn't been modified since
+func (c *dirSizeCache) get(path string, modTime time.Time) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sizes[path]
+	if !ok || !s.modTime.Equal(modTime) || time.Since(s.computed) > dirSizeTTL {
+		return 0, false
+	}
+	return s.bytes, true
+}
+
+// put caches the size of the directory at path
+func (c *dirSizeCache) put(path string, modTime time.Time, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sizes[path] = dirSize{modTime: modTime, bytes: bytes, computed: time.Now()}
+}
+
+// sizeDirs totals the directories of the loaded listing in the background,
+// with a pool of workers, when the config asks for directory sizes and the
+// Size column is shown. Their Size cells are filled in as each completes;
+// once all are, a listing sorted by size is sorted again. Loading another
+// listing stops it.
+func (p *Pane) sizeDirs() {
+	if p.sizeCancel != nil {
+		p.sizeCancel()
+		p.sizeCancel = nil
+	}
+	if !p.ui.config.DirSizes || p.inPathList() || inArchive(p.ui.fsys, p.path) || p.sizeColumn() < 0 {
+		return
+	}
+
+	// Sizes still in the cache were picked up while loading
+	dir := p.listingPath
+	cache := p.ui.dirSizes
+	var pending []dirEntry
+	for _, e := range p.listingEntries {
+		if e.IsDir() && !e.Sized {
+			pending = append(pending, e)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(p.ui.ctx)
+	p.sizeCancel = cancel
+	jobs := make(chan dirEntry, len(pending))
+	for _, e := range pending {
+		jobs <- e
+	}
+	close(jobs)
+
+	remaining := len(pending)
+	for range min(runtime.NumCPU(), len(pending)) {
+		p.ui.goBackground(func() {
+			for e := range jobs {
+				path := filepath.Join(dir, e.Name())
+				total, err := (&treeScan{}).run(ctx, p.ui.fsys, []string{path})
+				if err != nil {
+					return
+				}
+				cache.put(path, e.Info.ModTime(), total.bytes)
+				p.ui.queueUpdateDraw(func() {
+					if ctx.Err() != nil || p.listingPath != dir {
+						return
+					}
+					for i := range p.listingEntries {
+						if p.listingEntries[i].Name() == e.Name() {
+							p.setDirSize(i, total.bytes)
+						}
+					}
+					remaining--
+					if remaining == 0 {
+						cancel()
+						if p.ui.sortKey == SortSize && p.load == nil {
+							p.relist()
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// setDirSize records the size of the directory at index i of the listing
+// and shows it in its row
+func (p *Pane) setDirSize(i int, bytes int64) {
+	e := &p.listingEntries[i]
+	e.DirSize, e.Sized = bytes, true
+
+	col := p.sizeColumn()
+	if col < 0 {
+		return
+	}
+	for row := 1; row < p.table.GetRowCount(); row++ {
+		if p.rowName(row) == e.Name() {
+			p.table.SetCell(row, col+1, tview.NewTableCell(sizeColumn.text(*e)))
+			p.paintRow(row)
+			return
+		}
+	}
+}
+
+// sizeColumn returns the index of the Size column among the pane's columns
+// after Name, or -1 when it isn't shown
+func (p *Pane) sizeColumn() int {
+	for i, c := range p.columns() {
+		if c.title == sizeColumn.title {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+package ui
+
+import "errors"
+
+// diskSpace fails on platforms without a way to ask for the free space
+func diskSpace(dir string) (free, total uint64, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// drivesPage is the name of the drive picker overlay
+const drivesPage = "drives"
+
+// goUp navigates to the parent directory. At a drive root on Windows,
+// where there is no parent, it offers the list of drives instead. A path
+// list is left for the working directory.
+func (ui *FileExplorerUI) goUp() {
+	if ui.pane.inPathList() {
+		wd, err := os.Getwd()
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.navigate(wd)
+		return
+	}
+
+	parent := filepath.Dir(ui.pane.path)
+	if parent == ui.pane.path {
+		if drives := listDrives(); len(drives) > 0 {
+			ui.showDrives(drives)
+		}
+		return
+	}
+	ui.navigate(parent)
+}
+
+// showDrives opens a picker listing the available volumes
+func (ui *FileExplorerUI) showDrives(drives []string) {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle("Drives")
+
+	for i, drive := range drives {
+		list.AddItem(drive, "", 0, nil)
+		if filepath.VolumeName(drive) == filepath.VolumeName(ui.pane.path) {
+			list.SetCurrentItem(i)
+		}
+	}
+
+	list.SetSelectedFunc(func(index int, mainText, _ string, _ rune) {
+		ui.closePage(drivesPage)
+		ui.navigate(mainText)
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(drivesPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			ui.closePage(drivesPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(drivesPage, centered(list, 30, len(drives)+2))
+}
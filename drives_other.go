@@ -0,0 +1,8 @@
+//go:build !windows
+
+package ui
+
+// listDrives returns nil; there are no drive letters outside Windows
+func listDrives() []string {
+	return nil
+}
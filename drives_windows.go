@@ -0,0 +1,41 @@
+//go:build windows
+
+package ui
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// listDrives returns the root of every mounted volume, e.g. `C:\`
+func listDrives() []string {
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil
+	}
+
+	var drives []string
+	for i := 0; i < 26; i++ {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		// Skip letters that are assigned but unusable
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil || windows.GetDriveType(rootPtr) == windows.DRIVE_NO_ROOT_DIR {
+			continue
+		}
+		drives = append(drives, root)
+	}
+	return drives
+}
+
+// diskSpace returns the bytes available to the user and the size of the
+// volume holding dir
+func diskSpace(dir string) (free, total uint64, err error) {
+	dirPtr, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = windows.GetDiskFreeSpaceEx(dirPtr, &free, &total, nil)
+	return free, total, err
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/rivo/tview"
+)
+
+// DuplicateName selects the default name suggested when duplicating a file
+type DuplicateName string
+
+const (
+	// DuplicateCopy suggests "name (copy).ext"
+	DuplicateCopy DuplicateName = "copy"
+	// DuplicateBak suggests "name.ext.bak"
+	DuplicateBak DuplicateName = "bak"
+)
+
+// suggestDuplicateName proposes a name for a copy of name
+func suggestDuplicateName(name string, style DuplicateName) string {
+	if style == DuplicateBak {
+		return name + ".bak"
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = "" // dotfiles like .bashrc have no extension
+	}
+	return strings.TrimSuffix(name, ext) + " (copy)" + ext
+}
+
+// duplicateSelected copies the selected file within the current directory
+// under a name asked from the user
+func (ui *FileExplorerUI) duplicateSelected() {
+	if ui.refuseInArchive() {
+		return
+	}
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	info, err := core.Lstat(ui.fsys, path)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	if !info.Mode().IsRegular() {
+		ui.setFooterError("only regular files can be duplicated")
+		return
+	}
+
+	name := filepath.Base(path)
+	ui.prompt("Duplicate as", suggestDuplicateName(name, ui.config.DuplicateName), func(newName string) {
+		newName = strings.TrimSpace(newName)
+		if newName == "" || newName == name {
+			return
+		}
+		if err := core.ValidateName(newName); err != nil {
+			ui.showError(err)
+			return
+		}
+		if err := core.CopyFile(ui.fsys, path, filepath.Join(filepath.Dir(path), newName)); err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.pane.loadDirectory(ui.pane.path)
+		ui.pane.selectName(newName)
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Copied %s to %s", name, newName)))
+	})
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// errorPage is the name of the error details overlay
+const errorPage = "error"
+
+// maxErrorLength caps error messages in the footer; longer ones are cut
+// and left to the details view
+const maxErrorLength = 100
+
+// describeError phrases err concisely for the footer. Common failures get a
+// friendly wording naming the path involved; anything else falls back to the
+// error text, truncated.
+func describeError(err error) string {
+	var path string
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		path = pathErr.Path
+	case errors.As(err, &linkErr):
+		path = linkErr.New
+	}
+
+	var reason string
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		reason = "permission denied"
+	case errors.Is(err, fs.ErrNotExist):
+		reason = "no such file or directory"
+	case errors.Is(err, fs.ErrExist):
+		reason = "already exists"
+	case errors.Is(err, context.Canceled):
+		reason = "cancelled"
+	}
+	if reason == "" {
+		msg := []rune(err.Error())
+		if len(msg) > maxErrorLength {
+			return string(msg[:maxErrorLength-1]) + "…"
+		}
+		return string(msg)
+	}
+	if path != "" {
+		return path + ": " + reason
+	}
+	return strings.ToUpper(reason[:1]) + reason[1:]
+}
+
+// errorChain lists err and every error it wraps, outermost first. Errors
+// joined with errors.Join are followed depth-first.
+func errorChain(err error) []error {
+	var chain []error
+	var walk func(error)
+	walk = func(err error) {
+		for err != nil {
+			chain = append(chain, err)
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range joined.Unwrap() {
+					walk(e)
+				}
+				return
+			}
+			err = errors.Unwrap(err)
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// showError reports err in the footer and keeps it for the details view (E)
+func (ui *FileExplorerUI) showError(err error) {
+	ui.lastErr = err
+	desc := describeError(err)
+	msg := tview.Escape(desc)
+	if desc != err.Error() || len(errorChain(err)) > 1 {
+		msg += " " + colorTag(ui.theme.Muted) + "(E: details)"
+	}
+	ui.setFooterError(msg)
+}
+
+// ReportError shows err in the footer like the explorer's own errors, e.g.
+// one met while preparing it that shouldn't keep it from starting
+func (ui *FileExplorerUI) ReportError(err error) {
+	ui.showError(err)
+}
+
+// showErrorDetails opens the full text and unwrap chain of the last error
+func (ui *FileExplorerUI) showErrorDetails() {
+	if ui.lastErr == nil {
+		ui.setFooterStatus("No errors")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s[::b]%s[-::-]\n\n", colorTag(ui.theme.Error), tview.Escape(describeError(ui.lastErr)))
+	for i, err := range errorChain(ui.lastErr) {
+		fmt.Fprintf(&b, "%d. %s%T[-]\n   %s\n", i+1, colorTag(ui.theme.Title), err, tview.Escape(err.Error()))
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetWrap(true).SetText(b.String())
+	view.SetBorder(true)
+	view.SetTitle("Last Error")
+	view.SetDoneFunc(func(tcell.Key) {
+		ui.closePage(errorPage)
+	})
+
+	ui.showPage(errorPage, panel(view))
+}
@@ -0,0 +1,19 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestShowErrorEscapes(t *testing.T) {
+	dir := t.TempDir()
+	ui := runTestUI(t, dir, DefaultConfig())
+
+	onUI(ui, func() {
+		ui.showError(fmt.Errorf("cannot open %s", "/tmp/[red]x"))
+		if text := ui.footer.GetText(true); !strings.Contains(text, "cannot open /tmp/[red]x") {
+			t.Errorf("footer shows %q, want the path as it is", text)
+		}
+	})
+}
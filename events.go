@@ -0,0 +1,90 @@
+package ui
+
+import "fmt"
+
+// events holds the functions programs embedding the explorer registered to
+// be told about what happens in it
+type events struct {
+	dirChanged       []func(dir string)
+	fileOpened       []func(path string)
+	selectionChanged []func(paths []string)
+	beforeDelete     []func(paths []string) error
+}
+
+// OnDirectoryChanged calls f with the directory the active pane goes to
+// each time it changes directory. Like the other event functions, f runs on
+// the UI goroutine, so it may use the explorer but mustn't block.
+func (ui *FileExplorerUI) OnDirectoryChanged(f func(dir string)) {
+	ui.events.dirChanged = append(ui.events.dirChanged, f)
+}
+
+// OnFileOpened calls f with the path of each file opened: shown in the
+// preview with Enter, or handed to another program
+func (ui *FileExplorerUI) OnFileOpened(f func(path string)) {
+	ui.events.fileOpened = append(ui.events.fileOpened, f)
+}
+
+// OnSelectionChanged calls f with the selection, as returned by
+// SelectedPaths, whenever the cursor moves or the marks change
+func (ui *FileExplorerUI) OnSelectionChanged(f func(paths []string)) {
+	ui.events.selectionChanged = append(ui.events.selectionChanged, f)
+}
+
+// BeforeDelete calls f with the paths about to be deleted or moved to the
+// trash, once confirmed. An error from f cancels the deletion and is
+// reported.
+func (ui *FileExplorerUI) BeforeDelete(f func(paths []string) error) {
+	ui.events.beforeDelete = append(ui.events.beforeDelete, f)
+}
+
+// RegisterAction adds an action named name, listed with title in the help
+// and the command palette, that calls run, bound to keys. The name mustn't
+// be taken by a built-in action or another one registered. An error from
+// run is reported.
+func (ui *FileExplorerUI) RegisterAction(name Action, title string, run func() error, keys ...string) error {
+	if err := ui.plugins.addAction(pluginAction{action: name, title: title, run: run}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := ui.plugins.bind(key, name); err != nil {
+			return fmt.Errorf("action %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// directoryChanged tells the registered functions the active pane went to dir
+func (ui *FileExplorerUI) directoryChanged(dir string) {
+	for _, f := range ui.events.dirChanged {
+		f(dir)
+	}
+}
+
+// fileOpened tells the registered functions path was opened
+func (ui *FileExplorerUI) fileOpened(path string) {
+	for _, f := range ui.events.fileOpened {
+		f(path)
+	}
+}
+
+// selectionChanged tells the registered functions what is selected now
+func (ui *FileExplorerUI) selectionChanged() {
+	if len(ui.events.selectionChanged) == 0 {
+		return
+	}
+	paths := ui.SelectedPaths()
+	for _, f := range ui.events.selectionChanged {
+		f(paths)
+	}
+}
+
+// beforeDelete asks the registered functions whether paths can be deleted,
+// returning the first objection
+func (ui *FileExplorerUI) beforeDelete(paths []string) error {
+	for _, f := range ui.events.beforeDelete {
+		if err := f(paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// exifTag is an EXIF field shown in image previews
+type exifTag struct {
+	id    uint16
+	label string
+}
+
+// exifTags are the fields shown, in order. The first four live in IFD0,
+// the others in the Exif sub-IFD.
+var exifTags = []exifTag{
+	{0x010F, "Camera make"},
+	{0x0110, "Camera model"},
+	{0x0112, "Orientation"},
+	{0x0131, "Software"},
+	{0x9003, "Taken"},
+	{0x829A, "Exposure"},
+	{0x829D, "F-number"},
+	{0x8827, "ISO"},
+	{0x920A, "Focal length"},
+}
+
+// exifIFDPointer is the IFD0 tag pointing at the Exif sub-IFD
+const exifIFDPointer = 0x8769
+
+// jpegExif returns the TIFF-structured EXIF block of a JPEG file, or nil
+func jpegExif(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data follows; metadata segments come before it
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+// parseExif reads the fields in exifTags from a TIFF-structured EXIF
+// block, returning "label: value" lines for those present
+func parseExif(tiff []byte) []string {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	values := map[uint16]string{}
+	ifd0 := order.Uint32(tiff[4:])
+	sub := readIFD(tiff, order, ifd0, values)
+	if sub != 0 {
+		readIFD(tiff, order, sub, values)
+	}
+
+	var lines []string
+	for _, tag := range exifTags {
+		if v := values[tag.id]; v != "" {
+			lines = append(lines, tag.label+": "+v)
+		}
+	}
+	return lines
+}
+
+// readIFD stores the printable values of the entries of the IFD at offset
+// in values and returns the offset of the Exif sub-IFD if it points to one
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, values map[uint16]string) (sub uint32) {
+	if int64(offset)+2 > int64(len(tiff)) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := range count {
+		at := int(offset) + 2 + 12*i
+		if at+12 > len(tiff) {
+			break
+		}
+		entry := tiff[at : at+12]
+		id := order.Uint16(entry)
+		kind := order.Uint16(entry[2:])
+		n := order.Uint32(entry[4:])
+		if id == exifIFDPointer {
+			sub = order.Uint32(entry[8:])
+			continue
+		}
+		if v := exifValue(tiff, order, kind, n, entry[8:]); v != "" {
+			values[id] = v
+		}
+	}
+	return sub
+}
+
+// exifValue formats an IFD entry of the given type and count whose value,
+// or the offset of its value, is in field. Only the types used by exifTags
+// are handled.
+func exifValue(tiff []byte, order binary.ByteOrder, kind uint16, n uint32, field []byte) string {
+	// data returns the n*size bytes of the value, inline or at its offset
+	data := func(size uint32) []byte {
+		total := uint64(n) * uint64(size)
+		if total <= 4 {
+			return field[:total]
+		}
+		off := uint64(order.Uint32(field))
+		if off+total > uint64(len(tiff)) {
+			return nil
+		}
+		return tiff[off : off+total]
+	}
+
+	switch kind {
+	case 2: // ASCII
+		b := data(1)
+		return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	case 3: // SHORT
+		if b := data(2); len(b) >= 2 {
+			return fmt.Sprint(order.Uint16(b))
+		}
+	case 4: // LONG
+		if b := data(4); len(b) >= 4 {
+			return fmt.Sprint(order.Uint32(b))
+		}
+	case 5: // RATIONAL
+		if b := data(8); len(b) >= 8 {
+			num, den := order.Uint32(b), order.Uint32(b[4:])
+			switch {
+			case den == 0:
+				return ""
+			case num < den && num != 0:
+				return fmt.Sprintf("%d/%d", num, den)
+			}
+			return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(num)/float64(den)), ".0")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aktagon/gofiles/archive"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+// jobDialog shows the progress of a batch of jobs until the last one is
+// done, with the option to cancel them or to keep them running in the
+// background with their progress in the footer
+type jobDialog struct {
+	jobs     []ops.Job // the jobs not done yet
+	progress *progressDialog
+}
+
+// selectedArchives returns the selected entries if they are all archives,
+// and otherwise reports the first that isn't
+func (ui *FileExplorerUI) selectedArchives() ([]string, bool) {
+	if ui.refuseInArchive() || ui.refuseNonLocal() {
+		return nil, false
+	}
+	paths := ui.SelectedPaths()
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || !isArchiveFile(ui.fsys, path, info) {
+			ui.setFooterError(tview.Escape(filepath.Base(path) + " is not an archive"))
+			return nil, false
+		}
+	}
+	return paths, len(paths) > 0
+}
+
+// extractHere unpacks the selected archives into the current directory
+func (ui *FileExplorerUI) extractHere() {
+	if paths, ok := ui.selectedArchives(); ok {
+		ui.startExtract(paths, ui.pane.path)
+	}
+}
+
+// extractTo asks where to unpack the selected archives. The prompt starts
+// from a directory named after a single archive, in the other pane's
+// directory in the dual-pane layout.
+func (ui *FileExplorerUI) extractTo() {
+	paths, ok := ui.selectedArchives()
+	if !ok {
+		return
+	}
+	initial, _ := ui.transferDir()
+	title := "Extract to"
+	if len(paths) == 1 {
+		initial = filepath.Join(initial, archiveStem(paths[0]))
+	} else {
+		title = fmt.Sprintf("%s (%d archives)", title, len(paths))
+	}
+	ui.prompt(title, initial, func(dst string) {
+		dst = strings.TrimSpace(dst)
+		if dst == "" {
+			return
+		}
+		if !filepath.IsAbs(dst) {
+			dst = filepath.Join(ui.pane.path, dst)
+		}
+		ui.startExtract(paths, dst)
+	})
+}
+
+// archiveStem returns the name of an archive without its extension, e.g.
+// "src" for "src.tar.gz"
+func archiveStem(path string) string {
+	name := filepath.Base(path)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if ext := filepath.Ext(stem); strings.EqualFold(ext, ".tar") {
+		stem = strings.TrimSuffix(stem, ext)
+	}
+	if stem == "" {
+		return name
+	}
+	return stem
+}
+
+// startExtract queues the extraction of the archives into dst. Entries of
+// dst that the archives would replace are looked up first, in the
+// background since that means reading the archives, and overwritten only
+// once confirmed.
+func (ui *FileExplorerUI) startExtract(paths []string, dst string) {
+	ui.goBackground(func() {
+		conflicts, err := extractConflicts(paths, dst)
+		ui.queueUpdateDraw(func() {
+			if err != nil {
+				ui.showError(err)
+				return
+			}
+			queue := func(overwrite bool) {
+				ui.pane.clearMarks()
+				jobs := make([]ops.Job, len(paths))
+				for i, path := range paths {
+					jobs[i] = ops.Job{Kind: ops.Extract, Src: path, Dst: dst, Overwrite: overwrite}
+					ui.queueJob(jobs[i])
+				}
+				ui.showJobDialog(jobs)
+			}
+			if len(conflicts) == 0 {
+				queue(false)
+				return
+			}
+			ui.confirmDanger(questionOverwrite, "Overwrite existing entries?", "Overwrite", conflicts, func() {
+				queue(true)
+			})
+		})
+	})
+}
+
+// extractConflicts returns the entries of dst that the top-level members
+// of the archives would replace
+func extractConflicts(paths []string, dst string) ([]string, error) {
+	var conflicts []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		fsys, err := archive.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		entries, err := fs.ReadDir(fsys, ".")
+		fsys.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		for _, e := range entries {
+			target := filepath.Join(dst, e.Name())
+			if _, err := os.Lstat(target); err == nil && !seen[target] {
+				seen[target] = true
+				conflicts = append(conflicts, target)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// showJobDialog shows the progress of jobs that were just queued
+func (ui *FileExplorerUI) showJobDialog(jobs []ops.Job) {
+	text := fmt.Sprintf("%s %s...", jobVerb(jobs[0].Kind), jobName(jobs[0]))
+	if waiting := ui.jobs.Len() - len(jobs); waiting > 0 {
+		text = fmt.Sprintf("Waiting for %d other jobs to finish...", waiting)
+	}
+
+	// The dialog follows the jobs started last
+	if old := ui.jobDialog; old != nil {
+		old.progress.close()
+	}
+	d := &jobDialog{jobs: jobs}
+	d.progress = ui.newProgressDialog(text,
+		func() {
+			ui.jobDialog = nil
+			ui.setFooterStatus(fmt.Sprintf("Cancelled %d jobs", len(d.jobs)))
+			for _, job := range d.jobs {
+				ui.jobs.CancelJob(job)
+			}
+		},
+		func() {
+			ui.jobDialog = nil
+		})
+	ui.jobDialog = d
+}
+
+// updateJobDialog shows the progress of a job if the dialog is following it
+func (ui *FileExplorerUI) updateJobDialog(p ops.Progress) {
+	if d := ui.jobDialog; d != nil && slices.ContainsFunc(d.jobs, p.Job.Equal) {
+		label := fmt.Sprintf("%s %s", jobVerb(p.Job.Kind), jobName(p.Job))
+		if p.Pending > 0 {
+			label += fmt.Sprintf(", %d more queued", p.Pending)
+		}
+		d.progress.update(label, p.Stats)
+	}
+}
+
+// closeJobDialog notes that job is done, closing the dialog once all the
+// jobs it follows are
+func (ui *FileExplorerUI) closeJobDialog(job ops.Job) {
+	d := ui.jobDialog
+	if d == nil {
+		return
+	}
+	d.jobs = slices.DeleteFunc(d.jobs, job.Equal)
+	if len(d.jobs) == 0 {
+		ui.jobDialog = nil
+		d.progress.close()
+	}
+}
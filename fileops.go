@@ -0,0 +1,389 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/trash"
+	"github.com/rivo/tview"
+)
+
+// newJobQueue creates the queue running file operations, which reports
+// their progress in the footer and refreshes the listing after each one
+func (ui *FileExplorerUI) newJobQueue() *ops.Queue {
+	q := ops.NewQueue(ui.ctx, ui.undoLog, func(job ops.Job, err error) {
+		ui.queueUpdateDraw(func() {
+			ui.jobDone(job, err)
+		})
+	})
+	ui.goBackground(func() {
+		for {
+			select {
+			case <-ui.ctx.Done():
+				return
+			case p := <-q.Progress():
+				ui.queueUpdateDraw(func() {
+					// The job may have finished while the report was queued
+					if job, ok := q.Running(); !ok || !job.Equal(p.Job) {
+						return
+					}
+					ui.setFooterStatus(tview.Escape(describeProgress(p)))
+					ui.updateJobDialog(p)
+				})
+			}
+		}
+	})
+	return q
+}
+
+// describeProgress renders the progress of a running job for the footer
+func describeProgress(p ops.Progress) string {
+	name := jobName(p.Job)
+	// Moves only copy, without knowing how much, across filesystems
+	moving := p.Job.Kind == ops.Move || p.Job.Kind == ops.Trash
+	var text string
+	switch {
+	case p.Job.Kind == ops.Delete:
+		text = "Deleting " + name + "..."
+	case !moving && p.Total > 0:
+		text = fmt.Sprintf("%s %s: %d%% (%s of %s)", jobVerb(p.Job.Kind), name,
+			p.Done*100/p.Total, formatSize(p.Done), formatSize(p.Total))
+	case moving && p.Done > 0:
+		text = fmt.Sprintf("%s %s: %s copied", jobVerb(p.Job.Kind), name, formatSize(p.Done))
+	default:
+		text = fmt.Sprintf("%s %s...", jobVerb(p.Job.Kind), name)
+	}
+	if p.Pending > 0 {
+		text += fmt.Sprintf(", %d more queued", p.Pending)
+	}
+	return text
+}
+
+// jobVerb returns the progressive form of an operation, e.g. "Copying"
+func jobVerb(kind ops.Kind) string {
+	switch kind {
+	case ops.Copy:
+		return "Copying"
+	case ops.Move:
+		return "Moving"
+	case ops.Extract:
+		return "Extracting"
+	case ops.Compress:
+		return "Compressing"
+	case ops.Trash:
+		return "Trashing"
+	}
+	return "Deleting"
+}
+
+// jobName returns the name of the entry a job is about: the archive being
+// created, or else the source
+func jobName(job ops.Job) string {
+	if job.Kind == ops.Compress {
+		return filepath.Base(job.Dst)
+	}
+	return filepath.Base(job.Src)
+}
+
+// jobDone reports a finished job and refreshes the listings
+func (ui *FileExplorerUI) jobDone(job ops.Job, err error) {
+	ui.autoRefresh()
+	ui.closeJobDialog(job)
+
+	name := jobName(job)
+	switch {
+	case errors.Is(err, context.Canceled):
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("%s %s cancelled", jobVerb(job.Kind), name)))
+	case err != nil:
+		ui.showError(fmt.Errorf("%s %s: %w", job.Kind, name, err))
+	case job.Kind == ops.Copy:
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Copied %s to %s", name, job.Dst)))
+	case job.Kind == ops.Move:
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Moved %s to %s", name, job.Dst)))
+	case job.Kind == ops.Extract:
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Extracted %s to %s", name, job.Dst)))
+	case job.Kind == ops.Compress:
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("Created %s with %d entries", job.Dst, len(job.Srcs))))
+	case job.Kind == ops.Trash:
+		ui.setFooterStatus(tview.Escape("Moved " + name + " to the trash"))
+	default:
+		ui.setFooterStatus(tview.Escape("Deleted " + name))
+	}
+}
+
+// queueJob hands job to the queue, noting in the footer if it has to wait
+func (ui *FileExplorerUI) queueJob(job ops.Job) {
+	if n := ui.jobs.Len(); n > 0 {
+		ui.setFooterStatus(fmt.Sprintf("Queued %s of %s behind %d jobs",
+			job.Kind, jobName(job), n))
+	}
+	ui.jobs.Add(job)
+}
+
+// copySelected asks where to copy the selected entries and queues the copies
+func (ui *FileExplorerUI) copySelected() {
+	ui.transferSelected(ops.Copy, "Copy to")
+}
+
+// moveSelected asks where to move the selected entries and queues the moves
+func (ui *FileExplorerUI) moveSelected() {
+	ui.transferSelected(ops.Move, "Move to")
+}
+
+// transferSelected prompts for the destination of a copy or move of the
+// selected entries. A single entry can be given a new path, starting from
+// its current one; several entries go into an existing directory. In the
+// dual-pane layout the prompt starts from the other pane's directory.
+func (ui *FileExplorerUI) transferSelected(kind ops.Kind, title string) {
+	if ui.refuseInArchive() {
+		return
+	}
+	if !ui.local && kind == ops.Copy {
+		ui.downloadSelected()
+		return
+	}
+	if ui.refuseNonLocal() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	target, dual := ui.transferDir()
+	switch len(paths) {
+	case 0:
+		return
+	case 1:
+		path := paths[0]
+		initial := path
+		if dual {
+			initial = filepath.Join(target, filepath.Base(path))
+		}
+		ui.prompt(title, initial, func(dst string) {
+			dst = strings.TrimSpace(dst)
+			if dst == "" || dst == path {
+				return
+			}
+			ui.startTransfers([]ops.Job{{Kind: kind, Src: path, Dst: ui.resolveTarget(path, dst)}})
+		})
+	default:
+		title = fmt.Sprintf("%s (%d entries)", title, len(paths))
+		ui.prompt(title, target, func(dir string) {
+			dir = strings.TrimSpace(dir)
+			if dir == "" {
+				return
+			}
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(ui.pane.path, dir)
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				ui.setFooterError(tview.Escape(dir + " is not a directory"))
+				return
+			}
+			jobs := make([]ops.Job, len(paths))
+			for i, path := range paths {
+				jobs[i] = ops.Job{Kind: kind, Src: path, Dst: ui.resolveTarget(path, dir)}
+			}
+			ui.startTransfers(jobs)
+		})
+	}
+}
+
+// startTransfers queues copies or moves of the selected entries once they
+// are confirmed: with a summary of what directories hold, and which of the
+// entries they would replace to overwrite
+func (ui *FileExplorerUI) startTransfers(jobs []ops.Job) {
+	ui.confirmTransfer(jobs, func() {
+		ui.confirmOverwrites(jobs, func(jobs []ops.Job) {
+			ui.pane.clearMarks()
+			for _, job := range jobs {
+				ui.queueJob(job)
+			}
+			ui.showJobDialog(jobs)
+		})
+	})
+}
+
+// confirmTransfer asks before copying or moving directories, which are
+// scanned first with the option to cancel, stating how many files and
+// bytes they hold. Files alone are transferred without asking. done is
+// called once the transfer is confirmed.
+func (ui *FileExplorerUI) confirmTransfer(jobs []ops.Job, done func()) {
+	srcs := make([]string, len(jobs))
+	for i, job := range jobs {
+		srcs[i] = job.Src
+	}
+	if ui.skipQuestion(questionTransfer) || !anyDir(ui.fsys, srcs) {
+		done()
+		return
+	}
+
+	action := "Copy"
+	if jobs[0].Kind == ops.Move {
+		action = "Move"
+	}
+	dst := jobs[0].Dst
+	if len(jobs) > 1 {
+		dst = filepath.Dir(dst)
+	}
+	ui.scanPaths(action, srcs, func(summary treeSummary) {
+		text := fmt.Sprintf("%s to %s?\n\n%s\n[::b]This will %s %s.[::-]",
+			action, tview.Escape(dst), ui.listPaths(srcs), strings.ToLower(action), summary)
+		ui.ask(question{key: questionTransfer, text: text, action: action}, func(a answer) {
+			if a == answerYes {
+				done()
+			}
+		})
+	})
+}
+
+// confirmOverwrites asks, one at a time, whether the jobs whose destination
+// exists should replace it, then passes the jobs to go ahead with to done:
+// those without a conflict, and those confirmed with Overwrite set. Skip
+// leaves a job out, All overwrites the rest without asking and Cancel drops
+// every job.
+func (ui *FileExplorerUI) confirmOverwrites(jobs []ops.Job, done func([]ops.Job)) {
+	conflicts := 0
+	for _, job := range jobs {
+		if _, err := os.Lstat(job.Dst); err == nil {
+			conflicts++
+		}
+	}
+
+	var accepted []ops.Job
+	var next func(i int, all bool)
+	next = func(i int, all bool) {
+		for ; i < len(jobs); i++ {
+			job := jobs[i]
+			existing, err := os.Lstat(job.Dst)
+			if err != nil {
+				accepted = append(accepted, job)
+				continue
+			}
+			if all {
+				job.Overwrite = true
+				accepted = append(accepted, job)
+				continue
+			}
+			conflicts--
+			q := question{
+				key:    questionOverwrite,
+				text:   describeOverwrite(job, existing, ui.theme),
+				action: "Overwrite",
+				many:   conflicts > 0,
+				danger: true,
+			}
+			ui.ask(q, func(a answer) {
+				switch a {
+				case answerNo:
+					ui.setFooterStatus(jobVerb(job.Kind) + " cancelled")
+					return
+				case answerYes, answerAll:
+					job.Overwrite = true
+					accepted = append(accepted, job)
+				}
+				next(i+1, a == answerAll)
+			})
+			return
+		}
+		if len(accepted) > 0 {
+			done(accepted)
+		}
+	}
+	next(0, false)
+}
+
+// describeOverwrite asks whether the destination of job, existing, should
+// be replaced, comparing it with the source, with labels in theme's colors
+func describeOverwrite(job ops.Job, existing os.FileInfo, theme Theme) string {
+	describe := func(info os.FileInfo) string {
+		modified := info.ModTime().Format("2006-01-02 15:04")
+		if info.IsDir() {
+			return "directory, modified " + modified
+		}
+		return formatSize(info.Size()) + ", modified " + modified
+	}
+	label := colorTag(theme.Title)
+	text := fmt.Sprintf("%s already exists.\n\n%sExisting:[-] %s\n",
+		tview.Escape(job.Dst), label, describe(existing))
+	if src, err := os.Lstat(job.Src); err == nil {
+		text += fmt.Sprintf("%sReplacement:[-] %s\n", label, describe(src))
+	}
+	return text + "\nReplace it?"
+}
+
+// transferDir returns the directory copies and moves go to by default: the
+// other pane's in the dual-pane layout, reported by dual, or else the current
+// one
+func (ui *FileExplorerUI) transferDir() (dir string, dual bool) {
+	if other := ui.otherPane(); ui.dual && !other.inPathList() {
+		return other.path, true
+	}
+	return ui.pane.path, false
+}
+
+// resolveTarget interprets a destination typed by the user: relative paths
+// are taken from the current directory, and an existing directory receives
+// src under its own name
+func (ui *FileExplorerUI) resolveTarget(src, dst string) string {
+	if !filepath.IsAbs(dst) {
+		dst = filepath.Join(ui.pane.path, dst)
+	}
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	return dst
+}
+
+// deleteSelected moves the selected entries to the trash after
+// confirmation, or deletes them permanently with PermanentDelete set or on
+// file systems other than the local disk, which have no trash. Where the
+// system trash isn't supported, deleting permanently instead is always
+// confirmed, whatever the dialogs config says. Functions registered with BeforeDelete
+// can still object once it is confirmed.
+func (ui *FileExplorerUI) deleteSelected() {
+	if ui.refuseInArchive() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+	confirmed := func(del func()) func() {
+		return func() {
+			if err := ui.beforeDelete(paths); err != nil {
+				ui.showError(err)
+				return
+			}
+			ui.pane.clearMarks()
+			del()
+		}
+	}
+	if !ui.local {
+		ui.confirmDanger(questionDelete, "Delete permanently?", "Delete", paths, confirmed(func() {
+			ui.removeRemote(paths)
+		}))
+		return
+	}
+	if ui.config.PermanentDelete || !trash.Supported {
+		key, text := questionDelete, "Delete permanently?"
+		if !ui.config.PermanentDelete {
+			// Delete is meant to trash here, so never skip asking
+			key, text = "", fmt.Sprintf("The system trash is not supported on %s.\nDelete permanently instead?", runtime.GOOS)
+		}
+		ui.confirmDanger(key, text, "Delete", paths, confirmed(func() {
+			for _, path := range paths {
+				ui.queueJob(ops.Job{Kind: ops.Delete, Src: path})
+			}
+		}))
+		return
+	}
+	ui.confirmDanger(questionTrash, "Move to the trash?", "Trash", paths, confirmed(func() {
+		for _, path := range paths {
+			ui.queueJob(ops.Job{Kind: ops.Trash, Src: path})
+		}
+	}))
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// FilterPolicy decides what happens to an active filter when changing directory
+type FilterPolicy string
+
+const (
+	// FilterClear drops the filter on every directory change
+	FilterClear FilterPolicy = "clear"
+	// FilterKeep applies the same filter in the new directory
+	FilterKeep FilterPolicy = "keep"
+	// FilterAsk asks whether to keep the filter before changing directory
+	FilterAsk FilterPolicy = "ask"
+)
+
+// filterBarPage is the name of the filter bar
+const filterBarPage = "filterbar"
+
+// matchesFilter reports whether name passes the pane's filter
+func (p *Pane) matchesFilter(name string) bool {
+	return core.MatchesFilter(name, p.filter)
+}
+
+// highlightFilter escapes name for a table cell, marking the part of it
+// that matches the filter
+func (p *Pane) highlightFilter(name string) string {
+	lower := strings.ToLower(name)
+	i := -1
+	if p.filter != "" && len(lower) == len(name) {
+		i = strings.Index(lower, strings.ToLower(p.filter))
+	}
+	if i < 0 {
+		return tview.Escape(name)
+	}
+	j := i + len(p.filter)
+	return tview.Escape(name[:i]) + "[" + p.ui.theme.Match.String() + "::u]" +
+		tview.Escape(name[i:j]) + "[-::-]" + tview.Escape(name[j:])
+}
+
+// setFilter changes the active filter and lists the directory again
+func (ui *FileExplorerUI) setFilter(filter string) {
+	ui.pane.filter = filter
+	ui.pane.updateDirTitle()
+	ui.pane.relist()
+}
+
+// toggleHidden shows or hides dotfiles. The choice holds for every
+// directory until toggled again or the config is reloaded.
+func (ui *FileExplorerUI) toggleHidden() {
+	ui.showHidden = !ui.showHidden
+	for _, p := range ui.visiblePanes() {
+		p.reload()
+	}
+	if ui.showHidden {
+		ui.setFooterStatus("Showing hidden files")
+	} else {
+		ui.setFooterStatus("Hiding hidden files")
+	}
+}
+
+// SetFilter narrows the listing to names containing term, e.g. to start the
+// explorer in search mode. Blank terms leave the listing unfiltered and terms
+// with control characters are rejected.
+func (ui *FileExplorerUI) SetFilter(term string) error {
+	term = strings.TrimSpace(term)
+	if strings.IndexFunc(term, unicode.IsControl) >= 0 {
+		err := fmt.Errorf("invalid filter %q", term)
+		ui.showError(err)
+		return err
+	}
+	ui.setFilter(term)
+	return nil
+}
+
+// showFilterBar opens the filter bar over the footer, pre-filled with the
+// current filter. The listing narrows to the names containing what is typed
+// as it is typed, and the arrows keep moving the selection. Enter keeps the
+// filter and Escape clears it.
+func (ui *FileExplorerUI) showFilterBar() {
+	p := ui.pane
+	input := tview.NewInputField().SetLabel("Filter: ").SetText(p.filter)
+	input.SetLabelColor(ui.theme.FooterKey)
+	input.SetBackgroundColor(ui.theme.FooterBackground)
+	input.SetFieldBackgroundColor(ui.theme.Input)
+	input.SetChangedFunc(func(text string) {
+		p.filter = text
+		p.updateDirTitle()
+		p.relist()
+		// Select the first match once the selected entry is filtered out
+		if row, _ := p.table.GetSelection(); text != "" {
+			if _, ok := p.rowPath(row); !ok && p.load == nil {
+				for r := row + 1; r < p.table.GetRowCount(); r++ {
+					if _, ok := p.rowPath(r); ok {
+						p.table.Select(r, 0)
+						break
+					}
+				}
+			}
+		}
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			p.table.InputHandler()(event, func(tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		ui.closePage(filterBarPage)
+		if key == tcell.KeyEscape {
+			ui.setFilter("")
+		}
+	})
+
+	bar := tview.NewGrid().
+		SetRows(0, 1).
+		AddItem(input, 1, 0, 1, 1, 0, 0, true)
+	ui.showPage(filterBarPage, bar)
+}
+
+// updateDirTitle shows the tabs, the active filter and loading progress in
+// the directory pane's title
+func (p *Pane) updateDirTitle() {
+	title := "Directory Contents"
+	if tabs := p.tabBar(); tabs != "" {
+		title = tabs
+	}
+	if p.filter != "" {
+		title += fmt.Sprintf(" %s(filter: %s)[-]", colorTag(p.ui.theme.Warning), tview.Escape(p.filter))
+	}
+	if p.load != nil {
+		title += fmt.Sprintf(" %s(loading %s..., Esc cancels)[-]", colorTag(p.ui.theme.Muted),
+			plural(int64(len(p.load.files)), "entry", "entries"))
+	}
+	p.table.SetTitle(title)
+}
+
+// navigate changes to another directory, handling an active filter
+// according to the configured FilterPolicy
+func (ui *FileExplorerUI) navigate(path string) {
+	ui.navigateContext(context.Background(), path)
+}
+
+// navigateContext is navigate giving up on the listing once ctx is cancelled
+func (ui *FileExplorerUI) navigateContext(ctx context.Context, path string) {
+	if ui.pane.filter == "" || path == ui.pane.path {
+		ui.changeDirContext(ctx, path)
+		return
+	}
+
+	switch ui.config.FilterOnNavigate {
+	case FilterKeep:
+		ui.changeDirContext(ctx, path)
+	case FilterAsk:
+		text := fmt.Sprintf("Filter %s is active.\nKeep it in %s?",
+			tview.Escape(strconv.Quote(ui.pane.filter)), tview.Escape(path))
+		ui.ask(question{key: questionFilter, text: text, action: "Keep", other: "Clear"}, func(a answer) {
+			switch a {
+			case answerYes:
+				ui.changeDirContext(ctx, path)
+			case answerOther:
+				ui.pane.filter = ""
+				ui.pane.updateDirTitle()
+				ui.changeDirContext(ctx, path)
+			}
+		})
+	default:
+		ui.pane.filter = ""
+		ui.pane.updateDirTitle()
+		ui.changeDirContext(ctx, path)
+	}
+}
+
+// changeDir makes path the current directory and lists it, recording the
+// move in the tab's history
+func (ui *FileExplorerUI) changeDir(path string) {
+	ui.changeDirContext(context.Background(), path)
+}
+
+// changeDirContext is changeDir giving up on the listing once ctx is cancelled
+func (ui *FileExplorerUI) changeDirContext(ctx context.Context, path string) {
+	ui.pane.history.visit(ui.pane.path, path)
+	ui.visitDir(path)
+	ui.pane.pathList = nil
+	clear(ui.pane.marked)
+	ui.pane.path = path
+	ui.pane.loadDirectoryContext(ctx, path)
+	ui.directoryChanged(path)
+}
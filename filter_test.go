@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilterAsk(t *testing.T) {
+	tests := []struct {
+		name  string
+		skip  []string
+		asked bool
+	}{
+		{"asked", nil, true},
+		{"skipped", []string{questionFilter}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			sub := filepath.Join(dir, "sub")
+			if err := os.Mkdir(sub, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			cfg := DefaultConfig()
+			cfg.FilterOnNavigate = FilterAsk
+			cfg.Dialogs.Skip = tt.skip
+			ui := runTestUI(t, dir, cfg)
+			waitListed(t, ui, "sub")
+
+			q := question{key: questionFilter}
+			onUI(ui, func() {
+				ui.setFilter("sub")
+				ui.navigate(sub)
+				if asked := ui.pages.HasPage(confirmPage(q)); asked != tt.asked {
+					t.Fatalf("asked is %v, want %v", asked, tt.asked)
+				}
+				if !tt.asked && (ui.pane.path != sub || ui.pane.filter != "sub") {
+					t.Errorf("went to %s with filter %q, want %s keeping it", ui.pane.path, ui.pane.filter, sub)
+				}
+			})
+		})
+	}
+}
+
+func TestNavigateContext(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.FilterOnNavigate = FilterClear
+	ui := runTestUI(t, dir, cfg)
+	waitListed(t, ui, "sub")
+
+	onUI(ui, func() {
+		ui.setFilter("sub")
+		if err := ui.NavigateContext(t.Context(), "sub"); err != nil {
+			t.Fatal(err)
+		}
+		if ui.pane.path != sub || ui.pane.filter != "" {
+			t.Errorf("went to %s with filter %q, want %s without one", ui.pane.path, ui.pane.filter, sub)
+		}
+		if back := ui.pane.history.back; len(back) != 1 || back[0] != dir {
+			t.Errorf("history goes back to %q, want %s", back, dir)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// keyHint describes a keybinding shown in the footer
+type keyHint struct {
+	name    string   // identifier used in the footer_hints config list
+	actions []Action // actions whose keys are shown
+	key     string   // shown for hints of keys that aren't in the keymap
+	label   string
+}
+
+// keyHints lists every hint the footer can show, in display order
+var keyHints = []keyHint{
+	{"navigate", nil, "↑/↓", "Navigate"},
+	{"open", []Action{ActionOpen}, "", "Open"},
+	{"openwith", []Action{ActionOpenExternal, ActionOpenWith}, "", "Open Externally/With"},
+	{"edit", []Action{ActionEdit}, "", "Edit"},
+	{"shell", []Action{ActionShell}, "", "Run Command"},
+	{"up", []Action{ActionGoUp}, "", "Go Up"},
+	{"path", []Action{ActionBreadcrumb}, "", "Path"},
+	{"history", []Action{ActionBack, ActionForward, ActionHistory}, "", "Back/Forward/History"},
+	{"siblings", []Action{ActionPrevSibling, ActionNextSibling}, "", "Prev/Next Sibling"},
+	{"places", []Action{ActionPlaces}, "", "Places"},
+	{"sources", []Action{ActionSources}, "", "Sources"},
+	{"mounts", []Action{ActionMounts}, "", "Mounts"},
+	{"bookmarks", []Action{ActionBookmark, ActionBookmarks}, "", "Bookmark/Bookmarks"},
+	{"jump", []Action{ActionJump}, "", "Jump"},
+	{"goto", []Action{ActionGoto}, "", "Go To"},
+	{"pane", []Action{ActionSwitchPane}, "", "Other Pane"},
+	{"dual", []Action{ActionDualPane}, "", "Dual Pane"},
+	{"tree", []Action{ActionTree}, "", "Tree"},
+	{"tabs", []Action{ActionNewTab, ActionCloseTab, ActionNextTab}, "", "New/Close/Next Tab"},
+	{"filter", []Action{ActionFilter}, "", "Filter"},
+	{"hidden", []Action{ActionHidden}, "", "Hidden Files"},
+	{"ignored", []Action{ActionGitIgnored}, "", "Git Ignored"},
+	{"diff", []Action{ActionGitDiff}, "", "Git Diff"},
+	{"search", []Action{ActionSearch}, "", "Search"},
+	{"grep", []Action{ActionGrep}, "", "Search in Files"},
+	{"mark", []Action{ActionMark}, "", "Mark"},
+	{"markall", []Action{ActionMarkAll, ActionInvertMarks}, "", "Mark All/Invert"},
+	{"copy", []Action{ActionCopy}, "", "Copy"},
+	{"move", []Action{ActionMove}, "", "Move"},
+	{"delete", []Action{ActionDelete}, "", "Delete"},
+	{"rename", []Action{ActionRename}, "", "Rename"},
+	{"chmod", []Action{ActionChmod}, "", "Permissions"},
+	{"duplicate", []Action{ActionDuplicate}, "", "Duplicate"},
+	{"undo", []Action{ActionUndo}, "", "Undo"},
+	{"new", []Action{ActionNewFile, ActionNewDir}, "", "New File/Directory"},
+	{"template", []Action{ActionTemplate}, "", "New from Template"},
+	{"extract", []Action{ActionExtract, ActionExtractTo}, "", "Extract Here/To"},
+	{"compress", []Action{ActionCompress}, "", "Compress"},
+	{"sort", []Action{ActionSort, ActionReverseSort}, "", "Sort/Reverse"},
+	{"columns", []Action{ActionColumns}, "", "Columns"},
+	{"times", []Action{ActionFullTimes}, "", "Full Times"},
+	{"preview", []Action{ActionPreview}, "", "Preview (compact)"},
+	{"hex", []Action{ActionHexPreview, ActionHexView}, "", "Hex Preview/Viewer"},
+	{"realpath", []Action{ActionRealPath}, "", "Real Path"},
+	{"summary", []Action{ActionSummary}, "", "Summary"},
+	{"usage", []Action{ActionDiskUsage}, "", "Disk Usage"},
+	{"checksum", []Action{ActionChecksum}, "", "Checksums"},
+	{"clipboard", []Action{ActionCopyPath, ActionCopyName, ActionCopyContents}, "", "Copy Path/Name/Contents"},
+	{"trash", []Action{ActionTrash}, "", "Trash"},
+	{"error", []Action{ActionLastError}, "", "Last Error"},
+	{"help", []Action{ActionHelp}, "", "Help"},
+	{"palette", []Action{ActionPalette}, "", "Commands"},
+	{"hints", []Action{ActionHints}, "", "Hints"},
+	{"reload", []Action{ActionReloadConfig}, "", "Reload Config"},
+	{"quit", []Action{ActionQuit}, "", "Quit"},
+}
+
+// hintKey renders the keys of a hint from the keymap, showing the first key
+// of each action, or "" when none of them is bound
+func (ui *FileExplorerUI) hintKey(h keyHint) string {
+	if h.actions == nil {
+		return h.key
+	}
+	var keys []string
+	for _, action := range h.actions {
+		if bound := ui.keymap.Keys(action); len(bound) > 0 {
+			keys = append(keys, displayKey(bound[0]))
+		}
+	}
+	return strings.Join(keys, "/")
+}
+
+// displayKey renders a keymap key name the way tcell spells it, e.g.
+// "Ctrl-R" for "ctrl-r"
+func displayKey(name string) string {
+	if utf8.RuneCountInString(name) == 1 {
+		return name
+	}
+	if rest, ok := strings.CutPrefix(name, "alt-"); ok {
+		return "Alt-" + displayKey(rest)
+	}
+	for _, known := range tcell.KeyNames {
+		if strings.ToLower(known) == name {
+			return known
+		}
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl-"); ok {
+		return "Ctrl-" + displayKey(rest)
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// findKeyHint looks up a hint by its config name
+func findKeyHint(name string) (keyHint, bool) {
+	for _, h := range keyHints {
+		if h.name == name {
+			return h, true
+		}
+	}
+	return keyHint{}, false
+}
+
+// hintText renders the configured key hints, or "" when they are hidden
+func (ui *FileExplorerUI) hintText() string {
+	if !ui.showHints {
+		return ""
+	}
+
+	hints := keyHints
+	if ui.config.FooterHints != nil {
+		hints = nil
+		for _, name := range ui.config.FooterHints {
+			if h, ok := findKeyHint(name); ok {
+				hints = append(hints, h)
+			}
+		}
+	}
+
+	var parts []string
+	for _, h := range hints {
+		if key := ui.hintKey(h); key != "" {
+			parts = append(parts, colorTag(ui.theme.FooterKey)+tview.Escape(key)+colorTag(ui.theme.FooterText)+" "+h.label)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// renderFooter redraws the footer from the last message and the key hints.
+// The message takes the whole line when hints are hidden.
+func (ui *FileExplorerUI) renderFooter() {
+	hints := ui.hintText()
+	switch {
+	case hints == "":
+		ui.footer.SetText(ui.footerMsg)
+	case ui.footerMsg == "":
+		ui.footer.SetText(colorTag(ui.theme.FooterText) + "Keys: " + hints)
+	default:
+		ui.footer.SetText(ui.footerMsg + colorTag(ui.theme.FooterText) + " | Keys: " + hints)
+	}
+}
+
+// toggleHints shows or hides the key hints in the footer
+func (ui *FileExplorerUI) toggleHints() {
+	ui.showHints = !ui.showHints
+	ui.renderFooter()
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"errors"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// errNonLocal is returned for operations that only work on the local disk
+var errNonLocal = errors.New("only possible on the local file system")
+
+// isDir reports whether path is a directory in fsys, following symlinks
+func isDir(fsys vfs.FS, path string) bool {
+	info, err := core.Stat(fsys, path)
+	return err == nil && info.IsDir()
+}
+
+// Local reports whether the local disk is browsed, rather than a file
+// system given with WithFS or picked from the sources
+func (ui *FileExplorerUI) Local() bool {
+	return ui.local
+}
+
+// refuseNonLocal reports the explorer browsing something other than the
+// local disk, where moves, archives, undo and other programs can't reach
+func (ui *FileExplorerUI) refuseNonLocal() bool {
+	if ui.local {
+		return false
+	}
+	ui.showError(errNonLocal)
+	return true
+}
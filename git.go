@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// gitStatus is what git status reports about an entry; for directories,
+// about everything below them
+type gitStatus uint8
+
+const (
+	gitStaged    gitStatus = 1 << iota // changes in the index
+	gitModified                        // changes in the work tree not yet staged
+	gitUntracked                       // not tracked, not ignored
+	gitIgnored                         // ignored by a .gitignore or exclude file
+)
+
+// marker renders s for the Git column: + staged, M modified, ? untracked
+// and ! ignored
+func (s gitStatus) marker() string {
+	var b strings.Builder
+	for _, m := range []struct {
+		flag   gitStatus
+		marker byte
+	}{{gitStaged, '+'}, {gitModified, 'M'}, {gitUntracked, '?'}, {gitIgnored, '!'}} {
+		if s&m.flag != 0 {
+			b.WriteByte(m.marker)
+		}
+	}
+	return b.String()
+}
+
+var gitColumn = column{"Git", "", func(e dirEntry) string {
+	return e.git.marker()
+}}
+
+// inGitWorkTree reports whether dir is inside a git work tree, by looking
+// for .git in it and its ancestors, which is cheaper than asking git
+func inGitWorkTree(dir string) bool {
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// gitStatuses asks git for the status of the entries of dir, by name.
+// Directories get the status of everything below them, except that they
+// are only ignored if ignored as a whole. It reports false when dir is not
+// in a work tree or git fails.
+func gitStatuses(ctx context.Context, dir string) (map[string]gitStatus, bool) {
+	if !inGitWorkTree(dir) {
+		return nil, false
+	}
+	prefix, err := runGit(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, false
+	}
+	out, err := runGit(ctx, dir, "status", "--porcelain=v1", "-z", "--ignored=matching", "--", ".")
+	if err != nil {
+		return nil, false
+	}
+
+	statuses := map[string]gitStatus{}
+	records := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 4 {
+			continue
+		}
+		x, y := record[0], record[1]
+		if x == 'R' || x == 'C' {
+			i++ // the path renamed or copied from follows
+		}
+
+		var status gitStatus
+		switch {
+		case x == '?':
+			status = gitUntracked
+		case x == '!':
+			status = gitIgnored
+		default:
+			if x != ' ' {
+				status |= gitStaged
+			}
+			if y != ' ' {
+				status |= gitModified
+			}
+		}
+
+		// Paths are relative to the root of the work tree
+		rel, ok := strings.CutPrefix(record[3:], strings.TrimSpace(prefix))
+		if !ok {
+			continue
+		}
+		name, rest, _ := strings.Cut(strings.TrimSuffix(rel, "/"), "/")
+		if rest != "" {
+			status &^= gitIgnored
+		}
+		statuses[name] |= status
+	}
+	return statuses, true
+}
+
+// runGit runs git in dir and returns its output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	// Don't take the index lock to refresh it, which would get in the way
+	// of git commands run at the same time
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// toggleGitIgnored hides or shows the entries git ignores. The choice holds
+// for every directory until toggled again or the config is reloaded.
+func (ui *FileExplorerUI) toggleGitIgnored() {
+	ui.hideIgnored = !ui.hideIgnored
+	for _, p := range ui.visiblePanes() {
+		p.relist()
+	}
+	if ui.hideIgnored {
+		ui.setFooterStatus("Hiding files ignored by git")
+	} else {
+		ui.setFooterStatus("Showing files ignored by git")
+	}
+}
+
+// colorDiff renders a unified diff with tview color tags: additions,
+// removals and hunk headers in the theme's diff colors, file headers in bold
+func colorDiff(diff string, theme Theme) string {
+	lines := strings.SplitAfter(diff, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		text := tview.Escape(line)
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+			strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "):
+			b.WriteString("[::b]" + text + "[::-]")
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(colorTag(theme.DiffAdded) + text + "[-]")
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(colorTag(theme.DiffRemoved) + text + "[-]")
+		case strings.HasPrefix(line, "@@"):
+			b.WriteString(colorTag(theme.DiffHunk) + text + "[-]")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// previewGitDiff shows the changes to the selected entry since the last
+// commit, staged or not, in the preview pane in place of its contents.
+// Moving the selection previews entries as usual again.
+func (ui *FileExplorerUI) previewGitDiff() {
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	if !ui.pane.gitRepo {
+		ui.setFooterError("Not in a git work tree")
+		return
+	}
+
+	// Take the preview over from any read in flight
+	if ui.previewCancel != nil {
+		ui.previewCancel()
+	}
+	ctx, cancel := context.WithCancel(ui.ctx)
+	ui.previewCancel = cancel
+	ui.previewStream = nil
+
+	name := filepath.Base(path)
+	ui.goBackground(func() {
+		diff, err := runGit(ctx, filepath.Dir(path), "diff", "--no-color", "HEAD", "--", name)
+		ui.queueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			switch {
+			case err != nil:
+				ui.showError(fmt.Errorf("git diff %s: %w", name, err))
+			case diff == "":
+				ui.setFooterStatus(tview.Escape("No changes to " + name + " since the last commit"))
+			default:
+				ui.contentPane.SetText(colorDiff(diff, ui.theme))
+				ui.graphic = nil
+				ui.contentPane.SetTitle("Diff of " + tview.Escape(name))
+				ui.contentPane.ScrollToBeginning()
+				if ui.compact && !ui.compactPreview {
+					ui.togglePreview()
+				}
+			}
+		})
+	})
+}
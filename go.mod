@@ -3,16 +3,30 @@ module github.com/aktagon/gofiles
 go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/alecthomas/chroma/v2 v2.14.0
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/gdamore/tcell/v2 v2.8.1
+	github.com/jlaffaye/ftp v0.2.0
+	github.com/pkg/sftp v1.13.7
 	github.com/rivo/tview v0.0.0-20250501113434-0c592cd31026
+	github.com/ulikunitz/xz v0.5.12
+	github.com/yuin/gopher-lua v1.1.2
+	golang.org/x/crypto v0.32.0
+	golang.org/x/image v0.24.0
+	golang.org/x/sys v0.29.0
+	golang.org/x/term v0.28.0
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/dlclark/regexp2 v1.11.0 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/term v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
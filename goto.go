@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// gotoPage is the name of the go-to-path prompt
+const gotoPage = "goto"
+
+// expandPath resolves a path typed by the user for fsys: on the local file
+// system a leading ~ stands for the home directory, and relative paths are
+// taken from base
+func expandPath(fsys vfs.FS, path, base string) string {
+	home := path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator))
+	if home && vfs.IsLocal(fsys) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path)
+}
+
+// completePath completes the last element of a typed path to the entries of fsys
+// starting with it. With a single match, directories get a trailing
+// separator; with several, the text is extended to their common prefix and
+// the names are returned. Without showHidden, dotfiles are only offered once
+// a dot is typed.
+func completePath(fsys vfs.FS, text, base string, showHidden bool) (string, []string) {
+	if text == "~" && vfs.IsLocal(fsys) {
+		return "~" + string(filepath.Separator), nil
+	}
+	dir := text[:strings.LastIndexAny(text, "/"+string(filepath.Separator))+1]
+	prefix := text[len(dir):]
+	parent := expandPath(fsys, dir+".", base)
+	entries, err := fsys.ReadDir(vfs.Name(parent))
+	if err != nil {
+		return text, nil
+	}
+
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if core.IsHidden(name) && !showHidden && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if e.IsDir() || e.Type()&os.ModeSymlink != 0 && isDir(fsys, filepath.Join(parent, name)) {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, name)
+	}
+	switch len(matches) {
+	case 0:
+		return text, nil
+	case 1:
+		return dir + matches[0], nil
+	}
+
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	return dir + common, matches
+}
+
+// showGoto asks for a path to go to, absolute, relative to the current
+// directory or, on the local file system, starting with ~. Tab completes names, listing the choices in
+// the footer when there are several, and Ctrl-V pastes. Enter goes to a
+// directory, or selects a file in its directory.
+func (ui *FileExplorerUI) showGoto() {
+	base := ui.pane.path
+	if ui.pane.inPathList() {
+		base, _ = os.Getwd()
+	}
+
+	input := tview.NewInputField()
+	input.SetBorder(true)
+	input.SetTitle("Go to (Tab completes)")
+	input.SetFieldBackgroundColor(ui.theme.Input)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			text, choices := completePath(ui.fsys, input.GetText(), base, ui.showHidden)
+			input.SetText(text)
+			if len(choices) > 0 {
+				ui.setFooterStatus(tview.Escape(strings.Join(choices, "  ")))
+			}
+			return nil
+		case tcell.KeyCtrlV:
+			ui.pasteInto(input)
+			return nil
+		}
+		return event
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		text := strings.TrimSpace(input.GetText())
+		ui.closePage(gotoPage)
+		if key != tcell.KeyEnter || text == "" {
+			return
+		}
+		path := expandPath(ui.fsys, text, base)
+		info, err := statPath(ui.fsys, path)
+		switch {
+		case err != nil:
+			ui.showError(err)
+		case info.IsDir():
+			ui.navigate(path)
+		default:
+			ui.Reveal(path)
+		}
+	})
+
+	ui.showPage(gotoPage, centered(input, 70, 3))
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	base := filepath.Join(home, "base")
+	remote := vfs.ReadOnly(fstest.MapFS{})
+	tests := []struct {
+		name string
+		fsys vfs.FS
+		path string
+		want string
+	}{
+		{"home", vfs.OS(), "~", home},
+		{"below home", vfs.OS(), "~/docs", filepath.Join(home, "docs")},
+		{"relative", vfs.OS(), "docs", filepath.Join(base, "docs")},
+		{"remote home", remote, "~", filepath.Join(base, "~")},
+		{"remote below home", remote, "~/docs", filepath.Join(base, "~", "docs")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandPath(tt.fsys, tt.path, base); got != tt.want {
+				t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
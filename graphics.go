@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/png"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"golang.org/x/image/draw"
+)
+
+// ImageProtocol selects how images are drawn in the preview
+type ImageProtocol string
+
+const (
+	// ImageAuto uses the graphics protocol the terminal is known to
+	// support, or else half blocks
+	ImageAuto ImageProtocol = "auto"
+	// ImageKitty draws images with kitty's graphics protocol
+	ImageKitty ImageProtocol = "kitty"
+	// ImageSixel draws images as sixels
+	ImageSixel ImageProtocol = "sixel"
+	// ImageBlocks draws images with half-block characters, on any terminal
+	// with 24-bit color
+	ImageBlocks ImageProtocol = "blocks"
+)
+
+// defaultCellSize is the size of a cell in pixels assumed for kitty's
+// protocol when the terminal doesn't tell; kitty scales the image into its
+// cells anyway
+var defaultCellSize = image.Pt(10, 20)
+
+// detectImageProtocol picks the graphics protocol of the terminal described
+// by the environment getenv reads. Terminal multiplexers don't pass the
+// protocols through reliably, so they get half blocks.
+func detectImageProtocol(getenv func(string) string) ImageProtocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("TMUX") != "" || getenv("STY") != "" ||
+		strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux"):
+		return ImageBlocks
+	case getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || term == "xterm-ghostty" ||
+		program == "WezTerm" || program == "ghostty":
+		return ImageKitty
+	case strings.Contains(term, "sixel") || program == "iTerm.app" || program == "mintty" ||
+		slices.ContainsFunc([]string{"foot", "mlterm", "contour", "yaft"}, func(prefix string) bool {
+			return strings.HasPrefix(term, prefix)
+		}):
+		return ImageSixel
+	}
+	return ImageBlocks
+}
+
+// imageMode is how images are drawn in the preview
+type imageMode struct {
+	protocol ImageProtocol // never ImageAuto
+	cell     image.Point   // size of a cell in pixels, for graphics protocols
+}
+
+// imageMode returns how images are drawn on the terminal as configured.
+// Graphics are written to the terminal after the explorer is drawn, so an
+// embedded explorer always uses half blocks, as does sixel when the
+// terminal doesn't tell the size of its cells to fit the image into.
+func (ui *FileExplorerUI) imageMode() imageMode {
+	protocol := ui.config.ImageProtocol
+	if protocol == ImageAuto || protocol == "" {
+		protocol = detectImageProtocol(os.Getenv)
+	}
+	if protocol == ImageBlocks || ui.embedded || ui.screen == nil {
+		return imageMode{protocol: ImageBlocks}
+	}
+	tty, ok := ui.screen.Tty()
+	if !ok {
+		return imageMode{protocol: ImageBlocks}
+	}
+	var cell image.Point
+	if size, err := tty.WindowSize(); err == nil {
+		cell.X, cell.Y = size.CellDimensions()
+	}
+	if cell.X == 0 || cell.Y == 0 {
+		if protocol == ImageSixel {
+			return imageMode{protocol: ImageBlocks}
+		}
+		cell = defaultCellSize
+	}
+	return imageMode{protocol: protocol, cell: cell}
+}
+
+// graphic is an image encoded for a graphics protocol, drawn over blank
+// cells left for it at the top of the preview
+type graphic struct {
+	protocol   ImageProtocol
+	data       []byte
+	cols, rows int // cells covered
+}
+
+// encodeGraphic encodes img, already scaled to the pixels of the cells it
+// covers, for protocol
+func encodeGraphic(img image.Image, protocol ImageProtocol, cols, rows int) *graphic {
+	g := &graphic{protocol: protocol, cols: cols, rows: rows}
+	if protocol == ImageKitty {
+		g.data = encodeKitty(img, cols, rows)
+	} else {
+		g.data = encodeSixel(img)
+	}
+	return g
+}
+
+// kittyChunk is the most base64 data kitty accepts in one escape sequence
+const kittyChunk = 4096
+
+// encodeKitty encodes img as PNG to be shown by kitty's graphics protocol
+// over cols×rows cells, without moving the cursor or answering
+func encodeKitty(img image.Image, cols, rows int) []byte {
+	var p bytes.Buffer
+	png.Encode(&p, img)
+	data := base64.StdEncoding.EncodeToString(p.Bytes())
+
+	var b bytes.Buffer
+	for i := 0; i == 0 || i < len(data); i += kittyChunk {
+		chunk := data[i:min(i+kittyChunk, len(data))]
+		more := 0
+		if i+kittyChunk < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.Bytes()
+}
+
+// encodeSixel encodes img as sixels, dithered to the web-safe palette.
+// Pixels are painted six rows at a time, one pass per color used in them.
+func encodeSixel(img image.Image) []byte {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	p := image.NewPaletted(image.Rect(0, 0, width, height), palette.WebSafe)
+	draw.FloydSteinberg.Draw(p, p.Bounds(), img, bounds.Min)
+
+	var b bytes.Buffer
+	// Pixels left out of a color's pass keep what's behind them
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range p.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	sixels := make([][]byte, len(p.Palette))
+	for y := 0; y < height; y += 6 {
+		var used []uint8
+		for dy := 0; dy < 6 && y+dy < height; dy++ {
+			for x := 0; x < width; x++ {
+				c := p.ColorIndexAt(x, y+dy)
+				if sixels[c] == nil {
+					sixels[c] = make([]byte, width)
+					used = append(used, c)
+				}
+				sixels[c][x] |= 1 << dy
+			}
+		}
+		if y > 0 {
+			b.WriteByte('-')
+		}
+		for i, c := range used {
+			if i > 0 {
+				b.WriteByte('$')
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRuns(&b, sixels[c])
+			sixels[c] = nil
+		}
+	}
+	b.WriteString("\x1b\\")
+	return b.Bytes()
+}
+
+// writeSixelRuns writes a row of sixels, repeating runs of the same one
+func writeSixelRuns(b *bytes.Buffer, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+		c := '?' + row[x]
+		if n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, c)
+		} else {
+			for range n {
+				b.WriteByte(c)
+			}
+		}
+		x += n
+	}
+}
+
+// placement is a graphic drawn at a cell of the screen
+type placement struct {
+	graphic *graphic
+	x, y    int
+}
+
+// placeGraphic returns where the graphic of the preview goes: at its top,
+// while it is shown, scrolled to the start and not covered by a dialog
+func (ui *FileExplorerUI) placeGraphic() placement {
+	if ui.graphic == nil || ui.dual || ui.compact && !ui.compactPreview {
+		return placement{}
+	}
+	if front, _ := ui.pages.GetFrontPage(); front != mainPage {
+		return placement{}
+	}
+	if row, _ := ui.contentPane.GetScrollOffset(); row != 0 {
+		return placement{}
+	}
+	x, y, width, height := ui.contentPane.GetInnerRect()
+	if width < ui.graphic.cols || height < ui.graphic.rows {
+		return placement{}
+	}
+	return placement{graphic: ui.graphic, x: x, y: y}
+}
+
+// afterDraw writes the graphic of the preview to the terminal when it
+// changes or moves. The cells left for it are blank and stay unchanged
+// between draws, so tcell doesn't paint over it. A graphic drawn before is
+// erased before the screen is shown, which then paints whatever took its
+// place.
+func (ui *FileExplorerUI) afterDraw(screen tcell.Screen) {
+	next := ui.placeGraphic()
+	if next == ui.shownGraphic {
+		return
+	}
+	tty, ok := screen.Tty()
+	if !ok {
+		return
+	}
+	tty.Write(ui.shownGraphic.erase())
+	screen.Show()
+	tty.Write(next.draw())
+	ui.shownGraphic = next
+}
+
+// draw returns the escape sequences drawing the graphic, keeping the
+// cursor where tcell left it
+func (p placement) draw() []byte {
+	if p.graphic == nil {
+		return nil
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "\x1b7\x1b[%d;%dH", p.y+1, p.x+1)
+	b.Write(p.graphic.data)
+	b.WriteString("\x1b8")
+	return b.Bytes()
+}
+
+// erase returns the escape sequences erasing the graphic: kitty deletes its
+// images, and sixels are erased with the cells they cover
+func (p placement) erase() []byte {
+	switch {
+	case p.graphic == nil:
+		return nil
+	case p.graphic.protocol == ImageKitty:
+		return []byte("\x1b_Ga=d,d=A,q=2\x1b\\")
+	}
+	var b bytes.Buffer
+	b.WriteString("\x1b7")
+	for row := range p.graphic.rows {
+		fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[%dX", p.y+row+1, p.x+1, p.graphic.cols)
+	}
+	b.WriteString("\x1b8")
+	return b.Bytes()
+}
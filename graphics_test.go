@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDetectImageProtocol(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want ImageProtocol
+	}{
+		{"kitty", map[string]string{"TERM": "xterm-kitty", "KITTY_WINDOW_ID": "1"}, ImageKitty},
+		{"wezterm", map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, ImageKitty},
+		{"foot", map[string]string{"TERM": "foot"}, ImageSixel},
+		{"sixel term", map[string]string{"TERM": "xterm-sixel"}, ImageSixel},
+		{"xterm", map[string]string{"TERM": "xterm-256color"}, ImageBlocks},
+		{"tmux in kitty", map[string]string{"TERM": "tmux-256color", "TMUX": "/tmp/tmux", "KITTY_WINDOW_ID": "1"}, ImageBlocks},
+		{"screen", map[string]string{"TERM": "screen", "STY": "1.pts"}, ImageBlocks},
+		{"nothing", nil, ImageBlocks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectImageProtocol(func(k string) string { return tt.env[k] }); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testImage returns an image with a red left half and a blue right half
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeSixel(t *testing.T) {
+	data := string(encodeSixel(testImage(8, 8)))
+	if !strings.HasPrefix(data, "\x1bP0;1;0q\"1;1;8;8") || !strings.HasSuffix(data, "\x1b\\") {
+		t.Fatalf("not a sixel image of 8×8 pixels: %q", data)
+	}
+	// Two bands of six rows, the second two rows high, each painting four
+	// red and four blue columns in a pass per color
+	body := regexp.MustCompile(`#\d+;2;\d+;\d+;\d+`).ReplaceAllString(data, "")
+	bands := strings.Split(strings.TrimSuffix(strings.TrimPrefix(body, "\x1bP0;1;0q\"1;1;8;8"), "\x1b\\"), "-")
+	if len(bands) != 2 {
+		t.Fatalf("got %d bands, want 2", len(bands))
+	}
+	passes := regexp.MustCompile(`#(\d+)([^#$]*)`)
+	for i, band := range bands {
+		full := "~"
+		if i == 1 {
+			full = "B" // '?' + 0b11
+		}
+		var got []string
+		for _, m := range passes.FindAllStringSubmatch(band, -1) {
+			got = append(got, m[1]+":"+m[2])
+		}
+		// The web-safe palette has red at 180 and blue at 5
+		want := []string{"180:!4" + full + "!4?", "5:!4?!4" + full}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("band %d paints %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestEncodeKitty(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	// Noise keeps the PNG larger than a chunk
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range img.Pix {
+		img.Pix[i] = byte(r.Uint32())
+	}
+	data := string(encodeKitty(img, 20, 5))
+
+	commands := regexp.MustCompile("\x1b_G([^;]*);([^\x1b]*)\x1b\\\\").FindAllStringSubmatch(data, -1)
+	if len(commands) < 2 {
+		t.Fatalf("got %d commands, want the image in several chunks", len(commands))
+	}
+	if got, want := commands[0][1], "a=T,f=100,q=2,C=1,c=20,r=5,m=1"; got != want {
+		t.Errorf("first command is %q, want %q", got, want)
+	}
+	var encoded strings.Builder
+	for i, c := range commands {
+		want := "m=1"
+		if i == len(commands)-1 {
+			want = "m=0"
+		}
+		if i > 0 && c[1] != want {
+			t.Errorf("command %d is %q, want %q", i, c[1], want)
+		}
+		if len(c[2]) > kittyChunk {
+			t.Errorf("command %d carries %d bytes", i, len(c[2]))
+		}
+		encoded.WriteString(c[2])
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(bytes.NewReader(decoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != img.Bounds() {
+		t.Errorf("sent a %v image, want %v", got.Bounds(), img.Bounds())
+	}
+}
+
+func TestImagePreviewGraphic(t *testing.T) {
+	var data bytes.Buffer
+	png.Encode(&data, testImage(400, 100))
+	mode := imageMode{protocol: ImageKitty, cell: image.Pt(10, 20)}
+	r, err := imagePreview(data.Bytes(), 20, 20, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 400×100 pixels fit into 20 cells of 10 pixels as 200×50, three rows
+	if r.graphic == nil || r.graphic.cols != 20 || r.graphic.rows != 3 {
+		t.Fatalf("got graphic %+v, want one of 20×3 cells", r.graphic)
+	}
+	if want := "\n\n\n\nPNG image, 400×100 pixels"; !strings.HasPrefix(r.text, want) {
+		t.Errorf("text is %q, want it to start with %q", r.text, want)
+	}
+
+	r, err = imagePreview(data.Bytes(), 20, 20, imageMode{protocol: ImageBlocks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.graphic != nil || !strings.Contains(r.text, "▀") {
+		t.Errorf("blocks drew %q with graphic %+v", r.text, r.graphic)
+	}
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// grepPage is the name of the content search results view
+const grepPage = "grep"
+
+// grepFileLimit is the size above which files aren't searched
+const grepFileLimit = 16 * 1024 * 1024
+
+// grepSnippetWidth is how much of a matching line is shown around the match
+const grepSnippetWidth = 100
+
+// previewLine asks for the preview of the file at path to be scrolled to
+// line, e.g. to show a match of a content search
+type previewLine struct {
+	path string
+	line int // 1-based
+}
+
+// grepMatch is a line containing the searched text
+type grepMatch struct {
+	path       string
+	line       int // 1-based
+	text       string
+	start, end int // byte offsets of the match in text
+}
+
+// snippet renders the matching line for the results view, trimmed to the
+// part around the match, with the match highlighted in the theme's colors
+func (m grepMatch) snippet(theme Theme) string {
+	text, start, end := m.text, m.start, m.end
+	if len(text) > grepSnippetWidth {
+		// Center the match, keeping to rune boundaries
+		from := max(0, min(start, start-(grepSnippetWidth-(end-start))/2))
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		to := min(len(text), from+grepSnippetWidth)
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+		end = min(end, to)
+		text, start, end = text[from:to], start-from, end-from
+	}
+	return tview.Escape(text[:start]) +
+		styleTag(theme.MatchHighlightText, theme.MatchHighlight, "") + tview.Escape(text[start:end]) + "[-:-]" +
+		tview.Escape(text[end:])
+}
+
+// grepTree searches the contents of the files below root in fsys for lines
+// containing needle, ignoring case, using a pool of workers. Binary files and
+// files over grepFileLimit are skipped. Matches are passed to emit in
+// batches, in no particular order, and the files searched are counted in
+// scanned. It reports whether the search stopped at the match limit.
+func grepTree(parent context.Context, fsys vfs.FS, root, needle string, opts searchOptions, scanned *ops.Counter, emit func([]grepMatch)) (limited bool, err error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	files := make(chan string)
+	found := make(chan grepMatch)
+
+	var walkErr error
+	go func() {
+		defer close(files)
+		walkErr = walkTree(ctx, fsys, root, opts, func(path string, d fs.DirEntry) error {
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			select {
+			case files <- path:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	var workers sync.WaitGroup
+	pattern := []byte(strings.ToLower(needle))
+	for range runtime.NumCPU() {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range files {
+				grepFile(ctx, fsys, path, pattern, found)
+				scanned.Add(1)
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(found)
+	}()
+
+	var batch []grepMatch
+	count := 0
+	for m := range found {
+		batch = append(batch, m)
+		count++
+		if len(batch) == searchBatchSize {
+			emit(batch)
+			batch = nil
+		}
+		if opts.limit > 0 && count >= opts.limit {
+			limited = true
+			cancel()
+			break
+		}
+	}
+	// Let the workers finish; once found is closed the walk is over too
+	for range found {
+	}
+	if len(batch) > 0 {
+		emit(batch)
+	}
+	if limited {
+		return true, nil
+	}
+	if walkErr == nil {
+		// The workers may have been stopped after the walk was done
+		walkErr = parent.Err()
+	}
+	return false, walkErr
+}
+
+// grepFile sends the lines of the file at path in fsys that contain
+// pattern, which is in lower case, to found
+func grepFile(ctx context.Context, fsys vfs.FS, path string, pattern []byte, found chan<- grepMatch) {
+	info, err := core.Stat(fsys, path)
+	if err != nil || info.Size() > grepFileLimit {
+		return
+	}
+	content, err := core.ReadFile(ctx, fsys, path)
+	if err != nil || core.IsBinary(content) {
+		return
+	}
+
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		lower := bytes.ToLower(line)
+		if len(lower) != len(line) {
+			// Offsets into the lowered line wouldn't fit the original
+			lower = line
+		}
+		at := bytes.Index(lower, pattern)
+		if at < 0 {
+			continue
+		}
+		m := grepMatch{path: path, line: i + 1, text: string(line), start: at, end: at + len(pattern)}
+		select {
+		case found <- m:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// promptGrep asks for a text and searches the contents of the files below
+// the current directory for it
+func (ui *FileExplorerUI) promptGrep() {
+	root := ui.pane.path
+	if ui.pane.inPathList() {
+		ui.setFooterError("Search needs a directory, leave the path list first")
+		return
+	}
+	ui.prompt("Search in files below "+root, "", func(needle string) {
+		if strings.TrimSpace(needle) == "" {
+			return
+		}
+		ui.showGrep(root, needle)
+	})
+}
+
+// showGrep opens the results view of a content search and fills it from the
+// background, with the progress in a dialog until hidden. Enter reveals the file of the selected match and scrolls the
+// preview to its line; Esc stops a running search, or else closes the view.
+func (ui *FileExplorerUI) showGrep(root, needle string) {
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetBorderColor(ui.theme.ResultsBorder)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.Selection).Foreground(ui.theme.SelectionText))
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	var matches []grepMatch
+	running := true
+	status := "searching..., Esc stops"
+
+	setTitle := func() {
+		table.SetTitle(fmt.Sprintf("Files containing %q in %s - %s %s(%s)[-]",
+			needle, root, plural(int64(len(matches)), "match", "matches"), colorTag(ui.theme.Muted), status))
+	}
+	setTitle()
+
+	table.SetSelectedFunc(func(row, _ int) {
+		if row >= len(matches) {
+			return
+		}
+		m := matches[row]
+		cancel()
+		ui.closePage(grepPage)
+		ui.previewLine = previewLine{path: m.path, line: m.line}
+		if ui.Reveal(m.path) == nil {
+			ui.setFooterStatus(tview.Escape(fmt.Sprintf("%s:%d", m.path, m.line)))
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyEscape {
+			return event
+		}
+		if running {
+			cancel()
+			return nil
+		}
+		ui.closePage(grepPage)
+		return nil
+	})
+
+	opts := searchOptions{
+		maxDepth:   ui.config.SearchMaxDepth,
+		limit:      ui.config.SearchLimit,
+		showHidden: ui.showHidden,
+	}
+	scanned := ops.NewCounter(0)
+	dialog := ui.newProgressDialog("Searching the files below "+root, cancel, func() {})
+	dialog.amount = func(n int64) string { return plural(n, "file", "files") }
+	ui.goBackground(func() {
+		for stats := range scanned.Watch(ctx, progressInterval) {
+			ui.queueUpdateDraw(func() { dialog.update("", stats) })
+		}
+	})
+
+	ui.goBackground(func() {
+		defer cancel()
+		emit := func(batch []grepMatch) {
+			ui.queueUpdateDraw(func() {
+				for _, m := range batch {
+					rel, err := filepath.Rel(root, m.path)
+					if err != nil {
+						rel = m.path
+					}
+					row := len(matches)
+					table.SetCell(row, 0, tview.NewTableCell(tview.Escape(rel)).SetTextColor(ui.theme.Directory))
+					table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(m.line)).
+						SetTextColor(ui.theme.Match).
+						SetAlign(tview.AlignRight))
+					table.SetCell(row, 2, tview.NewTableCell(m.snippet(ui.theme)).SetExpansion(1))
+					matches = append(matches, m)
+				}
+				setTitle()
+			})
+		}
+		limited, err := grepTree(ctx, ui.fsys, root, needle, opts, scanned, emit)
+		ui.queueUpdateDraw(func() {
+			running = false
+			dialog.close()
+			switch {
+			case limited:
+				status = fmt.Sprintf("stopped at the limit of %d", opts.limit)
+			case errors.Is(err, context.Canceled):
+				status = "stopped"
+			case err != nil:
+				status = "failed"
+				ui.showError(err)
+			default:
+				status = "done"
+			}
+			if len(matches) == 0 {
+				table.SetCell(0, 0, tview.NewTableCell("(no matches)").
+					SetTextColor(ui.theme.Dim).
+					SetSelectable(false))
+			}
+			setTitle()
+		})
+	})
+
+	ui.showPage(grepPage, panel(table))
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// helpPage is the name of the key binding cheat sheet
+const helpPage = "help"
+
+// actionInfo describes an action for the help screen
+type actionInfo struct {
+	action Action
+	title  string
+}
+
+// actionCategory is a group of related actions on the help screen
+type actionCategory struct {
+	name    string
+	actions []actionInfo
+}
+
+// actionCategories describes every action, grouped for the help screen
+var actionCategories = []actionCategory{
+	{"Navigation", []actionInfo{
+		{ActionOpen, "Open the selected entry"},
+		{ActionPick, "Pick the selection, when choosing files for another program"},
+		{ActionGoUp, "Go up to the parent directory"},
+		{ActionCursorUp, "Move the cursor up"},
+		{ActionCursorDown, "Move the cursor down"},
+		{ActionBreadcrumb, "Edit the path in the header"},
+		{ActionBack, "Go back"},
+		{ActionForward, "Go forward"},
+		{ActionHistory, "Show the directory history"},
+		{ActionPrevSibling, "Go to the previous sibling directory"},
+		{ActionNextSibling, "Go to the next sibling directory"},
+		{ActionPlaces, "Show places"},
+		{ActionSources, "Switch the file system"},
+		{ActionMounts, "Show mounted filesystems"},
+		{ActionBookmark, "Bookmark the current directory"},
+		{ActionBookmarks, "Show bookmarks"},
+		{ActionJump, "Jump to a frequent directory"},
+		{ActionGoto, "Go to a path"},
+	}},
+	{"Panes and tabs", []actionInfo{
+		{ActionSwitchPane, "Switch to the other pane"},
+		{ActionDualPane, "Toggle the dual-pane layout"},
+		{ActionTree, "Toggle the directory tree"},
+		{ActionNewTab, "Open a new tab"},
+		{ActionCloseTab, "Close the tab"},
+		{ActionNextTab, "Go to the next tab"},
+		{ActionPrevTab, "Go to the previous tab"},
+	}},
+	{"Filtering and searching", []actionInfo{
+		{ActionFilter, "Filter the listing"},
+		{ActionHidden, "Toggle hidden files"},
+		{ActionGitIgnored, "Toggle git-ignored files"},
+		{ActionSearch, "Search names below the directory"},
+		{ActionGrep, "Search file contents below the directory"},
+	}},
+	{"Selection", []actionInfo{
+		{ActionMark, "Mark the entry"},
+		{ActionMarkAll, "Mark all entries"},
+		{ActionInvertMarks, "Invert the marks"},
+		{ActionClear, "Cancel loading, clear the filter or the marks"},
+	}},
+	{"File operations", []actionInfo{
+		{ActionCopy, "Copy"},
+		{ActionMove, "Move"},
+		{ActionDelete, "Delete"},
+		{ActionRename, "Rename"},
+		{ActionChmod, "Change permissions"},
+		{ActionDuplicate, "Duplicate"},
+		{ActionUndo, "Undo the last operation"},
+		{ActionNewFile, "Create a file"},
+		{ActionNewDir, "Create a directory"},
+		{ActionTemplate, "Create a file from a template"},
+		{ActionTrash, "Show the trash"},
+		{ActionExtract, "Extract archives here"},
+		{ActionExtractTo, "Extract archives to..."},
+		{ActionCompress, "Compress into an archive"},
+	}},
+	{"Opening", []actionInfo{
+		{ActionOpenExternal, "Open with the default application"},
+		{ActionOpenWith, "Open with..."},
+		{ActionEdit, "Edit in the editor"},
+		{ActionShell, "Run a command"},
+	}},
+	{"View", []actionInfo{
+		{ActionSort, "Change the sort order"},
+		{ActionReverseSort, "Reverse the sort order"},
+		{ActionColumns, "Change the columns"},
+		{ActionFullTimes, "Toggle full modification times"},
+		{ActionPreview, "Toggle the preview"},
+		{ActionHexPreview, "Toggle the hex preview"},
+		{ActionHexView, "Open the hex viewer"},
+		{ActionMarkdown, "Toggle rendered Markdown and its source"},
+		{ActionScrollUp, "Scroll the preview up"},
+		{ActionScrollDown, "Scroll the preview down"},
+		{ActionGitDiff, "Preview the git diff"},
+		{ActionRealPath, "Show the real path"},
+		{ActionSummary, "Summarize the selection"},
+		{ActionDiskUsage, "Show disk usage"},
+		{ActionChecksum, "Compute checksums"},
+	}},
+	{"Clipboard", []actionInfo{
+		{ActionCopyPath, "Copy the paths"},
+		{ActionCopyName, "Copy the names"},
+		{ActionCopyContents, "Copy the file contents"},
+	}},
+	{"Explorer", []actionInfo{
+		{ActionHelp, "Show the key bindings"},
+		{ActionPalette, "Run a command by name"},
+		{ActionHints, "Toggle the footer hints"},
+		{ActionLastError, "Show the last error"},
+		{ActionReloadConfig, "Reload the config"},
+		{ActionQuit, "Quit"},
+	}},
+}
+
+// categories returns the categories of the actions available: the built-in
+// ones, and those added by plugins
+func (ui *FileExplorerUI) categories() []actionCategory {
+	if category, ok := ui.pluginCategory(); ok {
+		return append(slices.Clip(actionCategories), category)
+	}
+	return actionCategories
+}
+
+// builtinKeys are keys the listing handles itself, shown for actions that
+// aren't bound otherwise
+var builtinKeys = map[Action]string{
+	ActionCursorUp:   "↑",
+	ActionCursorDown: "↓",
+}
+
+// showHelp opens a cheat sheet of the key bindings in effect, grouped by
+// category. Esc, q or the help key closes it.
+func (ui *FileExplorerUI) showHelp() {
+	const keyWidth = 20
+
+	var b strings.Builder
+	for i, category := range ui.categories() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s[::b]%s[::-][-]\n", colorTag(ui.theme.Directory), category.name)
+		for _, info := range category.actions {
+			var keys []string
+			for _, key := range ui.actionKeys(info.action) {
+				keys = append(keys, displayKey(key))
+			}
+			text := strings.Join(keys, ", ")
+			if text == "" {
+				text = builtinKeys[info.action]
+			}
+			if text == "" {
+				text = "-"
+			}
+			padding := strings.Repeat(" ", max(keyWidth-len([]rune(text)), 1))
+			fmt.Fprintf(&b, "  %s%s[-]%s%s\n", colorTag(ui.theme.FooterKey), tview.Escape(text), padding, info.title)
+		}
+	}
+
+	view := tview.NewTextView().SetDynamicColors(true).SetText(b.String())
+	view.SetBorder(true)
+	view.SetTitle("Key Bindings - " + ui.titleKey("Esc") + " Close")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' || ui.keymap[keyName(event)] == ActionHelp {
+			ui.closePage(helpPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(helpPage, panel(view))
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// hexRowSize is the number of bytes per row of the hex preview
+const hexRowSize = 16
+
+// hexDump renders data like hexdump -C: each row holds the offset, the hex
+// bytes and the same bytes as text, so both views scroll together.
+// Non-printable bytes show as dots in the text column. Colors come from theme.
+func hexDump(data []byte, theme Theme) string {
+	return hexDumpRows(data, hexRowSize, theme)
+}
+
+// hexDumpRows is hexDump with width bytes per row
+func hexDumpRows(data []byte, width int, theme Theme) string {
+	var b strings.Builder
+	for off := 0; off < len(data); off += width {
+		b.WriteString(hexRow(int64(off), data[off:min(off+width, len(data))], width, theme))
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "%s%08x[-]\n", colorTag(theme.Muted), len(data))
+	return b.String()
+}
+
+// hexRow renders up to width bytes found at offset off as a row of the hex
+// dump, with tview color tags for the colors of theme
+func hexRow(off int64, row []byte, width int, theme Theme) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%08x[-]  ", colorTag(theme.Muted), off)
+	for i := 0; i < width; i++ {
+		if i < len(row) {
+			fmt.Fprintf(&b, "%02x ", row[i])
+		} else {
+			b.WriteString("   ")
+		}
+		if i == width/2-1 {
+			b.WriteByte(' ')
+		}
+	}
+
+	text := make([]byte, len(row))
+	for i, c := range row {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		text[i] = c
+	}
+	fmt.Fprintf(&b, " %s|%s|[-]", colorTag(theme.Title), tview.Escape(string(text)))
+	return b.String()
+}
+
+// toggleHexPreview switches the preview between the regular view and text
+// with a hex gutter
+func (ui *FileExplorerUI) toggleHexPreview() {
+	ui.hexPreview = !ui.hexPreview
+	// Wrapping would break the rows of the hex dump apart
+	ui.contentPane.SetWrap(!ui.hexPreview)
+	if path, ok := ui.pane.selectedPath(); ok {
+		ui.previewFile(path)
+	}
+	if ui.hexPreview {
+		ui.setFooterStatus("Hex preview on")
+	} else {
+		ui.setFooterStatus("Hex preview off")
+	}
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// hexPreviewLimit is how much of a binary file the preview shows as hex
+const hexPreviewLimit = 4096
+
+// binaryPreview describes a binary file of the given size and shows head,
+// its first bytes, as a hex dump narrow enough for cols columns
+func binaryPreview(path string, size int64, head []byte, cols int, theme Theme) string {
+	// A row takes 14 columns plus 4 per byte; keep it a multiple of 4 bytes
+	width := min(max((cols-14)/4/4*4, 4), hexRowSize)
+	text := fmt.Sprintf("Binary file: %s\nSize: %s\n\n%s", tview.Escape(path), formatSize(size), hexDumpRows(head, width, theme))
+	if int64(len(head)) < size {
+		text += colorTag(theme.Muted) + "...[-]\n"
+	}
+	return text
+}
+
+// hexViewer pages through a file as a hex dump. Only the rows on screen
+// are read, so files of any size can be inspected.
+type hexViewer struct {
+	*tview.Box
+	f      io.ReaderAt
+	name   string
+	size   int64
+	offset int64 // offset of the first row shown, a multiple of hexRowSize
+	rows   int   // rows that fit on the screen at the last draw
+	theme  Theme
+}
+
+// Draw implements tview.Primitive
+func (v *hexViewer) Draw(screen tcell.Screen) {
+	v.SetTitle(fmt.Sprintf(" %s: %08x of %08x (%s) ", tview.Escape(v.name),
+		v.offset, v.size, formatSize(v.size)))
+	v.Box.DrawForSubclass(screen, v)
+	x, y, width, height := v.GetInnerRect()
+	v.rows = max(height, 1)
+
+	buf := make([]byte, height*hexRowSize)
+	n, err := v.f.ReadAt(buf, v.offset)
+	if err != nil && err != io.EOF {
+		tview.Print(screen, colorTag(v.theme.Error)+tview.Escape(err.Error()), x, y, width, tview.AlignLeft, v.theme.Text)
+		return
+	}
+	for i := 0; i*hexRowSize < n; i++ {
+		row := buf[i*hexRowSize : min((i+1)*hexRowSize, n)]
+		tview.Print(screen, hexRow(v.offset+int64(i*hexRowSize), row, hexRowSize, v.theme), x, y+i, width, tview.AlignLeft, v.theme.Text)
+	}
+}
+
+// scrollTo shows the row holding offset at the top, keeping the last page
+// full
+func (v *hexViewer) scrollTo(offset int64) {
+	last := max((v.size+hexRowSize-1)/hexRowSize-int64(v.rows), 0) * hexRowSize
+	v.offset = min(max(offset/hexRowSize*hexRowSize, 0), last)
+}
+
+// InputHandler implements tview.Primitive
+func (v *hexViewer) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return v.WrapInputHandler(func(event *tcell.EventKey, _ func(p tview.Primitive)) {
+		page := int64(v.rows * hexRowSize)
+		switch event.Key() {
+		case tcell.KeyUp:
+			v.scrollTo(v.offset - hexRowSize)
+		case tcell.KeyDown:
+			v.scrollTo(v.offset + hexRowSize)
+		case tcell.KeyPgUp:
+			v.scrollTo(v.offset - page)
+		case tcell.KeyPgDn:
+			v.scrollTo(v.offset + page)
+		case tcell.KeyHome:
+			v.scrollTo(0)
+		case tcell.KeyEnd:
+			v.scrollTo(v.size)
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'k':
+				v.scrollTo(v.offset - hexRowSize)
+			case 'j':
+				v.scrollTo(v.offset + hexRowSize)
+			case ' ':
+				v.scrollTo(v.offset + page)
+			case 'g':
+				v.scrollTo(0)
+			case 'G':
+				v.scrollTo(v.size)
+			}
+		}
+	})
+}
+
+// parseOffset reads a file offset given in decimal or, with 0x in front,
+// in hex
+func parseOffset(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if hex, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		return strconv.ParseInt(hex, 16, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// showHexViewer opens the selected file in the hex viewer. Arrows, PgUp,
+// PgDn, Home and End page through it, : jumps to an offset and Escape or q
+// closes it.
+func (ui *FileExplorerUI) showHexViewer() {
+	const name = "hexviewer"
+
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	if inArchive(ui.fsys, filepath.Dir(path)) {
+		ui.showError(fmt.Errorf("%s: files in archives can't be paged", filepath.Base(path)))
+		return
+	}
+	f, err := core.Open(ui.fsys, path)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	info, err := f.Stat()
+	r, ok := f.(io.ReaderAt)
+	switch {
+	case err != nil:
+	case !info.Mode().IsRegular():
+		err = fmt.Errorf("%s is not a regular file", filepath.Base(path))
+	case !ok:
+		err = fmt.Errorf("%s: files on this file system can't be paged", filepath.Base(path))
+	}
+	if err != nil {
+		f.Close()
+		ui.showError(err)
+		return
+	}
+
+	v := &hexViewer{Box: tview.NewBox(), f: r, name: filepath.Base(path), size: info.Size(), theme: ui.theme}
+	v.SetBorder(true)
+	v.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+			f.Close()
+			ui.closePage(name)
+			return nil
+		case event.Rune() == ':':
+			ui.prompt("Go to offset (decimal or 0x hex)", "", func(text string) {
+				offset, err := parseOffset(text)
+				if err != nil {
+					ui.setFooterError(tview.Escape("invalid offset " + text))
+					return
+				}
+				v.scrollTo(offset)
+			})
+			return nil
+		}
+		return event
+	})
+	ui.showPage(name, panel(v))
+}
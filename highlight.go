@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/rivo/tview"
+)
+
+// previewMode is how file contents are rendered in the preview
+type previewMode struct {
+	hex        bool   // hex dump instead of text
+	mdSource   bool   // Markdown as its source rather than rendered
+	style      string // chroma style for syntax highlighting; "" leaves text plain
+	cols, rows int    // size of the preview, images are fitted into it
+	limit      int64  // largest file read whole
+	image      imageMode
+	theme      Theme // colors of hex dumps and rendered documents
+}
+
+// previewMode returns the rendering options of the preview as configured
+func (ui *FileExplorerUI) previewMode() previewMode {
+	_, _, cols, rows := ui.contentPane.GetInnerRect()
+	mode := previewMode{hex: ui.hexPreview, mdSource: ui.markdownSource, cols: max(cols, 20), rows: max(rows, 10), limit: ui.previewLimit, image: ui.imageMode(), theme: ui.theme}
+	if ui.config.SyntaxHighlight {
+		mode.style = ui.config.SyntaxStyle
+	}
+	return mode
+}
+
+// highlight renders text with tview color tags for the syntax of the file
+// name, or of the contents if the name gives nothing away. It reports false
+// when the syntax is unknown.
+func highlight(name, text, styleName string) (string, bool) {
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		lexer = lexers.Analyse(text)
+	}
+	if lexer == nil {
+		return "", false
+	}
+	return highlightLexer(lexer, text, styleName)
+}
+
+// highlightLexer renders text with tview color tags for the syntax lexer
+// tokenizes, reporting false if it fails
+func highlightLexer(lexer chroma.Lexer, text, styleName string) (string, bool) {
+	iter, err := chroma.Coalesce(lexer).Tokenise(nil, text)
+	if err != nil {
+		return "", false
+	}
+	style := styles.Get(styleName)
+
+	var b strings.Builder
+	for _, token := range iter.Tokens() {
+		value := tview.Escape(token.Value)
+		entry := style.Get(token.Type)
+		if !entry.Colour.IsSet() && entry.Bold != chroma.Yes && entry.Italic != chroma.Yes && entry.Underline != chroma.Yes {
+			b.WriteString(value)
+			continue
+		}
+
+		color := "-"
+		if entry.Colour.IsSet() {
+			color = entry.Colour.String()
+		}
+		var attrs string
+		if entry.Bold == chroma.Yes {
+			attrs += "b"
+		}
+		if entry.Italic == chroma.Yes {
+			attrs += "i"
+		}
+		if entry.Underline == chroma.Yes {
+			attrs += "u"
+		}
+		fmt.Fprintf(&b, "[%s::%s]%s[-::-]", color, attrs, value)
+	}
+	return b.String(), true
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// historyPage is the name of the history popup
+const historyPage = "history"
+
+// historyLimit is how many directories are remembered in each direction
+const historyLimit = 100
+
+// history holds the directories visited in a tab, like a browser's
+type history struct {
+	back    []string // most recent last
+	forward []string // next one last
+}
+
+// visit records a move from one directory to another. Moving to the
+// directory on top of either stack steps through the history, so going up
+// and then forward returns to the directory just left; anything else starts a
+// new branch. from is "" when leaving a path list, which isn't recorded.
+func (h *history) visit(from, to string) {
+	switch {
+	case from == to:
+		return
+	case len(h.back) > 0 && h.back[len(h.back)-1] == to:
+		h.back = h.back[:len(h.back)-1]
+		if from != "" {
+			h.forward = appendLimited(h.forward, from)
+		}
+	case len(h.forward) > 0 && h.forward[len(h.forward)-1] == to:
+		h.forward = h.forward[:len(h.forward)-1]
+		if from != "" {
+			h.back = appendLimited(h.back, from)
+		}
+	default:
+		if from != "" {
+			h.back = appendLimited(h.back, from)
+		}
+		h.forward = nil
+	}
+}
+
+// clone returns a copy that can change independently of h
+func (h history) clone() history {
+	return history{back: slices.Clone(h.back), forward: slices.Clone(h.forward)}
+}
+
+// appendLimited appends dir to stack, dropping the oldest entry beyond historyLimit
+func appendLimited(stack []string, dir string) []string {
+	stack = append(stack, dir)
+	if len(stack) > historyLimit {
+		stack = slices.Delete(stack, 0, len(stack)-historyLimit)
+	}
+	return stack
+}
+
+// goBack returns to the previously visited directory
+func (ui *FileExplorerUI) goBack() {
+	back := ui.pane.history.back
+	if len(back) == 0 {
+		ui.setFooterStatus("No earlier directory")
+		return
+	}
+	ui.navigate(back[len(back)-1])
+	ui.setFocus(ui.pane.table)
+}
+
+// goForward undoes goBack
+func (ui *FileExplorerUI) goForward() {
+	forward := ui.pane.history.forward
+	if len(forward) == 0 {
+		ui.setFooterStatus("No later directory")
+		return
+	}
+	ui.navigate(forward[len(forward)-1])
+	ui.setFocus(ui.pane.table)
+}
+
+// showHistory opens a list of the recently visited directories of the tab,
+// most recent first, to go back to one of them
+func (ui *FileExplorerUI) showHistory() {
+	var dirs []string
+	for _, dir := range slices.Backward(ui.pane.history.back) {
+		if !slices.Contains(dirs, dir) && dir != ui.pane.path {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		ui.setFooterStatus("No earlier directory")
+		return
+	}
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle(fmt.Sprintf("History (%d)", len(dirs)))
+	list.ShowSecondaryText(false)
+	for _, dir := range dirs {
+		list.AddItem(tview.Escape(dir), "", 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.closePage(historyPage)
+		ui.navigate(dirs[index])
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(historyPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			ui.closePage(historyPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(historyPage, centered(list, 70, min(len(dirs)+2, 24)))
+}
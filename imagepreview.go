@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// imageLimit is the size above which images aren't previewed
+const imageLimit = 32 * 1024 * 1024
+
+// imageTypes are the MIME types of the images that are previewed
+var imageTypes = map[string]bool{
+	"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true,
+}
+
+// isImage reports whether files of the MIME type t are images the preview
+// can draw
+func isImage(t string) bool {
+	return imageTypes[t]
+}
+
+// imagePreview draws an image fitted into cols×rows cells together with its
+// dimensions and EXIF summary below it: with the graphics protocol of mode,
+// over blank lines left for it, or else with half-block characters, two
+// pixels per cell
+func imagePreview(data []byte, cols, rows int, mode imageMode) (renderedPreview, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return renderedPreview{}, err
+	}
+	bounds := img.Bounds()
+
+	info := []string{fmt.Sprintf("%s image, %d×%d pixels, %s",
+		strings.ToUpper(format), bounds.Dx(), bounds.Dy(), formatSize(int64(len(data))))}
+	if format == "jpeg" {
+		info = append(info, parseExif(jpegExif(data))...)
+	}
+	rows = max(rows-len(info)-1, 1)
+
+	if mode.protocol != ImageBlocks {
+		// Fit the image into the pixels of the cells, keeping its aspect ratio
+		scaled := scaleImage(img, cols*mode.cell.X, rows*mode.cell.Y)
+		size := scaled.Bounds().Size()
+		cols, rows = (size.X+mode.cell.X-1)/mode.cell.X, (size.Y+mode.cell.Y-1)/mode.cell.Y
+		return renderedPreview{
+			text:    strings.Repeat("\n", rows) + "\n" + strings.Join(info, "\n"),
+			graphic: encodeGraphic(scaled, mode.protocol, cols, rows),
+		}, nil
+	}
+
+	// A cell is about twice as high as wide, i.e. two pixels
+	scaled := scaleImage(img, cols, 2*max(rows, 2))
+	width, height := scaled.Bounds().Dx(), scaled.Bounds().Dy()
+	var b strings.Builder
+	for y := 0; y < height; y += 2 {
+		last := ""
+		for x := 0; x < width; x++ {
+			// The upper pixel is the foreground of ▀, the lower one its background
+			top := hexColor(scaled, x, y)
+			bottom := "-"
+			if y+1 < height {
+				bottom = hexColor(scaled, x, y+1)
+			}
+			if tag := "[" + top + ":" + bottom + "]"; tag != last {
+				b.WriteString(tag)
+				last = tag
+			}
+			b.WriteString("▀")
+		}
+		b.WriteString("[-:-]\n")
+	}
+	b.WriteString("\n" + strings.Join(info, "\n"))
+	return renderedPreview{text: b.String()}, nil
+}
+
+// scaleImage scales img down to fit into width×height pixels, keeping its
+// aspect ratio
+func scaleImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	if bounds.Dx()*height > bounds.Dy()*width {
+		height = max(1, bounds.Dy()*width/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*height/bounds.Dy())
+	}
+	width, height = min(width, bounds.Dx()), min(height, bounds.Dy())
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// hexColor returns the color of a pixel as #rrggbb, blended onto black
+// where it is transparent
+func hexColor(img *image.RGBA, x, y int) string {
+	c := img.RGBAAt(x, y)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
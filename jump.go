@@ -0,0 +1,341 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// jumpPage is the name of the directory jumper
+	jumpPage = "jump"
+	// jumpIndexLimit bounds how many directories below the current one the
+	// jumper indexes
+	jumpIndexLimit = 10000
+	// jumpResultLimit is how many matches the jumper lists
+	jumpResultLimit = 200
+	// frecencyLimit is how many directories the frecency file remembers
+	frecencyLimit = 500
+)
+
+// DirVisits is how often and when last a directory was visited
+type DirVisits struct {
+	Path  string    `toml:"path"`
+	Count int       `toml:"count"`
+	Last  time.Time `toml:"last"`
+}
+
+// score ranks d by frecency: each visit counts for more the more recent the
+// last one was
+func (d DirVisits) score(now time.Time) float64 {
+	weight := 0.25
+	switch age := now.Sub(d.Last); {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 1
+	}
+	return float64(d.Count) * weight
+}
+
+// Frecency remembers the directories visited, across sessions, in a TOML
+// file. Visits are merged into the file by Save, so sessions running at the
+// same time don't lose each other's.
+type Frecency struct {
+	path    string
+	dirs    []DirVisits
+	pending map[string]DirVisits // visits since the file was read
+}
+
+// frecencyFile is the layout of the frecency file
+type frecencyFile struct {
+	Dirs []DirVisits `toml:"dir"`
+}
+
+// DefaultFrecencyPath returns the location of the user's frecency file,
+// next to the config file
+func DefaultFrecencyPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofiles", "frecency.toml")
+}
+
+// LoadFrecency reads the frecency file at path. A missing file is not an
+// error, no directories have been visited yet.
+func LoadFrecency(path string) (*Frecency, error) {
+	f := &Frecency{path: path, pending: map[string]DirVisits{}}
+	dirs, err := readFrecencyFile(path)
+	f.dirs = dirs
+	return f, err
+}
+
+// readFrecencyFile returns the directories in the frecency file at path
+func readFrecencyFile(path string) ([]DirVisits, error) {
+	if path == "" {
+		return nil, nil
+	}
+	var file frecencyFile
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("frecency %s: %w", path, err)
+	}
+	return file.Dirs, nil
+}
+
+// Visit records a visit to dir at now
+func (f *Frecency) Visit(dir string, now time.Time) {
+	dir = filepath.Clean(dir)
+	f.dirs = addVisits(f.dirs, DirVisits{Path: dir, Count: 1, Last: now})
+	v := f.pending[dir]
+	f.pending[dir] = DirVisits{Path: dir, Count: v.Count + 1, Last: now}
+}
+
+// addVisits adds the visits of v to those of the same directory in dirs
+func addVisits(dirs []DirVisits, v DirVisits) []DirVisits {
+	i := slices.IndexFunc(dirs, func(d DirVisits) bool { return d.Path == v.Path })
+	if i < 0 {
+		return append(dirs, v)
+	}
+	dirs[i].Count += v.Count
+	if v.Last.After(dirs[i].Last) {
+		dirs[i].Last = v.Last
+	}
+	return dirs
+}
+
+// List returns the visited directories, highest frecency first
+func (f *Frecency) List(now time.Time) []DirVisits {
+	dirs := slices.Clone(f.dirs)
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return dirs[i].score(now) > dirs[j].score(now)
+	})
+	return dirs
+}
+
+// Save merges the visits since the file was read into it, dropping
+// directories that no longer exist and the lowest ranked ones beyond
+// frecencyLimit
+func (f *Frecency) Save() error {
+	if f.path == "" {
+		return errors.New("no frecency file")
+	}
+	dirs, err := readFrecencyFile(f.path)
+	if err != nil {
+		return err
+	}
+	for _, v := range f.pending {
+		dirs = addVisits(dirs, v)
+	}
+	dirs = slices.DeleteFunc(dirs, func(d DirVisits) bool {
+		info, err := os.Stat(d.Path)
+		return err != nil || !info.IsDir()
+	})
+	f.dirs = dirs
+	dirs = f.List(time.Now())
+	dirs = dirs[:min(len(dirs), frecencyLimit)]
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(frecencyFile{dirs}); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(f.path, buf.Bytes()); err != nil {
+		return err
+	}
+	f.dirs = dirs
+	clear(f.pending)
+	return nil
+}
+
+// fuzzyMatch reports whether the characters of query appear in s in order,
+// ignoring case, and scores the match. Runs of consecutive characters,
+// characters starting a path segment or word, and matches within the last
+// segment score higher.
+func fuzzyMatch(query, s string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	q := []rune(strings.ToLower(query))
+	s = strings.ToLower(s)
+	score, qi := 0, 0
+	base := len(s) - len(filepath.Base(s))
+	prev := rune(filepath.Separator)
+	consecutive := false
+	for i, r := range s {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			prev, consecutive = r, false
+			continue
+		}
+		score++
+		if consecutive {
+			score += 4
+		}
+		if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+			score += 6
+		}
+		if i >= base {
+			score += 2
+		}
+		qi++
+		prev, consecutive = r, true
+	}
+	return score, qi == len(q)
+}
+
+// jumpCandidate is a directory the jumper can go to
+type jumpCandidate struct {
+	path     string
+	frecency float64 // 0 for directories only indexed
+}
+
+// rankJumps returns the candidates matching query, best first: the match
+// score counts, and so does frecency, on a logarithmic scale so that often
+// visited directories don't drown out better matches
+func rankJumps(candidates []jumpCandidate, query string) []jumpCandidate {
+	type ranked struct {
+		jumpCandidate
+		rank float64
+	}
+	var matches []ranked
+	for _, c := range candidates {
+		score, ok := fuzzyMatch(query, c.path)
+		if !ok {
+			continue
+		}
+		matches = append(matches, ranked{c, float64(score) + 10*math.Log1p(c.frecency)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank > matches[j].rank
+	})
+
+	result := make([]jumpCandidate, 0, min(len(matches), jumpResultLimit))
+	for _, m := range matches[:min(len(matches), jumpResultLimit)] {
+		result = append(result, m.jumpCandidate)
+	}
+	return result
+}
+
+// visitDir records a visit to dir for the jumper; archives, path lists and
+// other file systems than the local disk aren't places it can return to
+func (ui *FileExplorerUI) visitDir(dir string) {
+	if dir == "" || !ui.local || inArchive(ui.fsys, dir) {
+		return
+	}
+	ui.frecency.Visit(dir, time.Now())
+}
+
+// showJump opens the directory jumper: typing narrows the visited
+// directories and those below the current one by fuzzy matching, ranked by
+// how good the match is and how often and how recently each directory was
+// visited. Enter goes to the selected one.
+func (ui *FileExplorerUI) showJump() {
+	now := time.Now()
+	var candidates []jumpCandidate
+	known := map[string]bool{}
+	for _, d := range ui.frecency.List(now) {
+		candidates = append(candidates, jumpCandidate{d.Path, d.score(now)})
+		known[d.Path] = true
+	}
+
+	input := tview.NewInputField()
+	input.SetLabel("> ")
+	input.SetFieldBackgroundColor(ui.theme.Input)
+	list := tview.NewList()
+	list.ShowSecondaryText(false)
+
+	var shown []jumpCandidate
+	update := func() {
+		shown = rankJumps(candidates, input.GetText())
+		list.Clear()
+		for _, c := range shown {
+			list.AddItem(tview.Escape(c.path), "", 0, nil)
+		}
+	}
+	update()
+
+	// Index the directories below the current one while the jumper is open
+	ctx, cancel := context.WithCancel(ui.ctx)
+	if root := ui.pane.path; !ui.pane.inPathList() && !inArchive(ui.fsys, root) {
+		opts := searchOptions{maxDepth: ui.config.SearchMaxDepth, showHidden: ui.showHidden}
+		ui.goBackground(func() {
+			var found []jumpCandidate
+			walkTree(ctx, ui.fsys, root, opts, func(path string, d fs.DirEntry) error {
+				if !d.IsDir() {
+					return nil
+				}
+				if !known[path] {
+					found = append(found, jumpCandidate{path: path})
+				}
+				if len(found) >= jumpIndexLimit {
+					return filepath.SkipAll
+				}
+				return nil
+			})
+			ui.queueUpdateDraw(func() {
+				if ctx.Err() != nil {
+					return
+				}
+				current := list.GetCurrentItem()
+				candidates = append(candidates, found...)
+				update()
+				list.SetCurrentItem(current)
+			})
+		})
+	}
+
+	closeJump := func() {
+		cancel()
+		ui.closePage(jumpPage)
+	}
+	input.SetChangedFunc(func(string) {
+		update()
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(event, nil)
+			return nil
+		case tcell.KeyEnter:
+			if len(shown) == 0 {
+				return nil
+			}
+			dir := shown[list.GetCurrentItem()].path
+			closeJump()
+			ui.navigate(dir)
+			return nil
+		case tcell.KeyEscape:
+			closeJump()
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+	flex.SetBorder(true)
+	flex.SetTitle(fmt.Sprintf("Jump to Directory - %s Go | %s Close", ui.titleKey("Enter"), ui.titleKey("Esc")))
+	ui.showPage(jumpPage, centered(flex, 80, 20))
+}
@@ -0,0 +1,535 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action names a command that can be bound to a key
+type Action string
+
+// The actions keys can be bound to
+const (
+	ActionOpen         Action = "open"
+	ActionPick         Action = "pick"
+	ActionOpenExternal Action = "open-external"
+	ActionOpenWith     Action = "open-with"
+	ActionEdit         Action = "edit"
+	ActionShell        Action = "shell"
+	ActionGoUp         Action = "go-up"
+	ActionBreadcrumb   Action = "breadcrumb" // focus the path in the header
+	ActionQuit         Action = "quit"
+	ActionClear        Action = "clear" // cancel loading, clear the filter or the marks
+	ActionCursorUp     Action = "cursor-up"
+	ActionCursorDown   Action = "cursor-down"
+	ActionScrollUp     Action = "preview-scroll-up"
+	ActionScrollDown   Action = "preview-scroll-down"
+	ActionBack         Action = "back"
+	ActionForward      Action = "forward"
+	ActionHistory      Action = "history"
+	ActionPrevSibling  Action = "prev-sibling"
+	ActionNextSibling  Action = "next-sibling"
+	ActionPlaces       Action = "places"
+	ActionSources      Action = "sources"
+	ActionMounts       Action = "mounts"
+	ActionBookmark     Action = "bookmark"
+	ActionBookmarks    Action = "bookmarks"
+	ActionJump         Action = "jump"
+	ActionGoto         Action = "go-to"
+	ActionSwitchPane   Action = "switch-pane"
+	ActionDualPane     Action = "dual-pane"
+	ActionTree         Action = "tree"
+	ActionNewTab       Action = "new-tab"
+	ActionCloseTab     Action = "close-tab"
+	ActionNextTab      Action = "next-tab"
+	ActionPrevTab      Action = "prev-tab"
+	ActionFilter       Action = "filter"
+	ActionHidden       Action = "hidden"
+	ActionGitIgnored   Action = "git-ignored"
+	ActionGitDiff      Action = "git-diff" // preview the changes to a file
+	ActionSearch       Action = "search"
+	ActionGrep         Action = "grep"
+	ActionMark         Action = "mark"
+	ActionMarkAll      Action = "mark-all"
+	ActionInvertMarks  Action = "invert-marks"
+	ActionCopy         Action = "copy"
+	ActionMove         Action = "move"
+	ActionDelete       Action = "delete"
+	ActionRename       Action = "rename"
+	ActionChmod        Action = "chmod"
+	ActionDuplicate    Action = "duplicate"
+	ActionUndo         Action = "undo"
+	ActionNewFile      Action = "new-file"
+	ActionNewDir       Action = "new-dir"
+	ActionTemplate     Action = "template"
+	ActionExtract      Action = "extract"
+	ActionExtractTo    Action = "extract-to"
+	ActionCompress     Action = "compress"
+	ActionSort         Action = "sort"
+	ActionReverseSort  Action = "reverse-sort"
+	ActionColumns      Action = "columns"
+	ActionFullTimes    Action = "full-times"
+	ActionPreview      Action = "preview"
+	ActionHexPreview   Action = "hex-preview"
+	ActionHexView      Action = "hex-view"
+	ActionMarkdown     Action = "markdown-source" // toggle rendered Markdown and its source
+	ActionRealPath     Action = "real-path"
+	ActionSummary      Action = "summary"
+	ActionDiskUsage    Action = "disk-usage"
+	ActionChecksum     Action = "checksum"
+	ActionCopyPath     Action = "copy-path"
+	ActionCopyName     Action = "copy-name"
+	ActionCopyContents Action = "copy-contents"
+	ActionTrash        Action = "trash"
+	ActionLastError    Action = "last-error"
+	ActionHelp         Action = "help"
+	ActionPalette      Action = "palette"
+	ActionHints        Action = "hints"
+	ActionReloadConfig Action = "reload-config"
+)
+
+// Keymap binds key names to actions. Keys are named as typed for printable
+// characters ("f", "F", "["), and otherwise in lower case after tcell's key
+// names, with "alt-" in front for Alt combinations: "enter", "esc",
+// "backspace", "space", "f5", "pgdn", "ctrl-r", "alt-x".
+type Keymap map[string]Action
+
+// DefaultKeymap returns the built-in key bindings
+func DefaultKeymap() Keymap {
+	return Keymap{
+		"enter":     ActionOpen,
+		"alt-enter": ActionPick,
+		"O":         ActionOpenExternal,
+		"o":         ActionOpenWith,
+		"e":         ActionEdit,
+		":":         ActionShell,
+		"!":         ActionShell,
+		"backspace": ActionGoUp,
+		"ctrl-c":    ActionQuit,
+		"esc":       ActionClear,
+		"ctrl-u":    ActionScrollUp,
+		"ctrl-d":    ActionScrollDown,
+		"alt-up":    ActionBreadcrumb,
+		"alt-left":  ActionBack,
+		"H":         ActionBack,
+		"alt-right": ActionForward,
+		"L":         ActionForward,
+		"alt-h":     ActionHistory,
+		"[":         ActionPrevSibling,
+		"]":         ActionNextSibling,
+		"p":         ActionPlaces,
+		"alt-m":     ActionSources,
+		"m":         ActionMounts,
+		"b":         ActionBookmark,
+		"B":         ActionBookmarks,
+		"ctrl-p":    ActionJump,
+		"g":         ActionGoto,
+		"ctrl-l":    ActionGoto,
+		"tab":       ActionSwitchPane,
+		"ctrl-o":    ActionDualPane,
+		"ctrl-e":    ActionTree,
+		"ctrl-t":    ActionNewTab,
+		"ctrl-w":    ActionCloseTab,
+		"ctrl-tab":  ActionNextTab,
+		"ctrl-n":    ActionNextTab,
+		"ctrl-b":    ActionPrevTab,
+		"f":         ActionFilter,
+		".":         ActionHidden,
+		"I":         ActionGitIgnored,
+		"ctrl-f":    ActionSearch,
+		"/":         ActionSearch,
+		"ctrl-g":    ActionGrep,
+		"space":     ActionMark,
+		"a":         ActionMarkAll,
+		"A":         ActionInvertMarks,
+		"f5":        ActionCopy,
+		"f6":        ActionMove,
+		"f8":        ActionDelete,
+		"r":         ActionRename,
+		"alt-c":     ActionChmod,
+		"y":         ActionDuplicate,
+		"u":         ActionUndo,
+		"n":         ActionNewFile,
+		"N":         ActionNewDir,
+		"t":         ActionTemplate,
+		"x":         ActionExtract,
+		"ctrl-x":    ActionExtractTo,
+		"Z":         ActionCompress,
+		"s":         ActionSort,
+		"S":         ActionReverseSort,
+		"C":         ActionColumns,
+		"M":         ActionFullTimes,
+		"v":         ActionPreview,
+		"X":         ActionHexPreview,
+		"V":         ActionHexView,
+		"R":         ActionMarkdown,
+		"d":         ActionGitDiff,
+		"P":         ActionRealPath,
+		"z":         ActionSummary,
+		"U":         ActionDiskUsage,
+		"c":         ActionChecksum,
+		"Y":         ActionCopyPath,
+		"alt-y":     ActionCopyName,
+		"ctrl-y":    ActionCopyContents,
+		"T":         ActionTrash,
+		"E":         ActionLastError,
+		"?":         ActionHelp,
+		"f1":        ActionHelp,
+		"alt-x":     ActionPalette,
+		"f2":        ActionHints,
+		"ctrl-r":    ActionReloadConfig,
+	}
+}
+
+// actions lists every action, for validating bindings
+var actions = []Action{
+	ActionOpen, ActionPick, ActionOpenExternal, ActionOpenWith, ActionEdit, ActionShell,
+	ActionGoUp, ActionBreadcrumb, ActionQuit, ActionClear, ActionCursorUp, ActionCursorDown,
+	ActionScrollUp, ActionScrollDown, ActionBack, ActionForward, ActionHistory,
+	ActionPrevSibling, ActionNextSibling, ActionPlaces, ActionSources, ActionMounts,
+	ActionBookmark, ActionBookmarks, ActionJump, ActionGoto, ActionSwitchPane,
+	ActionDualPane, ActionTree, ActionNewTab, ActionCloseTab, ActionNextTab, ActionPrevTab,
+	ActionFilter, ActionHidden, ActionGitIgnored, ActionGitDiff, ActionSearch, ActionGrep,
+	ActionMark, ActionMarkAll, ActionInvertMarks, ActionCopy, ActionMove, ActionDelete,
+	ActionRename, ActionChmod, ActionDuplicate, ActionUndo, ActionNewFile, ActionNewDir,
+	ActionTemplate, ActionExtract, ActionExtractTo, ActionCompress, ActionSort,
+	ActionReverseSort, ActionColumns, ActionFullTimes, ActionPreview, ActionHexPreview,
+	ActionHexView, ActionMarkdown, ActionRealPath, ActionSummary, ActionDiskUsage,
+	ActionChecksum, ActionCopyPath, ActionCopyName, ActionCopyContents, ActionTrash,
+	ActionLastError, ActionHelp, ActionPalette, ActionHints, ActionReloadConfig,
+}
+
+// isAction reports whether a is a known action
+func isAction(a Action) bool {
+	for _, known := range actions {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Bind binds key to action, replacing any action the key was bound to
+func (k Keymap) Bind(key string, action Action) error {
+	name, err := parseKeyName(key)
+	if err != nil {
+		return err
+	}
+	if !isAction(action) {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	k[name] = action
+	return nil
+}
+
+// Unbind removes every binding of action
+func (k Keymap) Unbind(action Action) {
+	maps.DeleteFunc(k, func(_ string, a Action) bool { return a == action })
+}
+
+// Keys returns the keys bound to action, in name order
+func (k Keymap) Keys(action Action) []string {
+	var keys []string
+	for key, a := range k {
+		if a == action {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// withOverrides returns a copy of k where each action in overrides is bound
+// to the given key instead of its defaults; an empty key unbinds the action
+func (k Keymap) withOverrides(overrides map[string]string) (Keymap, error) {
+	km := maps.Clone(k)
+	for action, key := range overrides {
+		if !isAction(Action(action)) {
+			return nil, fmt.Errorf("unknown action %q", action)
+		}
+		km.Unbind(Action(action))
+		if key == "" {
+			continue
+		}
+		if err := km.Bind(key, Action(action)); err != nil {
+			return nil, fmt.Errorf("%s: %w", action, err)
+		}
+	}
+	return km, nil
+}
+
+// parseKeyName checks a key name from the config and returns it in the form
+// keyName produces
+func parseKeyName(key string) (string, error) {
+	if utf8.RuneCountInString(key) == 1 {
+		if key == " " {
+			return "space", nil
+		}
+		return key, nil
+	}
+	name := strings.ToLower(key)
+	if rest, ok := strings.CutPrefix(name, "alt-"); ok {
+		if utf8.RuneCountInString(rest) == 1 {
+			// Keep the case of the character
+			return "alt-" + key[len("alt-"):], nil
+		}
+		if _, err := parseKeyName(rest); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	if name == "space" || name == "backspace" {
+		return name, nil
+	}
+	for _, known := range tcell.KeyNames {
+		if strings.ToLower(known) == name {
+			return name, nil
+		}
+	}
+	// Named keys like Tab combined with Ctrl
+	if rest, ok := strings.CutPrefix(name, "ctrl-"); ok && utf8.RuneCountInString(rest) > 1 {
+		if _, err := parseKeyName(rest); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q", key)
+}
+
+// keyName returns the name of the key pressed in event, as used in keymaps
+func keyName(event *tcell.EventKey) string {
+	var name string
+	switch event.Key() {
+	case tcell.KeyRune:
+		name = string(event.Rune())
+		if name == " " {
+			name = "space"
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		name = "backspace"
+	default:
+		name = strings.ToLower(tcell.KeyNames[event.Key()])
+		// Terminals that report it can combine keys like Tab with Ctrl
+		if event.Modifiers()&tcell.ModCtrl != 0 && !strings.HasPrefix(name, "ctrl-") {
+			name = "ctrl-" + name
+		}
+	}
+	if event.Modifiers()&tcell.ModAlt != 0 {
+		name = "alt-" + name
+	}
+	return name
+}
+
+// SetKeymap replaces the key bindings, e.g. to set up bindings for an
+// embedded explorer. Reloading the config restores the configured ones.
+func (ui *FileExplorerUI) SetKeymap(k Keymap) {
+	ui.keymap = maps.Clone(k)
+	ui.renderFooter()
+}
+
+// Keymap returns a copy of the key bindings in effect
+func (ui *FileExplorerUI) Keymap() Keymap {
+	return maps.Clone(ui.keymap)
+}
+
+// runAction performs action, reporting false for actions that don't apply
+// while browsing
+func (ui *FileExplorerUI) runAction(action Action) bool {
+	switch action {
+	case ActionOpen:
+		row, _ := ui.pane.table.GetSelection()
+		ui.openRow(row)
+	case ActionPick:
+		if ui.picker == nil {
+			return false
+		}
+		ui.pick()
+	case ActionOpenExternal:
+		ui.openExternal()
+	case ActionOpenWith:
+		ui.openWith()
+	case ActionEdit:
+		ui.editSelected()
+	case ActionShell:
+		ui.promptShell()
+	case ActionGoUp:
+		ui.goUp()
+	case ActionBreadcrumb:
+		ui.focusBreadcrumb()
+	case ActionQuit:
+		ui.quit()
+	case ActionClear:
+		ui.clearState()
+	case ActionCursorUp:
+		ui.moveCursor(-1)
+	case ActionCursorDown:
+		ui.moveCursor(1)
+	case ActionScrollUp:
+		ui.scrollPreview(-1)
+	case ActionScrollDown:
+		ui.scrollPreview(1)
+	case ActionBack:
+		ui.goBack()
+	case ActionForward:
+		ui.goForward()
+	case ActionHistory:
+		ui.showHistory()
+	case ActionPrevSibling:
+		ui.goSibling(-1)
+	case ActionNextSibling:
+		ui.goSibling(1)
+	case ActionPlaces:
+		ui.showPlaces()
+	case ActionSources:
+		ui.showSources()
+	case ActionMounts:
+		ui.showMounts()
+	case ActionBookmark:
+		ui.addBookmark()
+	case ActionBookmarks:
+		ui.showBookmarks()
+	case ActionJump:
+		ui.showJump()
+	case ActionGoto:
+		ui.showGoto()
+	case ActionSwitchPane:
+		ui.switchPane()
+	case ActionDualPane:
+		ui.toggleDual()
+	case ActionTree:
+		ui.toggleTree()
+	case ActionNewTab:
+		ui.newTab()
+	case ActionCloseTab:
+		ui.closeTab()
+	case ActionNextTab:
+		ui.cycleTab(1)
+	case ActionPrevTab:
+		ui.cycleTab(-1)
+	case ActionFilter:
+		ui.showFilterBar()
+	case ActionHidden:
+		ui.toggleHidden()
+	case ActionGitIgnored:
+		ui.toggleGitIgnored()
+	case ActionSearch:
+		ui.promptSearch()
+	case ActionGrep:
+		ui.promptGrep()
+	case ActionMark:
+		ui.toggleMark()
+	case ActionMarkAll:
+		ui.markAll(false)
+	case ActionInvertMarks:
+		ui.markAll(true)
+	case ActionCopy:
+		ui.copySelected()
+	case ActionMove:
+		ui.moveSelected()
+	case ActionDelete:
+		ui.deleteSelected()
+	case ActionRename:
+		ui.renameSelected()
+	case ActionChmod:
+		ui.chmodSelected()
+	case ActionDuplicate:
+		ui.duplicateSelected()
+	case ActionUndo:
+		ui.undo()
+	case ActionNewFile:
+		ui.newFile()
+	case ActionNewDir:
+		ui.newDir()
+	case ActionTemplate:
+		ui.newFromTemplate()
+	case ActionExtract:
+		ui.extractHere()
+	case ActionExtractTo:
+		ui.extractTo()
+	case ActionCompress:
+		ui.compressSelected()
+	case ActionSort:
+		ui.cycleSort()
+	case ActionReverseSort:
+		ui.reverseSort()
+	case ActionColumns:
+		ui.cycleColumns()
+	case ActionFullTimes:
+		ui.toggleFullTimes()
+	case ActionPreview:
+		ui.togglePreview()
+	case ActionHexPreview:
+		ui.toggleHexPreview()
+	case ActionHexView:
+		ui.showHexViewer()
+	case ActionMarkdown:
+		ui.toggleMarkdownSource()
+	case ActionGitDiff:
+		ui.previewGitDiff()
+	case ActionRealPath:
+		ui.showRealPath()
+	case ActionSummary:
+		ui.showSummary()
+	case ActionDiskUsage:
+		ui.showUsage()
+	case ActionChecksum:
+		ui.showChecksums()
+	case ActionCopyPath:
+		ui.copySelectedPaths()
+	case ActionCopyName:
+		ui.copySelectedNames()
+	case ActionCopyContents:
+		ui.copyContents()
+	case ActionTrash:
+		ui.showTrash()
+	case ActionLastError:
+		ui.showErrorDetails()
+	case ActionHelp:
+		ui.showHelp()
+	case ActionPalette:
+		ui.showPalette()
+	case ActionHints:
+		ui.toggleHints()
+	case ActionReloadConfig:
+		ui.reloadConfig()
+	default:
+		return ui.runPluginAction(action)
+	}
+	return true
+}
+
+// clearState backs out of the current state: it cancels a directory load,
+// or else clears the filter, or else the marks
+func (ui *FileExplorerUI) clearState() {
+	switch {
+	case ui.pane.load != nil:
+		ui.pane.loadCancel()
+	case ui.pane.filter != "":
+		ui.setFilter("")
+	case len(ui.pane.marked) > 0:
+		ui.pane.clearMarks()
+		ui.setFooterStatus("Marks cleared")
+	}
+}
+
+// moveCursor moves the selection by delta rows, skipping rows that can't be selected
+func (ui *FileExplorerUI) moveCursor(delta int) {
+	row, _ := ui.pane.table.GetSelection()
+	for next := row + delta; next >= 1 && next < ui.pane.table.GetRowCount(); next += delta {
+		if !ui.pane.table.GetCell(next, 0).NotSelectable {
+			ui.pane.table.Select(next, 0)
+			return
+		}
+	}
+}
+
+// scrollPreview scrolls the preview by half its height in the direction of delta
+func (ui *FileExplorerUI) scrollPreview(delta int) {
+	_, _, _, height := ui.contentPane.GetInnerRect()
+	row, col := ui.contentPane.GetScrollOffset()
+	ui.contentPane.ScrollTo(max(row+delta*max(height/2, 1), 0), col)
+}
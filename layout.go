@@ -0,0 +1,90 @@
+package ui
+
+import "github.com/rivo/tview"
+
+// arrangeGrid places the panes in the grid. The regular layout shows the
+// listing and the preview side by side, or both listings in the dual-pane
+// layout, with the tree to their left if shown; the compact layout used on
+// narrow terminals shows one of them at a time.
+func (ui *FileExplorerUI) arrangeGrid() {
+	ui.grid.Clear()
+	ui.paintPaneBorders()
+
+	if !ui.compact {
+		left, right := tview.Primitive(ui.pane.table), tview.Primitive(ui.contentPane)
+		if ui.dual {
+			left, right = ui.panes[0].table, ui.panes[1].table
+		}
+		// Two equal columns, after the tree if shown
+		columns := []int{0, 0}
+		if ui.showTree {
+			columns = []int{ui.config.TreeWidth, 0, 0}
+			ui.grid.AddItem(ui.tree, 1, 0, 1, 1, 0, 0, false)
+		}
+		first := len(columns) - 2
+		ui.grid.SetColumns(columns...)
+		ui.grid.AddItem(ui.header, 0, 0, 1, len(columns), 0, 0, false) // Header spans all columns
+		ui.grid.AddItem(left, 1, first, 1, 1, 0, 0, true)              // Directory pane
+		ui.grid.AddItem(right, 1, first+1, 1, 1, 0, 0, false)          // Content or second directory pane
+		ui.grid.AddItem(ui.footer, 2, 0, 1, len(columns), 0, 0, false) // Footer spans all columns
+		return
+	}
+
+	ui.grid.SetColumns(0) // One column holding either pane
+	ui.grid.AddItem(ui.header, 0, 0, 1, 1, 0, 0, false)
+	if ui.compactPreview {
+		ui.grid.AddItem(ui.contentPane, 1, 0, 1, 1, 0, 0, true)
+	} else {
+		ui.grid.AddItem(ui.pane.table, 1, 0, 1, 1, 0, 0, true)
+	}
+	ui.grid.AddItem(ui.footer, 2, 0, 1, 1, 0, 0, false)
+}
+
+// paintPaneBorders marks the active pane in the dual-pane layout, and the
+// tree while it has the focus
+func (ui *FileExplorerUI) paintPaneBorders() {
+	if ui.tree.HasFocus() {
+		ui.tree.SetBorderColor(ui.theme.PaneBorder)
+	} else {
+		ui.tree.SetBorderColor(ui.theme.InactivePaneBorder)
+	}
+	for _, p := range ui.panes {
+		if p == ui.pane || !ui.dual {
+			p.table.SetBorderColor(ui.theme.PaneBorder)
+		} else {
+			p.table.SetBorderColor(ui.theme.InactivePaneBorder)
+		}
+	}
+}
+
+// fitLayout switches to the compact layout when the terminal is narrower
+// than the configured threshold, and back when it is widened again
+func (ui *FileExplorerUI) fitLayout(width int) {
+	compact := width < ui.config.CompactWidth
+	if compact == ui.compact {
+		return
+	}
+	ui.compact = compact
+	ui.compactPreview = false
+	ui.arrangeGrid()
+	// Called while drawing, when the application can't change the focus yet
+	ui.afterUpdate(func() {
+		if front, _ := ui.pages.GetFrontPage(); front == mainPage {
+			ui.setFocus(ui.pane.table)
+		}
+	})
+}
+
+// togglePreview swaps the listing and the preview in the compact layout
+func (ui *FileExplorerUI) togglePreview() {
+	if !ui.compact {
+		return
+	}
+	ui.compactPreview = !ui.compactPreview
+	ui.arrangeGrid()
+	if ui.compactPreview {
+		ui.setFocus(ui.contentPane)
+	} else {
+		ui.setFocus(ui.pane.table)
+	}
+}
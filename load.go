@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/rivo/tview"
+)
+
+// dirLoad is the state of a directory listing in progress
+type dirLoad struct {
+	path       string
+	title      string        // shown in the header and footer
+	first      int           // row of the first entry
+	files      []fs.DirEntry // everything read so far
+	stated     []dirEntry    // everything read so far whose file info could be read
+	entries    []dirEntry    // the entries passing the hidden and name filters
+	report     bool          // whether to report the outcome in the footer
+	footer     string        // footer message when loading started
+	selectName string        // entry to select once loaded
+}
+
+// loadDirectory populates the directory pane with the contents of the given
+// path. Starting another load cancels the one in progress.
+func (p *Pane) loadDirectory(path string) {
+	p.loadDirectoryContext(context.Background(), path)
+}
+
+// loadDirectoryContext is loadDirectory with cancellation. The directory is
+// read in the background and entries appear in batches as they are read,
+// sorted once the listing is complete. It stops as soon as ctx is cancelled,
+// a newer load starts, Esc is pressed, or the UI shuts down.
+func (p *Pane) loadDirectoryContext(parent context.Context, path string) {
+	if p.loadCancel != nil {
+		p.loadCancel()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(p.ui.ctx, cancel)
+	p.loadCancel = func() {
+		stop()
+		cancel()
+	}
+	if p.sizeCancel != nil {
+		p.sizeCancel()
+		p.sizeCancel = nil
+	}
+	p.ui.watchPanes()
+	if path != p.listingPath {
+		p.gitRepo = false
+	}
+	load := p.beginLoad(path)
+
+	// Read directory contents, including those of archives, or stat the
+	// entries of the path list.
+	// Updates from a load that has been superseded are dropped.
+	pathList := p.pathList
+	git := p.ui.config.Git && p.ui.local && pathList == nil && !inArchive(p.ui.fsys, path)
+	// Detecting types reads every file, so only for the MIME column
+	mime := p.ui.config.ShowMIME && p.ui.columnPreset != ColumnsName && !inArchive(p.ui.fsys, path)
+	sizes := p.ui.dirSizes
+	if !p.ui.config.DirSizes || pathList != nil || inArchive(p.ui.fsys, path) {
+		sizes = nil
+	}
+	p.ui.goBackground(func() {
+		// The git status comes first, so ignored entries can be left out
+		var statuses map[string]gitStatus
+		if git {
+			var repo bool
+			statuses, repo = gitStatuses(ctx, path)
+			p.ui.queueUpdateDraw(func() {
+				if p.load == load && repo != p.gitRepo {
+					p.gitRepo = repo
+					p.setHeaderRow()
+				}
+			})
+		}
+
+		emit := func(batch []fs.DirEntry) {
+			// Stat here rather than on the UI goroutine, it may be slow too
+			entries := make([]dirEntry, 0, len(batch))
+			for _, file := range batch {
+				info, err := file.Info()
+				if err != nil {
+					continue
+				}
+				e := dirEntry{Entry: core.Entry{DirEntry: file, Info: info}, git: statuses[file.Name()]}
+				if sizes != nil && file.IsDir() {
+					e.DirSize, e.Sized = sizes.get(filepath.Join(path, file.Name()), info.ModTime())
+				}
+				if mime {
+					e.mime = core.FileType(p.ui.fsys, filepath.Join(path, file.Name()), info)
+				}
+				entries = append(entries, e)
+			}
+			p.ui.queueUpdateDraw(func() {
+				if p.load == load {
+					p.addBatch(batch, entries)
+				}
+			})
+		}
+
+		var err error
+		switch {
+		case pathList != nil:
+			err = streamPathList(ctx, p.ui.fsys, pathList, emit)
+		case inArchive(p.ui.fsys, path):
+			err = streamArchiveDir(ctx, path, emit)
+		default:
+			err = core.StreamDir(ctx, p.ui.fsys, path, emit)
+		}
+		p.ui.queueUpdateDraw(func() {
+			if p.load == load {
+				p.finishLoad(err)
+			}
+		})
+	})
+}
+
+// beginLoad clears the table for a listing of path and makes it the load in
+// progress, showing the path in the header if the pane is active
+func (p *Pane) beginLoad(path string) *dirLoad {
+	// Clear the table
+	p.table.Clear()
+
+	// Re-add the header row
+	p.setHeaderRow()
+
+	// Update header with current path; panes without the focus load quietly
+	title := path
+	if p.inPathList() {
+		title = p.pathListTitle()
+	}
+	active := p == p.ui.pane
+	if active {
+		p.ui.setHeader(p)
+		p.ui.syncTree()
+	}
+
+	// Add parent directory entry; a path list has no parent
+	first := 1
+	if !p.inPathList() {
+		p.table.SetCell(1, 0, tview.NewTableCell("..").SetTextColor(p.ui.theme.Directory))
+		first = 2
+	}
+
+	load := &dirLoad{path: path, title: title, first: first, report: active}
+	p.load = load
+	p.updateDirTitle()
+
+	// Select the first item (parent directory)
+	p.table.Select(1, 0)
+	if active {
+		p.ui.setFooterStatus(tview.Escape("Loading " + title + "..."))
+		load.footer = p.ui.footerMsg
+	}
+	return load
+}
+
+// relist lists the loaded entries again without reading the directory, as
+// when the filter changes, keeping the selection. A listing that is still
+// loading or incomplete is read again instead.
+func (p *Pane) relist() {
+	row, _ := p.table.GetSelection()
+	name := p.rowName(row)
+	if p.load != nil || p.listingPath != p.path {
+		p.loadDirectory(p.path)
+		p.selectName(name)
+		return
+	}
+	load := p.beginLoad(p.path)
+	load.selectName = name
+	p.addBatch(p.listing, p.listingEntries)
+	p.finishLoad(nil)
+}
+
+// addBatch lists the entries of a newly read batch below those already shown
+func (p *Pane) addBatch(files []fs.DirEntry, entries []dirEntry) {
+	load := p.load
+	load.files = append(load.files, files...)
+	load.stated = append(load.stated, entries...)
+
+	columns := p.columns()
+	for _, file := range entries {
+		if !p.ui.showHidden && core.IsHidden(filepath.Base(file.Name())) {
+			continue
+		}
+		if !p.matchesFilter(file.Name()) {
+			continue
+		}
+		if p.ui.hideIgnored && file.git&gitIgnored != 0 {
+			continue
+		}
+		if !p.ui.pickable(load.path, file) {
+			continue
+		}
+		p.setEntryRow(load.first+len(load.entries), file, columns)
+		load.entries = append(load.entries, file)
+	}
+	p.updateDirTitle()
+}
+
+// finishLoad sorts the loaded entries and reports how loading ended. A
+// cancelled or failed load keeps what was read up to that point.
+func (p *Pane) finishLoad(err error) {
+	load := p.load
+	p.load = nil
+	p.updateDirTitle()
+
+	sort.Slice(load.files, func(i, j int) bool {
+		return load.files[i].Name() < load.files[j].Name()
+	})
+	p.listing = load.files
+	p.listingEntries = load.stated
+	// Only a complete listing can stand in for reading the directory again
+	p.listingPath = ""
+	if err == nil {
+		p.listingPath = load.path
+	}
+
+	// Keep the selected entry selected as the rows are reordered
+	selected := load.selectName
+	if row, _ := p.table.GetSelection(); selected == "" && row >= load.first {
+		selected = p.rowName(row)
+	}
+
+	sortEntries(load.entries, p.ui.sortKey, p.ui.sortReverse, p.ui.config.DirsFirst)
+	columns := p.columns()
+	for i, file := range load.entries {
+		p.setEntryRow(load.first+i, file, columns)
+	}
+
+	// Tell an empty directory apart from one whose entries are all hidden or filtered
+	if len(load.entries) == 0 {
+		placeholder := "(empty directory)"
+		switch {
+		case len(load.files) > 0:
+			placeholder = "(no matching entries)"
+		case p.inPathList():
+			placeholder = "(no existing paths)"
+		}
+		p.table.SetCell(load.first, 0, tview.NewTableCell(placeholder).
+			SetTextColor(p.ui.theme.Dim).
+			SetSelectable(false))
+	}
+	p.table.Select(1, 0)
+	if selected != "" {
+		p.selectName(selected)
+	}
+	if err == nil {
+		p.sizeDirs()
+	}
+
+	// Leave messages posted while loading alone
+	if !load.report || p.ui.footerMsg != load.footer {
+		return
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		p.ui.setFooterStatus(tview.Escape(load.title + " (loading cancelled)"))
+	case err != nil:
+		p.ui.showError(err)
+	default:
+		p.ui.setFooterStatus(tview.Escape(load.title))
+	}
+}
+
+// setEntryRow fills row with the cells of an entry
+func (p *Pane) setEntryRow(row int, file dirEntry, columns []column) {
+	p.table.SetCell(row, 0, p.nameCell(file))
+	for i, c := range columns {
+		p.table.SetCell(row, i+1, tview.NewTableCell(c.text(file)))
+	}
+	p.paintRow(row)
+}
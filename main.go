@@ -1,272 +1,759 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
 
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/fsnotify/fsnotify"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// defaultPreviewLimit is the largest number of bytes shown in the preview
+// pane, unless set with WithPreviewLimit
+const defaultPreviewLimit = 100 * 1024
+
 // FileExplorerUI extends the base template for a file explorer application
 type FileExplorerUI struct {
-	app         *tview.Application
-	grid        *tview.Grid
-	header      *tview.TextView
-	dirPane     *tview.Table
-	contentPane *tview.TextView
-	footer      *tview.TextView
-	currentPath string
+	app           *tview.Application    // run by Start; nil when embedded
+	host          Host                  // updates the screen and suspends it
+	embedded      bool                  // shown by another application's layout, see NewExplorerView
+	onQuit        func()                // called on quitting when embedded
+	focused       tview.Primitive       // part of an embedded explorer given the focus last
+	hostFocus     func(tview.Primitive) // moves the focus of the application embedding the explorer
+	pages         *tview.Pages
+	grid          *tview.Grid
+	header        *breadcrumb
+	contentPane   *tview.TextView
+	footer        *tview.TextView
+	config        Config
+	configPath    string // file the config is reloaded from
+	bookmarksPath string // file the bookmarks are kept in
+	colors        colorScheme
+	footerMsg     string // last status or error shown in the footer
+	showHidden    bool   // whether dotfiles are listed
+	hideIgnored   bool   // whether entries ignored by git are left out
+	showHints     bool   // whether key hints are shown in the footer
+	keymap        Keymap
+	sortKey       SortKey
+	sortReverse   bool
+	columnPreset  ColumnPreset
+	fullTimes     bool // show sub-second modification times with the UTC offset
+
+	compact        bool // single-column layout for narrow terminals
+	compactPreview bool // preview shown instead of the listing in the compact layout
+
+	panes [2]*Pane // the listings; the second is only shown in the dual-pane layout
+	pane  *Pane    // the pane with the focus
+	dual  bool     // whether both panes are shown
+
+	tree     *tview.TreeView // directories, shown left of the listing if showTree is set
+	showTree bool
+	treePath string // directory the tree was last synced to
+
+	fsys     vfs.FS // the file system browsed
+	local    bool   // whether fsys is the local disk
+	source   string // name of fsys in the sources picker
+	startFS  vfs.FS // the file system given with WithFS, if any
+	startDir string // where the explorer started in startFS
+
+	ctx            context.Context    // cancelled when the UI shuts down
+	cancel         context.CancelFunc // cancels ctx
+	previewCancel  context.CancelFunc // aborts the preview read in flight
+	previews       *previewCache      // rendered previews of unchanged files
+	previewLimit   int64              // largest file read whole for the preview
+	dirSizes       *dirSizeCache      // totals of directories for the Size column
+	previewLine    previewLine        // line to scroll to once a file is previewed
+	previewStream  *previewStream     // large text file being previewed in parts
+	hexPreview     bool               // preview files as hex dumps
+	markdownSource bool               // preview Markdown files as their source
+	theme          Theme              // colors of the explorer
+	refreshCancel  context.CancelFunc // stops the periodic refresh
+	watchCancel    context.CancelFunc // stops the directory watcher
+	watcher        *fsnotify.Watcher  // notices changes to the directories shown, if enabled
+	watched        map[string]bool    // directories the watcher is pointed at
+	workers        sync.WaitGroup     // background goroutines, waited for on shutdown
+
+	jobs      *ops.Queue      // copies, moves, deletions and extractions running in the background
+	undoLog   *ops.History    // operations that can be undone
+	jobDialog *jobDialog      // progress of the jobs started last, if shown
+	dontAsk   map[string]bool // questions not to ask again this session
+
+	frecency *Frecency // directories visited, for the jumper
+	plugins  plugins   // actions, previewers and columns added by plugins or RegisterAction
+	events   events    // functions told about what happens, registered by embedding programs
+
+	screen tcell.Screen // the terminal, once drawn; for copying with OSC 52 and drawing images
+
+	graphic      *graphic  // image of the preview, drawn by a graphics protocol
+	shownGraphic placement // image on the terminal
+	clipboard    string    // text copied last, pasted when the clipboard can't be read
+
+	lastErr error // last reported error, shown in full with E
+
+	picker *Picker  // set when choosing files for another program, see WithPicker
+	picked []string // what was chosen
+}
+
+// NewFileExplorerUI creates and initializes a file explorer UI, with the
+// default config unless opts say otherwise
+func NewFileExplorerUI(opts ...Option) *FileExplorerUI {
+	app := tview.NewApplication()
+	return newFileExplorerUI(app, AppHost(app), opts)
 }
 
-// NewFileExplorerUI creates and initializes a file explorer UI
-func NewFileExplorerUI() *FileExplorerUI {
+// newFileExplorerUI creates a file explorer UI running app, or embedded in
+// the application of host if app is nil
+func newFileExplorerUI(app *tview.Application, host Host, opts []Option) *FileExplorerUI {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := o.config
+	if o.theme != "" {
+		cfg.Theme = o.theme
+	}
+	if o.previewLimit <= 0 {
+		o.previewLimit = defaultPreviewLimit
+	}
 	ui := &FileExplorerUI{
-		app:         tview.NewApplication(),
-		grid:        tview.NewGrid(),
-		header:      tview.NewTextView(),
-		dirPane:     tview.NewTable(),
-		contentPane: tview.NewTextView(),
-		footer:      tview.NewTextView(),
+		configPath:    o.configPath,
+		bookmarksPath: DefaultBookmarksPath(),
+		app:           app,
+		host:          host,
+		embedded:      app == nil,
+		pages:         tview.NewPages(),
+		grid:          tview.NewGrid(),
+		contentPane:   tview.NewTextView(),
+		footer:        tview.NewTextView(),
+		previews:      newPreviewCache(),
+		dirSizes:      newDirSizeCache(),
+		previewLimit:  o.previewLimit,
+		fsys:          o.fsys,
+		local:         o.fsys == nil,
+		picker:        o.picker,
+	}
+	ui.source = localSource
+	if ui.local {
+		ui.fsys = vfs.OS()
+	} else {
+		ui.startFS, ui.source = o.fsys, startSource
 	}
 
-	// Get the current directory
-	var err error
-	ui.currentPath, err = os.Getwd()
-	if err != nil {
-		ui.currentPath = "."
+	ui.header = newBreadcrumb(ui)
+	ui.ctx, ui.cancel = context.WithCancel(context.Background())
+	ui.undoLog = ops.NewHistory(undoLimit)
+	ui.jobs = ui.newJobQueue()
+	ui.dontAsk = map[string]bool{}
+	ui.applyConfig(cfg)
+	// applyConfig falls back to the default theme
+	_, themeErr := LoadTheme(cfg.Theme)
+	if o.keymap != nil {
+		ui.keymap = o.keymap
+	}
+
+	// Both panes start in the working directory, or the root of another
+	// file system, unless asked to start elsewhere
+	dir := vfs.Path(".")
+	if ui.local {
+		if wd, err := os.Getwd(); err == nil {
+			dir = wd
+		} else {
+			dir = "."
+		}
 	}
+	var reveal string
+	if o.startPath != "" {
+		start := expandPath(ui.fsys, o.startPath, dir)
+		if info, err := core.Stat(ui.fsys, start); err == nil && info.IsDir() {
+			dir = start
+		} else {
+			reveal = start
+		}
+	}
+	ui.startDir = dir
+	ui.panes = [2]*Pane{newPane(ui, dir), newPane(ui, dir)}
+	ui.pane = ui.panes[0]
+	ui.dual = cfg.DualPane
+	ui.showTree = cfg.ShowTree
 
 	ui.setupComponents()
 	ui.setupLayout()
 	ui.setupKeybindings()
-	ui.loadDirectory(ui.currentPath)
+	ui.loadPlugins(DefaultPluginsDir())
+	ui.pane.loadDirectory(ui.pane.path)
+	if ui.dual {
+		ui.otherPane().loadDirectory(dir)
+	}
+	ui.startAutoRefresh()
+	ui.startWatcher()
+
+	frecency, err := LoadFrecency(DefaultFrecencyPath())
+	if err != nil {
+		ui.showError(err)
+	}
+	ui.frecency = frecency
+	if reveal != "" {
+		// Reports a missing file, showing the nearest directory that exists
+		ui.Reveal(reveal)
+	}
+	if themeErr != nil {
+		ui.showError(themeErr)
+	}
+	if ui.picker != nil {
+		ui.setFooterStatus(ui.pickerHint())
+	}
 
 	return ui
 }
 
+// NewFileExplorerUIWithConfig creates and initializes a file explorer UI using cfg
+//
+// Deprecated: use NewFileExplorerUI(WithConfig(cfg))
+func NewFileExplorerUIWithConfig(cfg Config) *FileExplorerUI {
+	return NewFileExplorerUI(WithConfig(cfg))
+}
+
 // setupComponents initializes individual UI components
 func (ui *FileExplorerUI) setupComponents() {
 	// Header setup
-	ui.header.SetTextAlign(tview.AlignCenter)
-	ui.header.SetDynamicColors(true)
-	ui.header.SetText("[blue::b]File Explorer - " + ui.currentPath)
-	ui.header.SetBackgroundColor(tcell.ColorDarkBlue)
-
-	// Directory pane setup
-	ui.dirPane.SetBorder(true)
-	ui.dirPane.SetTitle("Directory Contents")
-	ui.dirPane.SetBorderColor(tcell.ColorGreen)
-	ui.dirPane.SetSelectable(true, false)
-	ui.dirPane.SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorDarkGreen).Foreground(tcell.ColorWhite))
-
-	// Setup column headers
-	ui.dirPane.SetCell(0, 0, tview.NewTableCell("Name").SetAttributes(tcell.AttrBold))
-	ui.dirPane.SetCell(0, 1, tview.NewTableCell("Size").SetAttributes(tcell.AttrBold))
-	ui.dirPane.SetCell(0, 2, tview.NewTableCell("Modified").SetAttributes(tcell.AttrBold))
+	ui.setHeader(ui.pane)
+
+	ui.setupTree()
 
 	// Content view pane setup
 	ui.contentPane.SetBorder(true)
-	ui.contentPane.SetTitle("File Preview")
-	ui.contentPane.SetBorderColor(tcell.ColorYellow)
+	ui.contentPane.SetTitle(previewTitle)
 	ui.contentPane.SetDynamicColors(true)
 	ui.contentPane.SetWordWrap(true)
 	ui.contentPane.SetText("Select a file to preview its contents")
 
 	// Footer setup
 	ui.footer.SetDynamicColors(true)
-	ui.footer.SetText("[white]Keys: [yellow]↑/↓[white] Navigate | [yellow]Enter[white] Open | [yellow]Backspace[white] Go Up | [yellow]Ctrl-C[white] Quit")
-	ui.footer.SetBackgroundColor(tcell.ColorDarkGray)
+	ui.applyTheme()
 }
 
 // setupLayout arranges UI components in a grid
 func (ui *FileExplorerUI) setupLayout() {
 	// Define grid layout
 	ui.grid.SetRows(1, 0, 1)  // Header: 1 line, Main: flexible, Footer: 1 line
-	ui.grid.SetColumns(0, 0)  // Two equal columns
 	ui.grid.SetBorders(false) // No borders between cells
+	ui.arrangeGrid()
+
+	// Set the grid as the bottom page; dialogs and views are layered on top
+	ui.pages.AddPage(mainPage, ui.grid, true, true)
+	if ui.embedded {
+		return
+	}
 
-	// Add components to the grid
-	ui.grid.AddItem(ui.header, 0, 0, 1, 2, 0, 0, false)      // Header spans both columns
-	ui.grid.AddItem(ui.dirPane, 1, 0, 1, 1, 0, 0, true)      // Directory pane
-	ui.grid.AddItem(ui.contentPane, 1, 1, 1, 1, 0, 0, false) // Content pane
-	ui.grid.AddItem(ui.footer, 2, 0, 1, 2, 0, 0, false)      // Footer spans both columns
+	ui.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		width, _ := screen.Size()
+		ui.beforeDraw(screen, width)
+		return false
+	})
+	ui.app.SetAfterDrawFunc(ui.afterDraw)
+	ui.app.SetRoot(ui.pages, true)
+}
 
-	// Set the grid as the root of the application
-	ui.app.SetRoot(ui.grid, true)
+// beforeDraw prepares drawing the explorer width cells wide on screen,
+// switching between the two-column and compact layouts as it is resized
+func (ui *FileExplorerUI) beforeDraw(screen tcell.Screen, width int) {
+	ui.screen = screen
+	ui.fitLayout(width)
+	ui.continuePreviewStream()
 }
 
 // setupKeybindings configures application-wide keyboard shortcuts
 func (ui *FileExplorerUI) setupKeybindings() {
-	// Set up selection handler for the directory pane
-	ui.dirPane.SetSelectionChangedFunc(func(row, column int) {
-		if row > 0 { // Skip header row
-			filename := ui.dirPane.GetCell(row, 0).Text
-			fullPath := filepath.Join(ui.currentPath, filename)
-			ui.previewFile(fullPath)
+	for _, p := range ui.panes {
+		ui.setupPaneKeybindings(p)
+	}
+
+	// In the compact layout the preview takes the listing's place until dismissed
+	ui.contentPane.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch ui.keymap[keyName(event)] {
+		case ActionPreview, ActionClear:
+			ui.togglePreview()
+			return nil
 		}
+		return event
 	})
 
-	// Set up selection handler for the directory pane
-	ui.dirPane.SetSelectedFunc(func(row, column int) {
-		if row > 0 { // Skip header row
-			filename := ui.dirPane.GetCell(row, 0).Text
+	// An embedded explorer sees its keys in ExplorerView.InputHandler
+	if !ui.embedded {
+		ui.app.SetInputCapture(ui.captureGlobalKeys)
+	}
+}
 
-			if filename == ".." {
-				// Go up one directory
-				ui.currentPath = filepath.Dir(ui.currentPath)
-				ui.loadDirectory(ui.currentPath)
-				return
-			}
+// captureGlobalKeys handles the keys that work everywhere, dialogs included:
+// quit keys other than characters
+func (ui *FileExplorerUI) captureGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() != tcell.KeyRune && ui.keymap[keyName(event)] == ActionQuit {
+		ui.quit()
+		return nil
+	}
+	return event
+}
 
-			fullPath := filepath.Join(ui.currentPath, filename)
-			fileInfo, err := os.Stat(fullPath)
-			if err != nil {
-				ui.setFooterError(err.Error())
-				return
+// setupPaneKeybindings connects the keys and mouse of a pane's table
+func (ui *FileExplorerUI) setupPaneKeybindings(p *Pane) {
+	// Set up selection handler for the directory pane
+	p.table.SetSelectionChangedFunc(func(row, column int) {
+		if row > 0 { // Skip header row
+			filename := p.rowName(row)
+			fullPath := filepath.Join(p.path, filename)
+			if fullPath != p.armedDir {
+				p.armedDir = ""
 			}
-
-			if fileInfo.IsDir() {
-				// Navigate into the directory
-				ui.currentPath = fullPath
-				ui.loadDirectory(ui.currentPath)
-			} else {
-				// Preview the file
+			if p == ui.pane {
 				ui.previewFile(fullPath)
+				ui.selectionChanged()
 			}
 		}
 	})
 
-	// Set global keybindings
-	ui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyCtrlC:
-			ui.app.Stop()
-			return nil
-		case tcell.KeyBackspace, tcell.KeyBackspace2:
-			// Go up one directory
-			ui.currentPath = filepath.Dir(ui.currentPath)
-			ui.loadDirectory(ui.currentPath)
+	// Set up selection handler for the directory pane
+	p.table.SetSelectedFunc(func(row, column int) {
+		ui.openRow(row)
+	})
+
+	// Bound keys only apply while browsing, so they don't interfere with
+	// text entry in dialogs
+	p.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if action, ok := ui.boundAction(keyName(event)); ok && ui.runAction(action) {
 			return nil
 		}
 		return event
 	})
-}
 
-// loadDirectory populates the directory pane with the contents of the given path
-func (ui *FileExplorerUI) loadDirectory(path string) {
-	// Clear the table
-	ui.dirPane.Clear()
+	// The focused pane is the active one, however it got the focus
+	p.table.SetFocusFunc(func() {
+		ui.activatePane(p)
+	})
 
-	// Re-add the header row
-	ui.dirPane.SetCell(0, 0, tview.NewTableCell("Name").SetAttributes(tcell.AttrBold))
-	ui.dirPane.SetCell(0, 1, tview.NewTableCell("Size").SetAttributes(tcell.AttrBold))
-	ui.dirPane.SetCell(0, 2, tview.NewTableCell("Modified").SetAttributes(tcell.AttrBold))
+	// Clicking a column header sorts by that column and double-clicking an
+	// entry opens it; single clicks select and the wheel scrolls as usual
+	p.table.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		row, column := p.table.CellAt(event.Position())
+		if action == tview.MouseLeftDoubleClick && row > 0 && p == ui.pane {
+			p.table.Select(row, 0)
+			ui.openRow(row)
+			return tview.MouseConsumed, nil
+		}
+		if action != tview.MouseLeftClick || row != 0 || column < 0 {
+			return action, event
+		}
+		if key, ok := p.table.GetCell(0, column).GetReference().(SortKey); ok {
+			ui.sortBy(key)
+		}
+		return tview.MouseConsumed, nil
+	})
+}
 
-	// Update header with current path
-	ui.header.SetText("[blue::b]File Explorer - " + path)
+// openRow activates the entry in row: directories are opened according to
+// the DirOpenMode, files are previewed and ".." goes up
+func (ui *FileExplorerUI) openRow(row int) {
+	if row <= 0 { // Skip header row
+		return
+	}
+	filename := ui.pane.rowName(row)
 
-	// Add parent directory entry
-	ui.dirPane.SetCell(1, 0, tview.NewTableCell("..").SetTextColor(tcell.ColorBlue))
-	ui.dirPane.SetCell(1, 1, tview.NewTableCell(""))
-	ui.dirPane.SetCell(1, 2, tview.NewTableCell(""))
+	if filename == ".." {
+		// Go up one directory
+		ui.goUp()
+		return
+	}
 
-	// Read directory contents
-	files, err := os.ReadDir(path)
+	fullPath := filepath.Join(ui.pane.path, filename)
+	fileInfo, err := statPath(ui.fsys, fullPath)
 	if err != nil {
-		ui.setFooterError(err.Error())
+		ui.showError(err)
 		return
 	}
 
-	// Add files to the table
-	row := 2
-	for _, file := range files {
-		info, err := file.Info()
-		if err != nil {
-			continue
-		}
+	if ui.picker != nil && ui.pickFile(fullPath, fileInfo) {
+		return
+	}
 
-		// Set the file name with appropriate color
-		nameCell := tview.NewTableCell(file.Name())
-		if file.IsDir() {
-			nameCell.SetTextColor(tcell.ColorBlue)
-		} else {
-			nameCell.SetTextColor(tcell.ColorWhite)
-		}
-		ui.dirPane.SetCell(row, 0, nameCell)
+	if kind := core.SpecialKind(fileInfo.Mode()); kind != "" {
+		ui.setFooterError(tview.Escape(fmt.Sprintf("%s is a %s and cannot be opened", filename, kind)))
+		return
+	}
 
-		// Set the file size
-		sizeText := "-"
-		if !file.IsDir() {
-			sizeText = formatSize(info.Size())
-		}
-		ui.dirPane.SetCell(row, 1, tview.NewTableCell(sizeText))
+	// Archives are browsed like directories
+	switch {
+	case fileInfo.IsDir() || isArchiveFile(ui.fsys, fullPath, fileInfo):
+		ui.openDirectory(fullPath)
+	case ui.config.OpenExternal && ui.local && !inArchive(ui.fsys, ui.pane.path) && !isTextFile(ui.fsys, fullPath):
+		ui.openExternal()
+	default:
+		// Preview the file
+		ui.previewFile(fullPath)
+		ui.fileOpened(fullPath)
+	}
+}
 
-		// Set the modification time
-		ui.dirPane.SetCell(row, 2, tview.NewTableCell(info.ModTime().Format("2006-01-02 15:04:05")))
+// selectedPath returns the full path of the selected row, if any
+func (p *Pane) selectedPath() (string, bool) {
+	row, _ := p.table.GetSelection()
+	// Placeholders aren't entries, and the selection can be left past the
+	// last row when only they follow
+	if row < 1 || row >= p.table.GetRowCount() || p.table.GetCell(row, 0).NotSelectable {
+		return "", false
+	}
+	return filepath.Join(p.path, p.rowName(row)), true
+}
 
-		row++
+// selectedEntry is selectedPath for operations on entries, excluding the ".." row
+func (p *Pane) selectedEntry() (string, bool) {
+	row, _ := p.table.GetSelection()
+	if row < 1 || p.rowName(row) == ".." {
+		return "", false
 	}
+	return p.selectedPath()
+}
 
-	// Select the first item (parent directory)
-	ui.dirPane.Select(1, 0)
-	ui.app.SetFocus(ui.dirPane)
+// reload lists the current directory again, keeping the same entry selected
+func (p *Pane) reload() {
+	row, _ := p.table.GetSelection()
+	name := p.rowName(row)
+	p.loadDirectory(p.path)
+	p.selectName(name)
+}
 
-	ui.setFooterStatus(path)
+// selectName moves the selection to the row listing name, if present.
+// While the directory is loading, name is selected once it is complete.
+func (p *Pane) selectName(name string) {
+	if p.load != nil {
+		p.load.selectName = name
+	}
+	for row := 1; row < p.table.GetRowCount(); row++ {
+		if p.rowName(row) == name {
+			p.table.Select(row, 0)
+			return
+		}
+	}
 }
 
-// previewFile shows a preview of the file in the content pane
-func (ui *FileExplorerUI) previewFile(path string) {
-	fileInfo, err := os.Stat(path)
+// showRealPath displays the absolute, symlink-resolved path of the selection
+// in the footer. Links are only resolved on the local disk.
+func (ui *FileExplorerUI) showRealPath() {
+	path, ok := ui.pane.selectedPath()
+	if !ok || ui.refuseNonLocal() {
+		return
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
 	if err != nil {
-		ui.contentPane.SetText(fmt.Sprintf("Error: %s", err.Error()))
+		ui.showError(fmt.Errorf("cannot resolve %s: %w", abs, err))
 		return
 	}
 
-	if fileInfo.IsDir() {
-		ui.contentPane.SetText(fmt.Sprintf("Directory: %s\nContains %d items",
-			path, countDirItems(path)))
+	if resolved != abs {
+		ui.setFooterStatus(tview.Escape(fmt.Sprintf("%s → %s", abs, resolved)))
 		return
 	}
+	ui.setFooterStatus(tview.Escape(resolved))
+}
 
-	// Don't try to preview large files
-	if fileInfo.Size() > 100*1024 { // 100KB limit
-		ui.contentPane.SetText(fmt.Sprintf("File is too large to preview (%s)",
-			formatSize(fileInfo.Size())))
+// openDirectory handles activation of a directory row according to the configured DirOpenMode
+func (ui *FileExplorerUI) openDirectory(path string) {
+	switch ui.config.DirOpenMode {
+	case DirOpenConfirm:
+		if ui.pane.armedDir != path {
+			// First activation only previews; the next one descends
+			ui.pane.armedDir = path
+			ui.previewFile(path)
+			ui.setFooterStatus(tview.Escape("Press Enter again to open " + filepath.Base(path)))
+			return
+		}
+	case DirOpenPreview:
+		ui.pane.armedDir = ""
+		ui.navigate(path)
+		ui.previewFile(path)
+		ui.setFocus(ui.pane.table)
 		return
 	}
 
-	// Read file content
-	content, err := os.ReadFile(path)
-	if err != nil {
-		ui.contentPane.SetText(fmt.Sprintf("Error reading file: %s", err.Error()))
+	ui.pane.armedDir = ""
+	ui.navigate(path)
+	ui.setFocus(ui.pane.table)
+}
+
+// setHeader shows the directory of p in the header, or the title of its path
+// list
+func (ui *FileExplorerUI) setHeader(p *Pane) {
+	if p.inPathList() {
+		ui.header.setTitle(p.pathListTitle())
 		return
 	}
+	ui.header.setPath(p.path)
+}
+
+// setHeaderRow writes the column headers into the first row of the directory
+// pane, marking the sort column with the sort direction
+func (p *Pane) setHeaderRow() {
+	p.table.SetCell(0, 0, p.headerCell("Name", SortName))
+	for i, c := range p.columns() {
+		p.table.SetCell(0, i+1, p.headerCell(c.title, c.sortKey))
+	}
+}
+
+// headerCell creates a non-selectable column header. Columns with a sort
+// key carry it as reference so clicks on them can change the sort.
+func (p *Pane) headerCell(title string, key SortKey) *tview.TableCell {
+	if key != "" && key == p.ui.sortKey {
+		if p.ui.sortReverse {
+			title += " ▼"
+		} else {
+			title += " ▲"
+		}
+	}
+	cell := tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false)
+	if key != "" {
+		cell.SetReference(key)
+	}
+	return cell
+}
+
+// NavigateContext changes the current directory to path, giving up on the
+// listing if ctx is cancelled first. A relative path is taken from the
+// current directory, and the move is recorded in the history and applies
+// FilterOnNavigate like any other. The directory is listed in the
+// background; errors reading it are reported in the footer. It is meant for
+// embedders; once the application is running it must be called on the UI
+// goroutine, e.g. from within Application.QueueUpdateDraw.
+func (ui *FileExplorerUI) NavigateContext(ctx context.Context, path string) error {
+	ui.navigateContext(ctx, expandPath(ui.fsys, path, ui.pane.path))
+	return nil
+}
+
+// previewFile shows a preview of the file in the content pane. The file is
+// read in the background; starting another preview cancels the one in flight
+// so only the most recent selection ever lands in the pane. The preview
+// starts at the top, or at the line requested in ui.previewLine for path.
+func (ui *FileExplorerUI) previewFile(path string) {
+	if ui.previewCancel != nil {
+		ui.previewCancel()
+	}
+	ctx, cancel := context.WithCancel(ui.ctx)
+	ui.previewCancel = cancel
+	if ui.previewStream != nil {
+		ui.previewStream = nil
+		ui.contentPane.SetTitle(previewTitle)
+	}
+
+	// Capture what the background read needs from the UI state up front
+	counter := dirCounter{showHidden: ui.showHidden, limit: ui.config.DirCountLimit}
+	mode := ui.previewMode()
+	if path == ui.pane.listingPath {
+		counter.cached = ui.pane.listing
+	}
+	// Plugins preview local files, except in hex
+	previewer, custom := ui.plugins.previewer(path)
+	custom = custom && ui.local && !mode.hex && !inArchive(ui.fsys, path)
+
+	ui.goBackground(func() {
+		// Regular files are served from the cache while they are unchanged
+		var key previewKey
+		info, err := core.Stat(ui.fsys, path)
+		custom := custom && err == nil && info.Mode().IsRegular()
+		// Large text files are read a part at a time as the preview is scrolled
+		if err == nil && !custom {
+			if encoding, ok := streamable(ui.fsys, path, info, mode); ok {
+				ui.startPreviewStream(ctx, path, info.Size(), encoding)
+				return
+			}
+		}
+		cacheable := err == nil && info.Mode().IsRegular()
+		if cacheable {
+			key = previewKey{path: path, modTime: info.ModTime(), size: info.Size(), mode: mode}
+		}
+
+		var r renderedPreview
+		ok := false
+		if cacheable {
+			r, ok = ui.previews.get(key)
+		}
+		if !ok {
+			var stable bool
+			if custom {
+				r.text, stable = renderPluginPreview(previewer, path)
+			} else {
+				r, stable = renderPreview(ctx, ui.fsys, path, counter, mode)
+			}
+			if cacheable && stable && ctx.Err() == nil {
+				ui.previews.put(key, r)
+			}
+		}
+
+		ui.queueUpdateDraw(func() {
+			// Checked on the UI goroutine, where cancellation happens
+			if ctx.Err() != nil {
+				return
+			}
+			ui.contentPane.SetTitle(r.title())
+			ui.contentPane.SetText(r.text)
+			ui.graphic = r.graphic
+			if ui.previewLine.path == path {
+				ui.contentPane.ScrollTo(ui.previewLine.line-1, 0)
+				ui.previewLine = previewLine{}
+			} else {
+				ui.contentPane.ScrollToBeginning()
+			}
+		})
+	})
+}
+
+// renderedPreview is a preview as shown in the preview pane
+type renderedPreview struct {
+	text     string
+	encoding string   // of text not in UTF-8, shown in the title
+	graphic  *graphic // image drawn over the blank start of text, if any
+}
+
+// title returns the title of the preview pane showing the preview
+func (r renderedPreview) title() string {
+	if r.encoding == "" {
+		return previewTitle
+	}
+	return fmt.Sprintf("%s (%s)", previewTitle, r.encoding)
+}
+
+// renderPreview builds the preview text for path in fsys. It does not touch the UI
+// and gives up early once ctx is cancelled. Files are rendered according to
+// mode. stable reports whether the text depends on nothing but
+// the file's contents, so it can be cached.
+func renderPreview(ctx context.Context, fsys vfs.FS, path string, counter dirCounter, mode previewMode) (r renderedPreview, stable bool) {
+	// Archives are summarized and their members read from them, except for
+	// the hex dump of an archive file
+	if _, member, ok := splitArchivePath(fsys, path); ok && !(mode.hex && member == ".") {
+		return renderArchivePreview(ctx, path, mode)
+	}
+
+	p, err := core.ReadPreview(ctx, fsys, path, core.PreviewOptions{Limit: mode.limit, HeadSize: hexPreviewLimit})
+	if err != nil {
+		return renderedPreview{text: fmt.Sprintf("Error: %s", err.Error())}, false
+	}
+	size := p.Info.Size()
+
+	switch p.Kind {
+	case core.Directory:
+		return renderedPreview{text: fmt.Sprintf("Directory: %s\nContains %s items",
+			path, counter.count(ctx, fsys, path))}, false
+	case core.Special:
+		// Reading a FIFO or device could block forever, so it wasn't read
+		return renderedPreview{text: fmt.Sprintf("Cannot preview %s: %s\nMode: %s",
+			core.SpecialKind(p.Info.Mode()), path, formatPermissions(p.Info.Mode()))}, false
+	}
+
+	// Office documents are zip containers; show their text instead of "Binary file"
+	if isOfficeDocument(p.Type) && !mode.hex {
+		if text, err := officePreview(fsys, path, p.Type, int(mode.limit), mode.theme); err == nil {
+			return renderedPreview{text: text}, true
+		}
+		// Malformed documents fall through to the generic preview
+	}
+
+	// Draw images rather than calling them binary, reading those larger
+	// than the preview limit whole
+	if isImage(p.Type) && !mode.hex && size <= imageLimit {
+		content := p.Data
+		if p.Partial {
+			content, err = core.ReadFile(ctx, fsys, path)
+		}
+		if err == nil {
+			if r, err := imagePreview(content, mode.cols, mode.rows, mode.image); err == nil {
+				return r, true
+			}
+		}
+		// Undecodable images fall through to the generic preview
+	}
+
+	// Don't preview large files, except for the start of binaries
+	if p.Partial {
+		if mode.hex || p.Kind == core.Binary {
+			return renderedPreview{text: binaryPreview(path, size, p.Data, mode.cols, mode.theme)}, true
+		}
+		return renderedPreview{text: fmt.Sprintf("File is too large to preview (%s)", formatSize(size))}, true
+	}
+
+	r.text, r.encoding = renderContent(path, size, p.Data, mode)
+	return r, true
+}
+
+// renderContent renders the contents of a file of the given size for the
+// preview: as a hex dump if mode asks for one, as the hex dump of their
+// start for binary files, or else as text, transcoded to UTF-8 from the
+// encoding returned, rendered if it's Markdown and otherwise highlighted if
+// its syntax is known
+func renderContent(path string, size int64, content []byte, mode previewMode) (text, encoding string) {
+	if mode.hex {
+		return hexDump(content, mode.theme), ""
+	}
 
 	// Check if it's a binary file
-	if isBinary(content) {
-		ui.contentPane.SetText(fmt.Sprintf("Binary file: %s\nSize: %s",
-			path, formatSize(fileInfo.Size())))
-		return
+	encoding, ok := core.DetectEncoding(content)
+	if !ok {
+		return binaryPreview(path, size, content[:min(len(content), hexPreviewLimit)], mode.cols, mode.theme), ""
+	}
+	content, err := core.Decode(content, encoding)
+	if err != nil {
+		return fmt.Sprintf("Error decoding %s: %s", encoding, err), encoding
 	}
 
-	// Display the file content
-	ui.contentPane.SetText(string(content))
+	if !mode.mdSource && core.TypeByExtension(path) == "text/markdown" {
+		return renderMarkdown(string(content), mode.style, mode.cols, mode.theme), encoding
+	}
+
+	// Display the file content, highlighted if its syntax is known
+	if mode.style != "" {
+		if text, ok := highlight(filepath.Base(path), string(content), mode.style); ok {
+			return text, encoding
+		}
+	}
+	return string(content), encoding
 }
 
-// Helper function to set footer status
+// Helper function to set footer status. status may hold color tags, so
+// text from paths, names or errors must be escaped with tview.Escape.
 func (ui *FileExplorerUI) setFooterStatus(status string) {
-	ui.footer.SetText(fmt.Sprintf("[white]%s | Keys: [yellow]↑/↓[white] Navigate | [yellow]Enter[white] Open | [yellow]Backspace[white] Go Up | [yellow]Ctrl-C[white] Quit", status))
+	ui.footerMsg = colorTag(ui.theme.FooterText) + status
+	ui.renderFooter()
 }
 
-// Helper function to set footer error
+// Helper function to set footer error, escaped like setFooterStatus
 func (ui *FileExplorerUI) setFooterError(errMsg string) {
-	ui.footer.SetText(fmt.Sprintf("[red]Error: %s[white] | Keys: [yellow]↑/↓[white] Navigate | [yellow]Enter[white] Open | [yellow]Backspace[white] Go Up | [yellow]Ctrl-C[white] Quit", errMsg))
+	ui.footerMsg = colorTag(ui.theme.Error) + "Error: " + errMsg
+	ui.renderFooter()
 }
 
-// Start runs the application
+// Start runs the application until it is stopped, then waits briefly for
+// background work to finish so nothing touches the terminal afterwards
 func (ui *FileExplorerUI) Start() error {
+	defer ui.shutdown()
+	// There's no screen left to report a failure to save on
+	defer ui.frecency.Save()
+
+	// Termination signals quit the same way as Ctrl-C, restoring the terminal
+	sigCtx, stopSignals := signal.NotifyContext(ui.ctx, syscall.SIGTERM, syscall.SIGHUP)
+	defer stopSignals()
+	context.AfterFunc(sigCtx, ui.Stop)
+
 	return ui.app.Run()
 }
 
@@ -286,23 +773,102 @@ func formatSize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
-// countDirItems returns the number of items in a directory
-func countDirItems(path string) int {
-	files, err := os.ReadDir(path)
-	if err != nil {
-		return 0
+// formatPermissions renders a file mode the way ls -l does, e.g. "-rwsr-xr-x".
+// The setuid and setgid bits replace the owner and group execute slots with
+// 's' (or 'S' when execute is unset); the sticky bit does the same with 't'/'T'
+// in the others slot.
+func formatPermissions(mode fs.FileMode) string {
+	buf := []byte("----------")
+
+	switch {
+	case mode&fs.ModeDir != 0:
+		buf[0] = 'd'
+	case mode&fs.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&fs.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	case mode&fs.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&fs.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		buf[0] = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	perm := mode.Perm()
+	for i := 0; i < 9; i++ {
+		if perm&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i]
+		}
+	}
+
+	special := func(idx int, set bool, lower, upper byte) {
+		if !set {
+			return
+		}
+		if buf[idx] == 'x' {
+			buf[idx] = lower
+		} else {
+			buf[idx] = upper
+		}
 	}
-	return len(files)
+	special(3, mode&fs.ModeSetuid != 0, 's', 'S')
+	special(6, mode&fs.ModeSetgid != 0, 's', 'S')
+	special(9, mode&fs.ModeSticky != 0, 't', 'T')
+
+	return string(buf)
+}
+
+// hasSpecialBits reports whether the setuid, setgid or sticky bit is set
+func hasSpecialBits(mode fs.FileMode) bool {
+	return mode&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky) != 0
 }
 
-// isBinary checks if data appears to be binary
-// TODO: Make fast
-func isBinary(data []byte) bool {
-	// Check for null bytes or control characters, which suggest binary content
-	for _, b := range data {
-		if b == 0 || (b < 32 && b != '\n' && b != '\r' && b != '\t') {
-			return true
+// dirCounter counts directory entries for the directory preview
+type dirCounter struct {
+	showHidden bool
+	limit      int           // stop counting beyond this many entries; 0 means no limit
+	cached     []fs.DirEntry // listing of the loaded directory, reused instead of reading it again
+}
+
+// count returns the number of entries in path as text, e.g. "42" or "500+"
+// once the limit is exceeded. Only as much of the directory as needed to
+// reach the limit is read.
+func (c dirCounter) count(ctx context.Context, fsys vfs.FS, path string) string {
+	n := 0
+	over := false
+	add := func(entries []fs.DirEntry) {
+		for _, e := range entries {
+			if !c.showHidden && core.IsHidden(e.Name()) {
+				continue
+			}
+			n++
+			if c.limit > 0 && n > c.limit {
+				over = true
+				return
+			}
 		}
 	}
-	return false
+
+	if c.cached != nil {
+		add(c.cached)
+	} else {
+		// Stop reading as soon as the limit is exceeded
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		err := core.StreamDir(ctx, fsys, path, func(batch []fs.DirEntry) {
+			if add(batch); over {
+				cancel()
+			}
+		})
+		if err != nil && !over {
+			return "?"
+		}
+	}
+
+	if over {
+		return fmt.Sprintf("%d+", c.limit)
+	}
+	return fmt.Sprint(n)
 }
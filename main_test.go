@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestFormatPermissions(t *testing.T) {
+	tests := []struct {
+		mode    fs.FileMode
+		want    string
+		special bool
+	}{
+		{0o644, "-rw-r--r--", false},
+		{0o755, "-rwxr-xr-x", false},
+		{0, "----------", false},
+
+		// Special bits show in the execute slots, in upper case without execute
+		{fs.ModeSetuid | 0o755, "-rwsr-xr-x", true},
+		{fs.ModeSetuid | 0o644, "-rwSr--r--", true},
+		{fs.ModeSetgid | 0o755, "-rwxr-sr-x", true},
+		{fs.ModeSetgid | 0o644, "-rw-r-Sr--", true},
+		{fs.ModeDir | fs.ModeSticky | 0o777, "drwxrwxrwt", true},
+		{fs.ModeDir | fs.ModeSticky | 0o776, "drwxrwxrwT", true},
+		{fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky, "---S--S--T", true},
+		{fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky | 0o777, "-rwsrwsrwt", true},
+
+		// Types
+		{fs.ModeDir | 0o755, "drwxr-xr-x", false},
+		{fs.ModeSymlink | 0o777, "lrwxrwxrwx", false},
+		{fs.ModeNamedPipe | 0o600, "prw-------", false},
+		{fs.ModeSocket | 0o755, "srwxr-xr-x", false},
+		{fs.ModeDevice | fs.ModeCharDevice | 0o666, "crw-rw-rw-", false},
+		{fs.ModeDevice | 0o660, "brw-rw----", false},
+	}
+	for _, tt := range tests {
+		if got := formatPermissions(tt.mode); got != tt.want {
+			t.Errorf("formatPermissions(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+		if got := hasSpecialBits(tt.mode); got != tt.special {
+			t.Errorf("hasSpecialBits(%v) = %v, want %v", tt.mode, got, tt.special)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/rivo/tview"
+)
+
+// Patterns of the Markdown blocks renderMarkdown tells apart
+var (
+	mdHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetext    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdFence     = regexp.MustCompile("^( {0,3})(```+|~~~+)[ \t]*([^ \t`]*)")
+	mdRule      = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	mdQuote     = regexp.MustCompile(`^ {0,3}> ?`)
+	mdBullet    = regexp.MustCompile(`^([ \t]*)[-*+][ \t]+(.*)$`)
+	mdOrdered   = regexp.MustCompile(`^([ \t]*)(\d{1,9}[.)])[ \t]+(.*)$`)
+	mdTask      = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	mdTableRule = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdLink      = regexp.MustCompile(`^!?\[([^\]]*)\]\(([^)\s]*)(?:\s+"[^"]*")?\)`)
+)
+
+// Styles of rendered Markdown that don't depend on the theme, as tview
+// color tags
+const (
+	mdHeadingStyle = "[::b]"
+	mdResetStyle   = "[-:-:-]"
+)
+
+// mdStyles are the styles of rendered Markdown taken from the theme, as
+// tview color tags
+type mdStyles struct {
+	heading1, heading2 string
+	code, muted        string
+}
+
+// markdownStyles returns the styles Markdown is rendered in with theme
+func markdownStyles(theme Theme) mdStyles {
+	return mdStyles{
+		heading1: colorTag(theme.Title) + "[::bu]",
+		heading2: colorTag(theme.Title) + "[::b]",
+		code:     colorTag(theme.Code),
+		muted:    colorTag(theme.Muted),
+	}
+}
+
+// renderMarkdown renders Markdown text with tview color tags, the way a
+// terminal Markdown viewer shows it: headings, emphasis and code styled,
+// list bullets drawn and paragraphs joined for the preview to wrap. Code
+// blocks are highlighted in the chroma style if one is given and their
+// language is known. Rules are drawn cols wide, and colors come from theme.
+func renderMarkdown(src, style string, cols int, theme Theme) string {
+	s := markdownStyles(theme)
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString(s.renderInline(strings.Join(para, " ")))
+			b.WriteByte('\n')
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// Fenced code blocks run to the closing fence or the end of the text
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flush()
+			fence, lang := m[2], m[3]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence[:3]) &&
+					strings.Trim(strings.TrimSpace(lines[i]), fence[:1]) == "" {
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], m[1]))
+			}
+			b.WriteString(s.renderCodeBlock(strings.Join(code, "\n"), lang, style))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+			b.WriteByte('\n')
+		case mdSetext.MatchString(line) && len(para) > 0:
+			// A line of = or - under a paragraph makes it a heading
+			level := 1
+			if trimmed[0] == '-' {
+				level = 2
+			}
+			text := strings.Join(para, " ")
+			para = nil
+			b.WriteString(s.renderHeading(level, text))
+		case mdRule.MatchString(line):
+			flush()
+			b.WriteString(s.muted + strings.Repeat("─", cols) + "[-]\n")
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			b.WriteString(s.renderHeading(len(m[1]), m[2]))
+		case mdQuote.MatchString(line):
+			flush()
+			text := mdQuote.ReplaceAllString(line, "")
+			fmt.Fprintf(&b, "%s▎[-] [::i]%s%s\n", s.muted, s.renderInlineIn(text, "[::i]"), mdResetStyle)
+		case mdBullet.MatchString(line):
+			flush()
+			m := mdBullet.FindStringSubmatch(line)
+			bullet, text := "•", m[2]
+			if t := mdTask.FindStringSubmatch(text); t != nil {
+				bullet, text = "☐", text[len(t[0]):]
+				if t[1] != " " {
+					bullet = "☑"
+				}
+			}
+			fmt.Fprintf(&b, "%s%s %s\n", listIndent(m[1]), bullet, s.renderInline(text))
+		case mdOrdered.MatchString(line):
+			flush()
+			m := mdOrdered.FindStringSubmatch(line)
+			fmt.Fprintf(&b, "%s%s %s\n", listIndent(m[1]), m[2], s.renderInline(m[3]))
+		case strings.HasPrefix(trimmed, "|"):
+			// Tables keep their layout, line by line
+			flush()
+			if mdTableRule.MatchString(line) {
+				b.WriteString(s.muted + tview.Escape(line) + "[-]\n")
+			} else {
+				b.WriteString(s.renderInline(line) + "\n")
+			}
+		case strings.HasPrefix(line, "    ") && len(para) == 0:
+			// Indented code blocks
+			b.WriteString(s.code + tview.Escape(line) + "[-]\n")
+		case strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`):
+			// Hard line breaks
+			para = append(para, strings.TrimRight(strings.TrimSuffix(trimmed, `\`), " "))
+			flush()
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// renderHeading renders a heading of level 1 to 6
+func (s mdStyles) renderHeading(level int, text string) string {
+	style := mdHeadingStyle
+	switch level {
+	case 1:
+		style = s.heading1
+	case 2:
+		style = s.heading2
+	}
+	return style + s.renderInlineIn(text, style) + mdResetStyle + "\n"
+}
+
+// renderCodeBlock renders the code of a fenced block in the language lang,
+// highlighted in the chroma style when both are known
+func (s mdStyles) renderCodeBlock(code, lang, style string) string {
+	if code == "" {
+		return ""
+	}
+	if style != "" && lang != "" {
+		if lexer := lexers.Get(lang); lexer != nil {
+			if text, ok := highlightLexer(lexer, code, style); ok {
+				return strings.TrimRight(text, "\n") + "\n"
+			}
+		}
+	}
+	return s.code + tview.Escape(code) + "[-]\n"
+}
+
+// listIndent returns the indentation of a list item nested indent deep,
+// two spaces for each level
+func listIndent(indent string) string {
+	width := len(strings.ReplaceAll(indent, "\t", "    "))
+	return strings.Repeat("  ", width/2)
+}
+
+// renderInline renders the code spans, emphasis and links of a line of
+// Markdown
+func (s mdStyles) renderInline(text string) string {
+	return s.renderInlineIn(text, "")
+}
+
+// renderInlineIn renders the code spans, emphasis and links of a line of
+// Markdown shown in the style base, which spans and emphasis return to
+func (s mdStyles) renderInlineIn(text, base string) string {
+	var b, plain strings.Builder
+	// Text is escaped as a whole, as brackets apart escape differently
+	tag := func(t string) {
+		b.WriteString(tview.Escape(plain.String()))
+		plain.Reset()
+		b.WriteString(t)
+	}
+	var bold, italic, strike bool
+	restore := func() {
+		attrs := ""
+		if bold {
+			attrs += "b"
+		}
+		if italic {
+			attrs += "i"
+		}
+		if strike {
+			attrs += "s"
+		}
+		tag(mdResetStyle + base)
+		if attrs != "" {
+			tag("[::" + attrs + "]")
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch c := rest[0]; {
+		case c == '\\' && len(rest) > 1 && strings.IndexByte("\\`*_[]()#+-.!|~<>", rest[1]) >= 0:
+			plain.WriteByte(rest[1])
+			i += 2
+		case c == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+			if end < 0 {
+				plain.WriteString(rest[:ticks])
+				i += ticks
+				continue
+			}
+			tag(s.code)
+			plain.WriteString(strings.TrimSpace(rest[ticks : ticks+end]))
+			restore()
+			i += 2*ticks + end
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if !bold && !strings.Contains(rest[2:], rest[:2]) {
+				plain.WriteString(rest[:2])
+				i += 2
+				continue
+			}
+			bold = !bold
+			restore()
+			i += 2
+		case strings.HasPrefix(rest, "~~"):
+			if !strike && !strings.Contains(rest[2:], "~~") {
+				plain.WriteString("~~")
+				i += 2
+				continue
+			}
+			strike = !strike
+			restore()
+			i += 2
+		case c == '*' || c == '_':
+			// Underscores inside words, as in snake_case, aren't emphasis
+			inWord := c == '_' && i > 0 && isWordByte(text[i-1]) && len(rest) > 1 && isWordByte(rest[1])
+			if inWord || !italic && !strings.Contains(rest[1:], rest[:1]) {
+				plain.WriteByte(c)
+				i++
+				continue
+			}
+			italic = !italic
+			restore()
+			i++
+		case c == '[' || strings.HasPrefix(rest, "!["):
+			m := mdLink.FindStringSubmatch(rest)
+			if m == nil {
+				plain.WriteByte(c)
+				i++
+				continue
+			}
+			label := m[1]
+			if c == '!' {
+				label = "image: " + label
+			}
+			tag("[::u]" + s.renderInlineIn(label, "[::u]"))
+			restore()
+			if m[2] != "" && m[2] != m[1] {
+				tag(" " + s.muted)
+				plain.WriteString("(" + m[2] + ")")
+				restore()
+			}
+			i += len(m[0])
+		default:
+			plain.WriteByte(c)
+			i++
+		}
+	}
+	if bold || italic || strike {
+		bold, italic, strike = false, false, false
+		restore()
+	}
+	tag("")
+	return b.String()
+}
+
+// isWordByte reports whether c is an ASCII letter or digit
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// toggleMarkdownSource switches the preview of Markdown files between the
+// rendered text and the source
+func (ui *FileExplorerUI) toggleMarkdownSource() {
+	ui.markdownSource = !ui.markdownSource
+	if path, ok := ui.pane.selectedPath(); ok {
+		ui.previewFile(path)
+	}
+	if ui.markdownSource {
+		ui.setFooterStatus("Markdown source on")
+	} else {
+		ui.setFooterStatus("Markdown source off")
+	}
+}
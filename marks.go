@@ -0,0 +1,115 @@
+package ui
+
+import "path/filepath"
+
+// rowPath returns the full path listed in row, or false for the header,
+// the ".." row and placeholders
+func (p *Pane) rowPath(row int) (string, bool) {
+	if row < 1 || row >= p.table.GetRowCount() {
+		return "", false
+	}
+	name := p.rowName(row)
+	if name == ".." || p.table.GetCell(row, 0).NotSelectable {
+		return "", false
+	}
+	return filepath.Join(p.path, name), true
+}
+
+// rowName returns the name listed in row. Entries keep their name as the
+// reference of the name cell, whose text is escaped and may be highlighted;
+// the ".." row and placeholders only have text.
+func (p *Pane) rowName(row int) string {
+	cell := p.table.GetCell(row, 0)
+	if name, ok := cell.GetReference().(string); ok {
+		return name
+	}
+	return cell.Text
+}
+
+// paintRow highlights row if its entry is marked
+func (p *Pane) paintRow(row int) {
+	path, ok := p.rowPath(row)
+	if !ok {
+		return
+	}
+	bg := p.ui.theme.Background
+	if p.marked[path] {
+		bg = p.ui.theme.Marked
+	}
+	for col := 0; col < p.table.GetColumnCount(); col++ {
+		if cell := p.table.GetCell(row, col); cell != nil {
+			cell.SetBackgroundColor(bg)
+		}
+	}
+}
+
+// toggleMark marks or unmarks the selected entry and moves to the next row
+func (ui *FileExplorerUI) toggleMark() {
+	row, _ := ui.pane.table.GetSelection()
+	path, ok := ui.pane.rowPath(row)
+	if !ok {
+		return
+	}
+	if ui.pane.marked[path] {
+		delete(ui.pane.marked, path)
+	} else {
+		ui.pane.marked[path] = true
+	}
+	ui.pane.paintRow(row)
+	if row+1 < ui.pane.table.GetRowCount() {
+		ui.pane.table.Select(row+1, 0)
+	}
+	ui.showMarkCount()
+}
+
+// markAll marks every listed entry, or inverts the marks when invert is set
+func (ui *FileExplorerUI) markAll(invert bool) {
+	for row := 1; row < ui.pane.table.GetRowCount(); row++ {
+		path, ok := ui.pane.rowPath(row)
+		if !ok {
+			continue
+		}
+		if invert && ui.pane.marked[path] {
+			delete(ui.pane.marked, path)
+		} else {
+			ui.pane.marked[path] = true
+		}
+		ui.pane.paintRow(row)
+	}
+	ui.showMarkCount()
+}
+
+// clearMarks unmarks everything
+func (p *Pane) clearMarks() {
+	clear(p.marked)
+	for row := 1; row < p.table.GetRowCount(); row++ {
+		p.paintRow(row)
+	}
+	if p == p.ui.pane {
+		p.ui.selectionChanged()
+	}
+}
+
+// showMarkCount reports the number of marked entries in the footer, and the
+// new selection to the functions registered with OnSelectionChanged
+func (ui *FileExplorerUI) showMarkCount() {
+	ui.setFooterStatus(plural(int64(len(ui.SelectedPaths())), "entry", "entries") + " selected")
+	ui.selectionChanged()
+}
+
+// SelectedPaths returns the marked entries in listing order. Without marks
+// it returns the entry under the cursor, if any.
+func (ui *FileExplorerUI) SelectedPaths() []string {
+	var paths []string
+	for row := 1; row < ui.pane.table.GetRowCount(); row++ {
+		if path, ok := ui.pane.rowPath(row); ok && ui.pane.marked[path] {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		if path, ok := ui.pane.selectedEntry(); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// mountsPage is the name of the mount picker
+const mountsPage = "mounts"
+
+// describeSpace renders the free space of the filesystem holding dir, or
+// nothing if it can't be told
+func describeSpace(dir string) string {
+	free, total, err := diskSpace(dir)
+	if err != nil || total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s free of %s (%d%% used)", formatSize(int64(free)),
+		formatSize(int64(total)), (total-free)*100/total)
+}
+
+// showMounts opens a picker of the mounted filesystems, or the drives on
+// Windows, with their free space. Picking one jumps to its root, going
+// back to the local disk when browsing another file system.
+func (ui *FileExplorerUI) showMounts() {
+	mounts := listMounts()
+	if len(mounts) == 0 {
+		ui.setFooterStatus("No mounted filesystems found")
+		return
+	}
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle("Mounts")
+	for _, mount := range mounts {
+		list.AddItem(mount, describeSpace(mount), 0, nil)
+	}
+	if ui.local {
+		list.SetCurrentItem(mountIndex(mounts, ui.pane.path))
+	}
+
+	list.SetSelectedFunc(func(_ int, path, _ string, _ rune) {
+		ui.closePage(mountsPage)
+		if !ui.local {
+			ui.switchSource(localSource, vfs.OS(), true, path)
+			return
+		}
+		ui.navigate(path)
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(mountsPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			ui.closePage(mountsPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(mountsPage, centered(list, 60, min(2*len(mounts)+2, 24)))
+}
+
+// mountIndex returns the index of the mount holding path: the longest
+// mount point it is below
+func mountIndex(mounts []string, path string) int {
+	best, length := 0, -1
+	for i, mount := range mounts {
+		rel, err := filepath.Rel(mount, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(mount) > length {
+			best, length = i, len(mount)
+		}
+	}
+	return best
+}
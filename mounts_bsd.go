@@ -0,0 +1,43 @@
+//go:build darwin || freebsd || dragonfly
+
+package ui
+
+import (
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// pseudoFilesystems are kernel filesystems that aren't worth browsing to
+var pseudoFilesystems = map[string]bool{
+	"devfs": true, "autofs": true, "fdescfs": true, "procfs": true,
+	"linprocfs": true, "linsysfs": true,
+}
+
+// listMounts returns the mounted filesystems reported by getfsstat,
+// leaving out pseudo filesystems and the system volumes of macOS
+func listMounts() []string {
+	n, err := unix.Getfsstat(nil, unix.MNT_NOWAIT)
+	if err != nil || n == 0 {
+		return nil
+	}
+	stats := make([]unix.Statfs_t, n)
+	n, err = unix.Getfsstat(stats, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil
+	}
+
+	var mounts []string
+	seen := map[string]bool{}
+	for _, st := range stats[:n] {
+		dir := unix.ByteSliceToString(st.Mntonname[:])
+		fstype := unix.ByteSliceToString(st.Fstypename[:])
+		if pseudoFilesystems[fstype] || seen[dir] ||
+			strings.HasPrefix(dir, "/System/Volumes/") || strings.HasPrefix(dir, "/dev/") {
+			continue
+		}
+		seen[dir] = true
+		mounts = append(mounts, dir)
+	}
+	return mounts
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package ui
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// pseudoFilesystems are kernel filesystems that aren't worth browsing to
+var pseudoFilesystems = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true,
+	"cgroup": true, "cgroup2": true, "securityfs": true, "pstore": true,
+	"bpf": true, "debugfs": true, "tracefs": true, "mqueue": true,
+	"hugetlbfs": true, "configfs": true, "fusectl": true, "autofs": true,
+	"binfmt_misc": true, "efivarfs": true, "rpc_pipefs": true, "nsfs": true,
+}
+
+// listMounts returns the mount points listed in /proc/mounts, leaving out
+// pseudo filesystems and system directories
+func listMounts() []string {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var mounts []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || pseudoFilesystems[fields[2]] {
+			continue
+		}
+		dir := unescapeMount(fields[1])
+		if isSystemMount(dir) || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		mounts = append(mounts, dir)
+	}
+	return mounts
+}
+
+// isSystemMount reports whether dir belongs to the system rather than to
+// a disk the user would browse, e.g. /proc or /run/user/1000
+func isSystemMount(dir string) bool {
+	for _, prefix := range []string{"/proc", "/sys", "/dev", "/run"} {
+		if dir == prefix || strings.HasPrefix(dir, prefix+"/") {
+			// Removable media is mounted below /run/media
+			return !strings.HasPrefix(dir, "/run/media/")
+		}
+	}
+	return false
+}
+
+// unescapeMount decodes the octal escapes (\040 for space) used in /proc/mounts
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
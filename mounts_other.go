@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly
+
+package ui
+
+// listMounts returns the drive roots on Windows and nil on platforms
+// without mount enumeration
+func listMounts() []string {
+	return listDrives()
+}
@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/rivo/tview"
+)
+
+// MIME types of the OOXML formats we can preview
+const (
+	docxType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	xlsxType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	pptxType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+)
+
+// officeTypes maps the MIME types of the OOXML formats we can preview to a
+// description
+var officeTypes = map[string]string{
+	docxType: "Word document",
+	xlsxType: "Excel workbook",
+	pptxType: "PowerPoint presentation",
+}
+
+// isOfficeDocument reports whether files of the MIME type t are OOXML
+// documents
+func isOfficeDocument(t string) bool {
+	_, ok := officeTypes[t]
+	return ok
+}
+
+// officeLimit is the size above which documents on file systems whose
+// files can't be read at offsets aren't previewed, as they are read into
+// memory whole
+const officeLimit = 32 * 1024 * 1024
+
+// officePreview renders the metadata and plain text of the OOXML document
+// at path in fsys, of the MIME type t. At most limit bytes of text are
+// extracted so large documents stay cheap. Colors come from theme.
+func officePreview(fsys vfs.FS, path, t string, limit int, theme Theme) (string, error) {
+	f, err := core.Open(fsys, path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	// The zip directory is at the end, so read there directly if possible
+	ra, ok := f.(io.ReaderAt)
+	size := info.Size()
+	if !ok {
+		if size > officeLimit {
+			return "", fmt.Errorf("%s is too large to read whole", filepath.Base(path))
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		parts[f.Name] = f
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s[::b]%s[-::-]\n", colorTag(theme.Title), officeTypes[t])
+	writeOfficeMetadata(&b, parts, theme)
+	b.WriteString("\n")
+
+	text := &limitedBuilder{limit: limit}
+	switch t {
+	case docxType:
+		err = extractXMLText(parts["word/document.xml"], text)
+	case xlsxType:
+		err = extractWorkbookText(parts, text)
+	case pptxType:
+		err = extractSlidesText(parts, text)
+	}
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return "", err
+	}
+
+	b.WriteString(tview.Escape(text.String()))
+	if text.truncated {
+		b.WriteString("\n" + colorTag(theme.Muted) + "(preview truncated)")
+	}
+	return b.String(), nil
+}
+
+// writeOfficeMetadata prints the document properties from docProps/core.xml
+// and docProps/app.xml, skipping any that are missing, labeled in the
+// theme's text color
+func writeOfficeMetadata(b *strings.Builder, parts map[string]*zip.File, theme Theme) {
+	fields := []struct{ part, element, label string }{
+		{"docProps/core.xml", "title", "Title"},
+		{"docProps/core.xml", "subject", "Subject"},
+		{"docProps/core.xml", "creator", "Author"},
+		{"docProps/core.xml", "lastModifiedBy", "Last modified by"},
+		{"docProps/core.xml", "created", "Created"},
+		{"docProps/core.xml", "modified", "Modified"},
+		{"docProps/app.xml", "Application", "Application"},
+		{"docProps/app.xml", "Pages", "Pages"},
+		{"docProps/app.xml", "Words", "Words"},
+		{"docProps/app.xml", "Slides", "Slides"},
+	}
+
+	props := map[string]map[string]string{}
+	for _, f := range fields {
+		if _, ok := props[f.part]; !ok {
+			props[f.part] = readXMLProperties(parts[f.part])
+		}
+		if value := props[f.part][f.element]; value != "" {
+			fmt.Fprintf(b, "%s[::b]%s:[-::-] %s\n", colorTag(theme.Text), f.label, tview.Escape(value))
+		}
+	}
+}
+
+// readXMLProperties collects the text of the top-level child elements of a
+// flat properties document, keyed by local element name
+func readXMLProperties(f *zip.File) map[string]string {
+	props := map[string]string{}
+	if f == nil {
+		return props
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return props
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(io.LimitReader(rc, 64*1024))
+	depth := 0
+	var current string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return props
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				current = t.Name.Local
+			}
+		case xml.EndElement:
+			depth--
+			current = ""
+		case xml.CharData:
+			if current != "" {
+				props[current] += strings.TrimSpace(string(t))
+			}
+		}
+	}
+}
+
+// extractWorkbookText lists the sheet names and the shared strings of a workbook
+func extractWorkbookText(parts map[string]*zip.File, out *limitedBuilder) error {
+	sheets := readAttributeValues(parts["xl/workbook.xml"], "sheet", "name")
+	if len(sheets) > 0 {
+		out.WriteString("Sheets: " + strings.Join(sheets, ", ") + "\n\n")
+	}
+	return extractXMLText(parts["xl/sharedStrings.xml"], out)
+}
+
+// extractSlidesText extracts the text of each slide in order
+func extractSlidesText(parts map[string]*zip.File, out *limitedBuilder) error {
+	var slides []int
+	for name := range parts {
+		rest, ok := strings.CutPrefix(name, "ppt/slides/slide")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(rest, ".xml")); err == nil {
+			slides = append(slides, n)
+		}
+	}
+	sort.Ints(slides)
+
+	for _, n := range slides {
+		if err := out.WriteString(fmt.Sprintf("--- Slide %d ---\n", n)); err != nil {
+			return err
+		}
+		if err := extractXMLText(parts[fmt.Sprintf("ppt/slides/slide%d.xml", n)], out); err != nil {
+			return err
+		}
+		if err := out.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAttributeValues returns the value of attr on every element named elem
+func readAttributeValues(f *zip.File, elem, attr string) []string {
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	var values []string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return values
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == elem {
+			for _, a := range start.Attr {
+				if a.Name.Local == attr {
+					values = append(values, a.Value)
+				}
+			}
+		}
+	}
+}
+
+// extractXMLText streams the text runs (<w:t>, <a:t>, <t>) of an XML part into
+// out, breaking lines at paragraph and shared-string boundaries
+func extractXMLText(f *zip.File, out *limitedBuilder) error {
+	if f == nil {
+		return fmt.Errorf("document has no main content part")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				err = out.WriteString("\t")
+			case "br":
+				err = out.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "si":
+				err = out.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				err = out.WriteString(string(t))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// errLimitReached stops extraction once the preview limit is hit
+var errLimitReached = errors.New("preview limit reached")
+
+// limitedBuilder is a strings.Builder that refuses to grow past limit bytes
+type limitedBuilder struct {
+	strings.Builder
+	limit     int
+	truncated bool
+}
+
+// WriteString appends s, returning errLimitReached once the limit is exceeded
+func (l *limitedBuilder) WriteString(s string) error {
+	if room := l.limit - l.Len(); len(s) > room {
+		room = max(room, 0)
+		for room > 0 && !utf8.RuneStart(s[room]) {
+			room--
+		}
+		l.Builder.WriteString(s[:room])
+		l.truncated = true
+		return errLimitReached
+	}
+	l.Builder.WriteString(s)
+	return nil
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/rivo/tview"
+)
+
+// isTextFile reports whether the file at path in fsys is text, judging by
+// its MIME type
+func isTextFile(fsys vfs.FS, path string) bool {
+	head, err := core.ReadHead(fsys, path, core.SniffSize)
+	return err == nil && core.IsTextType(core.DetectType(filepath.Base(path), head))
+}
+
+// openerCommand returns the command opening path outside the explorer: the
+// configured opener or else the system's
+func (ui *FileExplorerUI) openerCommand(path string) *exec.Cmd {
+	if ui.config.Opener != "" {
+		return shellWithArgs(ui.config.Opener, path)
+	}
+	return systemOpener(path)
+}
+
+// openExternal opens the selected file with the configured or system opener.
+// The opener is expected to hand the file to a separate window, so the
+// explorer keeps running rather than waiting for it.
+func (ui *FileExplorerUI) openExternal() {
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	if inArchive(ui.fsys, filepath.Dir(path)) {
+		ui.showError(fmt.Errorf("%s: files in archives can't be opened outside the explorer", filepath.Base(path)))
+		return
+	}
+	if ui.refuseNonLocal() {
+		return
+	}
+	cmd := ui.openerCommand(path)
+	if err := cmd.Start(); err != nil {
+		ui.showError(fmt.Errorf("open %s: %w", filepath.Base(path), err))
+		return
+	}
+	// Reap the opener once it exits
+	go cmd.Wait()
+	ui.setFooterStatus(tview.Escape("Opened " + filepath.Base(path)))
+	ui.fileOpened(path)
+}
+
+// openWith asks for a command and runs it on the selected entries in the
+// terminal, suspending the explorer until it exits
+func (ui *FileExplorerUI) openWith() {
+	if ui.refuseInArchive() || ui.refuseNonLocal() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+	ui.prompt("Open with", "", func(command string) {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			return
+		}
+		ui.runSuspended(command, shellWithArgs(command, paths...))
+		for _, path := range paths {
+			ui.fileOpened(path)
+		}
+	})
+}
+
+// editor returns the configured editor, falling back to $VISUAL, $EDITOR
+// and the platform's default
+func (ui *FileExplorerUI) editor() string {
+	for _, editor := range []string{ui.config.Editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editSelected opens the selected file in the editor, suspending the
+// explorer until the editor exits
+func (ui *FileExplorerUI) editSelected() {
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+	if ui.refuseInArchive() || ui.refuseNonLocal() {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+	if info.IsDir() {
+		ui.setFooterError(tview.Escape(filepath.Base(path) + " is a directory"))
+		return
+	}
+	editor := ui.editor()
+	ui.runSuspended(editor, shellWithArgs(editor, path))
+	ui.fileOpened(path)
+}
+
+// runSuspended runs cmd attached to the terminal while the explorer is
+// suspended, then refreshes the listings and the preview, which the command
+// may have changed. name describes the command in errors.
+func (ui *FileExplorerUI) runSuspended(name string, cmd *exec.Cmd) {
+	cmd.Dir = ui.pane.path
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	var err error
+	if !ui.host.Suspend(func() {
+		err = cmd.Run()
+	}) {
+		err = errors.New("cannot suspend the screen")
+	}
+	// The terminal was handed over, taking the image of the preview with it
+	ui.shownGraphic = placement{}
+	ui.autoRefresh()
+	if path, ok := ui.pane.selectedPath(); ok {
+		ui.previewFile(path)
+	}
+	if err != nil {
+		ui.showError(fmt.Errorf("%s: %w", name, err))
+	}
+}
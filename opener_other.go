@@ -0,0 +1,35 @@
+//go:build !windows
+
+package ui
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// systemOpener returns the command opening path with the application the
+// desktop associates with it
+func systemOpener(path string) *exec.Cmd {
+	if runtime.GOOS == "darwin" {
+		return exec.Command("open", path)
+	}
+	return exec.Command("xdg-open", path)
+}
+
+// shellWithArgs returns a command running the command line in the shell
+// with args appended, each passed as a single word
+func shellWithArgs(command string, args ...string) *exec.Cmd {
+	return exec.Command("/bin/sh", append([]string{"-c", command + ` "$@"`, "sh"}, args...)...)
+}
+
+// shellCommand returns a command running the command line in the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// shellQuote quotes s as a single word for the shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
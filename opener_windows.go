@@ -0,0 +1,36 @@
+//go:build windows
+
+package ui
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// systemOpener returns the command opening path with the application the
+// desktop associates with it
+func systemOpener(path string) *exec.Cmd {
+	// The empty argument is the window title start expects before a quoted path
+	return exec.Command("cmd", "/C", "start", "", path)
+}
+
+// shellWithArgs returns a command running the command line in the shell
+// with args appended, each passed as a single word
+func shellWithArgs(command string, args ...string) *exec.Cmd {
+	line := command
+	for _, arg := range args {
+		line += " " + shellQuote(arg)
+	}
+	return exec.Command("cmd", "/C", line)
+}
+
+// shellCommand returns a command running the command line in the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
+
+// shellQuote quotes s as a single word for the shell
+func shellQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,201 @@
+package ops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrFormat is returned when an archive is to be created in a format that
+// can't be written
+var ErrFormat = errors.New("archives can only be created as .zip, .tar, .tar.gz or .tgz")
+
+// archiveWriter adds entries to an archive being written
+type archiveWriter interface {
+	// add writes an entry named name; r is nil for anything but regular files
+	add(name string, info fs.FileInfo, link string, r io.Reader) error
+	Close() error
+}
+
+// CanCompress reports whether Compress can create an archive named name
+func CanCompress(name string) bool {
+	_, err := newArchiveWriter(name, io.Discard)
+	return err == nil
+}
+
+// newArchiveWriter returns a writer of the format given by the extension
+// of name
+func newArchiveWriter(name string, w io.Writer) (archiveWriter, error) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return zipWriter{zip.NewWriter(w)}, nil
+	case strings.HasSuffix(name, ".tar"):
+		return &tarWriter{tw: tar.NewWriter(w)}, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz := gzip.NewWriter(w)
+		return &tarWriter{tw: tar.NewWriter(gz), gz: gz}, nil
+	}
+	return nil, ErrFormat
+}
+
+// compress packs srcs, recursing into directories, into the archive dst,
+// which must not exist. Entries are named relative to the directories of
+// srcs. Symlinks are stored as links and other irregular files skipped. On
+// failure or cancellation the partial archive is removed.
+func compress(ctx context.Context, srcs []string, dst string, copied, total *atomic.Int64) (err error) {
+	var sum int64
+	for _, src := range srcs {
+		if err := checkTarget(src, dst); err != nil {
+			return err
+		}
+		n, err := size(ctx, src)
+		if err != nil {
+			return err
+		}
+		sum += n
+	}
+	total.Store(sum)
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+
+	aw, err := newArchiveWriter(dst, out)
+	if err != nil {
+		return err
+	}
+	for _, src := range srcs {
+		if err := addTree(ctx, aw, src, copied); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// addTree writes src and everything below it to aw
+func addTree(ctx context.Context, aw archiveWriter, src string, copied *atomic.Int64) error {
+	base := filepath.Dir(src)
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			return aw.add(name, info, "", nil)
+		case mode&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return aw.add(name, info, link, nil)
+		case mode.IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return aw.add(name, info, "", &progressReader{ctx: ctx, r: f, n: copied})
+		}
+		return nil
+	})
+}
+
+// zipWriter writes zip archives
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (z zipWriter) add(name string, info fs.FileInfo, link string, r io.Reader) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	} else {
+		hdr.Method = zip.Deflate
+	}
+	w, err := z.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case link != "":
+		// Zip stores the target of a symlink as its contents
+		_, err = io.WriteString(w, link)
+	case r != nil:
+		_, err = io.Copy(w, r)
+	}
+	return err
+}
+
+func (z zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// tarWriter writes tar archives, gzip-compressed if gz is set
+type tarWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (t *tarWriter) add(name string, info fs.FileInfo, link string, r io.Reader) error {
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if r != nil {
+		_, err = io.Copy(t.tw, r)
+	}
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.gz != nil {
+		return t.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/aktagon/gofiles/archive"
+)
+
+// extract unpacks the archive src into the directory dst, which is created
+// if needed. Existing files are replaced only if overwrite is set, while
+// existing directories are merged into. Members other than directories and
+// regular files, like symlinks, are skipped. If dst had to be created, it
+// is removed again on failure or cancellation.
+func extract(ctx context.Context, src, dst string, overwrite bool, copied, total *atomic.Int64) (err error) {
+	fsys, err := archive.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	size, err := archiveSize(ctx, fsys)
+	if err != nil {
+		return err
+	}
+	total.Store(size)
+
+	if _, statErr := os.Stat(dst); os.IsNotExist(statErr) {
+		defer func() {
+			if err != nil {
+				os.RemoveAll(dst)
+			}
+		}()
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	return archive.Walk(fsys, func(name string, info fs.FileInfo, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case info.Mode().IsRegular():
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			return extractFile(ctx, r, target, flags, info.Mode().Perm(), copied)
+		}
+		return nil
+	})
+}
+
+// extractFile writes the member read from r to dst, opened with flags
+func extractFile(ctx context.Context, r io.Reader, dst string, flags int, perm fs.FileMode, copied *atomic.Int64) (err error) {
+	// Archives made on some systems record no permissions at all
+	if perm == 0 {
+		perm = 0o644
+	}
+	out, err := os.OpenFile(dst, flags, perm)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s: %w", dst, ErrExists)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := io.Copy(out, &progressReader{ctx: ctx, r: r, n: copied}); err != nil {
+		return err
+	}
+	return out.Chmod(perm)
+}
+
+// archiveSize totals the sizes of the regular files in an archive
+func archiveSize(ctx context.Context, fsys fs.FS) (int64, error) {
+	var total int64
+	err := fs.WalkDir(fsys, ".", func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,152 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aktagon/gofiles/trash"
+)
+
+// ErrChanged is returned when undoing the creation of an entry that has
+// been written to since
+var ErrChanged = errors.New("changed since it was created")
+
+// Op is the kind of an operation recorded in a History
+type Op int
+
+const (
+	// OpRename renamed Src to Dst in the same directory
+	OpRename Op = iota
+	// OpMove moved Src to Dst
+	OpMove
+	// OpTrash moved Src to the trash, where it is kept at Dst
+	OpTrash
+	// OpCreate created the empty file or directory Dst
+	OpCreate
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpRename:
+		return "rename"
+	case OpMove:
+		return "move"
+	case OpTrash:
+		return "trash"
+	case OpCreate:
+		return "create"
+	}
+	return fmt.Sprintf("Op(%d)", int(o))
+}
+
+// Entry is an operation that has been performed
+type Entry struct {
+	Op   Op
+	Src  string // path of the entry before the operation; unused by OpCreate
+	Dst  string // path of the entry after it
+	Time time.Time
+}
+
+// History is a log of the operations performed, most recent last, which
+// can be undone one at a time. It is safe for concurrent use; a nil
+// History records nothing.
+type History struct {
+	mu      sync.Mutex
+	entries []Entry
+	limit   int
+}
+
+// NewHistory creates a history keeping the last limit operations
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Add records an operation, dropping the oldest one beyond the limit
+func (h *History) Add(e Entry) {
+	if h == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, e)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+}
+
+// Entries returns the recorded operations, oldest first
+func (h *History) Entries() []Entry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Entry(nil), h.entries...)
+}
+
+// Last returns the most recent operation, if any
+func (h *History) Last() (Entry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) == 0 {
+		return Entry{}, false
+	}
+	return h.entries[len(h.entries)-1], true
+}
+
+// Undo reverses the most recent operation and returns it. Undoing is
+// refused where it would overwrite something, and a created entry is only
+// removed while it is still empty. The operation is dropped from the
+// history even if it can't be undone, as what it recorded no longer holds.
+func (h *History) Undo(ctx context.Context) (Entry, error) {
+	h.mu.Lock()
+	if len(h.entries) == 0 {
+		h.mu.Unlock()
+		return Entry{}, errors.New("nothing to undo")
+	}
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	h.mu.Unlock()
+
+	return e, undo(ctx, e)
+}
+
+// undo reverses e
+func undo(ctx context.Context, e Entry) error {
+	var copied atomic.Int64
+	switch e.Op {
+	case OpRename, OpMove:
+		return move(ctx, e.Dst, e.Src, &copied)
+	case OpTrash:
+		if _, err := os.Stat(filepath.Dir(e.Src)); err != nil {
+			return err
+		}
+		if err := move(ctx, e.Dst, e.Src, &copied); err != nil {
+			return err
+		}
+		item := trash.Item{Name: filepath.Base(e.Dst), Root: filepath.Dir(filepath.Dir(e.Dst))}
+		return os.Remove(item.InfoPath())
+	case OpCreate:
+		info, err := os.Lstat(e.Dst)
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && info.Size() > 0 {
+			return fmt.Errorf("%s: %w", e.Dst, ErrChanged)
+		}
+		err = os.Remove(e.Dst)
+		if err != nil && info.IsDir() {
+			// The directory isn't empty any more
+			return fmt.Errorf("%s: %w", e.Dst, ErrChanged)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown operation %s", e.Op)
+}
@@ -0,0 +1,320 @@
+// Package ops performs file operations - copies, moves, deletions, moves
+// to the trash and the extraction and creation of archives - in the
+// background, one at a time, reporting the progress of long copies.
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrExists is returned when the destination of a copy or move is taken
+	ErrExists = errors.New("destination already exists")
+	// ErrIntoItself is returned when a directory would be copied or moved into itself
+	ErrIntoItself = errors.New("cannot copy or move a directory into itself")
+	// ErrReplaceParent is returned when a copy or move would replace a
+	// directory holding its source
+	ErrReplaceParent = errors.New("cannot replace a directory holding the source")
+)
+
+// Kind is the type of a file operation
+type Kind int
+
+const (
+	// Copy copies Src recursively to Dst
+	Copy Kind = iota
+	// Move renames Src to Dst, copying across filesystems
+	Move
+	// Delete removes Src recursively
+	Delete
+	// Extract unpacks the archive Src into the directory Dst
+	Extract
+	// Compress packs Srcs into the new zip or tar archive Dst
+	Compress
+	// Trash moves Src to the user's trash
+	Trash
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Copy:
+		return "copy"
+	case Move:
+		return "move"
+	case Delete:
+		return "delete"
+	case Extract:
+		return "extract"
+	case Compress:
+		return "compress"
+	case Trash:
+		return "trash"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Job is a single file operation
+type Job struct {
+	Kind Kind
+	Src  string
+	Dst  string // full path of the copy or moved entry, or where to extract; unused by Delete and Trash
+	// Overwrite lets Extract replace existing files, and Copy and Move
+	// replace Dst
+	Overwrite bool
+	// Srcs are the entries Compress packs, instead of Src
+	Srcs []string
+}
+
+// Equal reports whether j and other are the same operation
+func (j Job) Equal(other Job) bool {
+	return j.Kind == other.Kind && j.Src == other.Src && j.Dst == other.Dst &&
+		j.Overwrite == other.Overwrite && slices.Equal(j.Srcs, other.Srcs)
+}
+
+// run performs job, adding the bytes copied so far to copied. Jobs that
+// only learn how much they copy once started store it in total. Moves,
+// including those to the trash, are recorded in history.
+func run(ctx context.Context, job Job, copied, total *atomic.Int64, history *History) error {
+	switch job.Kind {
+	case Copy:
+		if job.Overwrite {
+			return replace(job.Src, job.Dst, func(tmp string) error {
+				return copyTree(ctx, job.Src, tmp, copied)
+			})
+		}
+		return copyTree(ctx, job.Src, job.Dst, copied)
+	case Move:
+		var err error
+		if job.Overwrite {
+			err = replace(job.Src, job.Dst, func(tmp string) error {
+				return move(ctx, job.Src, tmp, copied)
+			})
+		} else {
+			err = move(ctx, job.Src, job.Dst, copied)
+		}
+		if err == nil {
+			history.Add(Entry{Op: OpMove, Src: job.Src, Dst: job.Dst})
+		}
+		return err
+	case Delete:
+		return os.RemoveAll(job.Src)
+	case Extract:
+		return extract(ctx, job.Src, job.Dst, job.Overwrite, copied, total)
+	case Compress:
+		return compress(ctx, job.Srcs, job.Dst, copied, total)
+	case Trash:
+		dst, err := moveToTrash(ctx, job.Src, copied)
+		if err == nil {
+			history.Add(Entry{Op: OpTrash, Src: job.Src, Dst: dst})
+		}
+		return err
+	}
+	return fmt.Errorf("unknown operation %s", job.Kind)
+}
+
+// size totals the bytes of the regular files below path
+func size(ctx context.Context, path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// checkTarget refuses to overwrite dst or to put src inside itself
+func checkTarget(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s: %w", dst, ErrExists)
+	}
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if absDst == absSrc || strings.HasPrefix(absDst, absSrc+string(filepath.Separator)) {
+		return ErrIntoItself
+	}
+	return nil
+}
+
+// replace puts src in place of the existing entry dst. create copies or
+// moves src to a temporary name next to dst, which is only removed once
+// that has succeeded.
+func replace(src, dst string, create func(tmp string) error) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if absSrc == absDst {
+		return ErrIntoItself
+	}
+	if strings.HasPrefix(absSrc, absDst+string(filepath.Separator)) {
+		return ErrReplaceParent
+	}
+
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".replacing")
+	if err := create(tmp); err != nil {
+		return err
+	}
+	err = os.RemoveAll(dst)
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; %s is left at %s", err, filepath.Base(src), tmp)
+	}
+	return nil
+}
+
+// move renames src to dst. Across filesystems, where renaming is impossible,
+// src is copied and then removed.
+func move(ctx context.Context, src, dst string, copied *atomic.Int64) error {
+	if err := checkTarget(src, dst); err != nil {
+		return err
+	}
+	err := os.Rename(src, dst)
+	if err == nil || !crossDevice(err) {
+		return err
+	}
+	if err := copyTree(ctx, src, dst, copied); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies src to dst, which must not exist, recursing into
+// directories and recreating symlinks. Permission bits are preserved. On
+// failure or cancellation the partial copy is removed.
+func copyTree(ctx context.Context, src, dst string, copied *atomic.Int64) (err error) {
+	if err := checkTarget(src, dst); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dst)
+		}
+	}()
+
+	// Directories are created writable so they can be filled, and get
+	// their real mode once their contents are in place
+	type dirMode struct {
+		path string
+		mode fs.FileMode
+	}
+	var dirs []dirMode
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			dirs = append(dirs, dirMode{target, mode.Perm()})
+			return os.Mkdir(target, 0o700)
+		case mode&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case mode.IsRegular():
+			return copyFile(ctx, path, target, mode.Perm(), copied)
+		default:
+			return fmt.Errorf("%s: cannot copy irregular file", path)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the regular file src to the new file dst with mode perm
+func copyFile(ctx context.Context, src, dst string, perm fs.FileMode, copied *atomic.Int64) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := io.Copy(out, &progressReader{ctx: ctx, r: in, n: copied}); err != nil {
+		return err
+	}
+	// OpenFile is subject to the umask; apply the source mode exactly
+	return out.Chmod(perm)
+}
+
+// progressReader counts the bytes read through it and fails once ctx is
+// cancelled, so large copies can be aborted midway
+type progressReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   *atomic.Int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.n.Add(int64(n))
+	return n, err
+}
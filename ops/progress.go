@@ -0,0 +1,104 @@
+package ops
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Counter counts what an operation has processed, usually bytes, out of a
+// total that may only be learned as it goes. It is safe for concurrent use.
+type Counter struct {
+	done  atomic.Int64
+	total atomic.Int64
+	start time.Time
+}
+
+// NewCounter starts counting an operation of total units, 0 if unknown
+func NewCounter(total int64) *Counter {
+	c := &Counter{start: time.Now()}
+	c.total.Store(total)
+	return c
+}
+
+// Add counts n more units as done
+func (c *Counter) Add(n int64) {
+	c.done.Add(n)
+}
+
+// SetTotal sets the units the operation processes in all
+func (c *Counter) SetTotal(n int64) {
+	c.total.Store(n)
+}
+
+// Stats returns a snapshot of the counter. A total that turned out too
+// low is raised to what has been done.
+func (c *Counter) Stats() Stats {
+	done, total := c.done.Load(), c.total.Load()
+	if total > 0 {
+		total = max(total, done)
+	}
+	return Stats{Done: done, Total: total, Elapsed: time.Since(c.start)}
+}
+
+// Watch sends a snapshot of the counter on the returned channel every
+// interval until ctx is done, then closes it. The channel holds one
+// snapshot, which a newer one replaces if it hasn't been received, so a
+// slow receiver sees the latest progress rather than falling behind.
+func (c *Counter) Watch(ctx context.Context, interval time.Duration) <-chan Stats {
+	ch := make(chan Stats, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sendLatest(ch, c.Stats())
+			}
+		}
+	}()
+	return ch
+}
+
+// sendLatest puts v in the buffered channel ch, replacing the value
+// waiting there if the receiver hasn't taken it. ch must have a single
+// sender.
+func sendLatest[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- v
+	}
+}
+
+// Stats is a snapshot of the progress of an operation
+type Stats struct {
+	Done    int64 // units processed so far
+	Total   int64 // units to process; 0 if unknown
+	Elapsed time.Duration
+}
+
+// Rate returns the units processed per second so far
+func (s Stats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Done) / s.Elapsed.Seconds()
+}
+
+// Remaining estimates the time left at the rate so far, reporting false
+// while there's too little to go on
+func (s Stats) Remaining() (time.Duration, bool) {
+	rate := s.Rate()
+	if s.Total == 0 || rate == 0 || s.Elapsed < time.Second {
+		return 0, false
+	}
+	return time.Duration(float64(s.Total-s.Done) / rate * float64(time.Second)), true
+}
@@ -0,0 +1,175 @@
+package ops
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often a running job reports its progress
+const progressInterval = 200 * time.Millisecond
+
+// Progress is a snapshot of the running job. Its Stats count bytes copied;
+// the total is 0 for deletions and same-filesystem moves.
+type Progress struct {
+	Job Job
+	Stats
+	Pending int // jobs waiting behind this one
+}
+
+// Queue runs jobs one after another in the background. onDone is called
+// from the queue's goroutine.
+type Queue struct {
+	ctx      context.Context
+	progress chan Progress
+	onDone   func(Job, error)
+	history  *History
+
+	mu      sync.Mutex
+	pending []Job
+	running bool
+	current Job                // the running job
+	cancel  context.CancelFunc // cancels the running job
+	wg      sync.WaitGroup
+}
+
+// NewQueue creates a queue whose jobs stop when ctx is cancelled. onDone
+// is called once a job has finished, with the error that stopped it, if
+// any. Completed moves are recorded in history, which may be nil.
+func NewQueue(ctx context.Context, history *History, onDone func(Job, error)) *Queue {
+	return &Queue{ctx: ctx, history: history, progress: make(chan Progress, 1), onDone: onDone}
+}
+
+// Progress returns the channel on which the running job reports its
+// progress periodically. Like Counter.Watch it holds the latest report
+// only; it is never closed.
+func (q *Queue) Progress() <-chan Progress {
+	return q.progress
+}
+
+// Add appends jobs to the queue, starting the worker if it is idle
+func (q *Queue) Add(jobs ...Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(q.pending, jobs...)
+	if !q.running && len(q.pending) > 0 {
+		q.running = true
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Len returns the number of jobs queued or running
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := len(q.pending)
+	if q.running {
+		n++
+	}
+	return n
+}
+
+// Running returns the job being run, reporting false if there is none.
+// Progress received for any other job is stale.
+func (q *Queue) Running() (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.current, q.cancel != nil
+}
+
+// Cancel drops the queued jobs and aborts the running one
+func (q *Queue) Cancel() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = nil
+	if q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// CancelJob drops job if it is queued, or aborts it if it is running.
+// Dropped jobs aren't reported to onDone.
+func (q *Queue) CancelJob(job Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = slices.DeleteFunc(q.pending, job.Equal)
+	if q.running && q.current.Equal(job) && q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// Wait blocks until the queue is idle
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// work runs the queued jobs until there are none left
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		job := q.pending[0]
+		q.pending = q.pending[1:]
+		ctx, cancel := context.WithCancel(q.ctx)
+		q.current = job
+		q.cancel = cancel
+		q.mu.Unlock()
+
+		err := q.run(ctx, job)
+		cancel()
+		q.mu.Lock()
+		q.cancel = nil
+		q.mu.Unlock()
+		q.onDone(job, err)
+	}
+}
+
+// run performs job while reporting its progress
+func (q *Queue) run(ctx context.Context, job Job) error {
+	counter := NewCounter(0)
+	if job.Kind == Copy {
+		n, err := size(ctx, job.Src)
+		if err != nil {
+			return err
+		}
+		counter.SetTotal(n)
+	}
+
+	// The reports are stopped before returning so no progress is reported
+	// after the job is done
+	watchCtx, stop := context.WithCancel(ctx)
+	stats := counter.Watch(watchCtx, progressInterval)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for s := range stats {
+			q.mu.Lock()
+			pending := len(q.pending)
+			q.mu.Unlock()
+			sendLatest(q.progress, Progress{Job: job, Stats: s, Pending: pending})
+		}
+	}()
+	defer func() {
+		stop()
+		<-stopped
+		// Drop a report not received yet
+		select {
+		case <-q.progress:
+		default:
+		}
+	}()
+
+	return run(ctx, job, &counter.done, &counter.total, q.history)
+}
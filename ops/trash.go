@@ -0,0 +1,32 @@
+package ops
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+
+	"github.com/aktagon/gofiles/trash"
+)
+
+// moveToTrash moves src into the home trash, copying it there from other
+// filesystems, and returns where it is kept
+func moveToTrash(ctx context.Context, src string, copied *atomic.Int64) (string, error) {
+	item, err := trash.Reserve(src)
+	if err != nil {
+		return "", err
+	}
+	if err := move(ctx, src, item.FilePath(), copied); err != nil {
+		os.Remove(item.InfoPath())
+		return "", err
+	}
+	return item.FilePath(), nil
+}
+
+// Untrash moves src, kept in a trash, back out to dst, which must not
+// exist. Like moveToTrash it copies across filesystems, so items brought
+// into the home trash from other filesystems can be restored; pass it to
+// trash.Restore.
+func Untrash(ctx context.Context, src, dst string) error {
+	var copied atomic.Int64
+	return move(ctx, src, dst, &copied)
+}
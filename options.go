@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"maps"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// Option configures a file explorer UI created with NewFileExplorerUI
+type Option func(*options)
+
+// options are the settings a file explorer UI is created with
+type options struct {
+	config       Config
+	configPath   string
+	startPath    string
+	theme        string
+	keymap       Keymap
+	previewLimit int64
+	fsys         vfs.FS
+	picker       *Picker
+}
+
+// defaultOptions returns the settings of a file explorer UI created
+// without options
+func defaultOptions() options {
+	return options{
+		config:       DefaultConfig(),
+		configPath:   DefaultConfigPath(),
+		previewLimit: defaultPreviewLimit,
+	}
+}
+
+// WithConfig sets the config to start with, used as is; start from
+// DefaultConfig or LoadConfig. Without it the defaults are used.
+func WithConfig(cfg Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithConfigPath sets the file Ctrl-R reloads the config from, by default
+// DefaultConfigPath. It doesn't read the file; see LoadConfig.
+func WithConfigPath(path string) Option {
+	return func(o *options) {
+		o.configPath = path
+	}
+}
+
+// WithStartPath sets the directory to start in, or a file to select in its
+// directory, instead of the working directory
+func WithStartPath(path string) Option {
+	return func(o *options) {
+		o.startPath = path
+	}
+}
+
+// WithTheme sets the theme, a built-in theme's name or the path of a YAML
+// theme file, instead of the configured one
+func WithTheme(name string) Option {
+	return func(o *options) {
+		o.theme = name
+	}
+}
+
+// WithKeymap replaces the key bindings of the config, like SetKeymap.
+// Reloading the config restores the configured ones.
+func WithKeymap(k Keymap) Option {
+	return func(o *options) {
+		o.keymap = maps.Clone(k)
+	}
+}
+
+// WithPreviewLimit sets the size of the largest file read whole for the
+// preview; larger text files are previewed in parts as they are scrolled
+// through
+func WithPreviewLimit(bytes int64) Option {
+	return func(o *options) {
+		o.previewLimit = bytes
+	}
+}
+
+// WithFS browses fsys, e.g. vfs.ReadOnly of an embed.FS, instead of the
+// local disk, starting in its root unless WithStartPath says otherwise.
+// Paths are those of the local disk: "/docs/a.txt" is "docs/a.txt" in
+// fsys. Copies download to a local directory and deletions are permanent;
+// moves, archives, undo, git and running other programs are only possible
+// on the local disk. The sources picker (alt-m) switches to the local disk
+// and Docker containers and back; leave it unbound with WithKeymap to keep
+// to fsys.
+func WithFS(fsys vfs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
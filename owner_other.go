@@ -0,0 +1,22 @@
+//go:build !unix
+
+package ui
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// ownershipSupported tells whether entries have an owner and group that
+// can be shown and changed
+const ownershipSupported = false
+
+// fileOwner reports that ownership isn't known on this platform
+func fileOwner(fs.FileInfo) (owner, group string, ok bool) {
+	return "", "", false
+}
+
+// chown fails, as ownership can't be changed on this platform
+func chown(path, owner, group string) error {
+	return errors.ErrUnsupported
+}
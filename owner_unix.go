@@ -0,0 +1,85 @@
+//go:build unix
+
+package ui
+
+import (
+	"io/fs"
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// ownershipSupported tells whether entries have an owner and group that
+// can be shown and changed
+const ownershipSupported = true
+
+// userNames and groupNames cache the names of the ids seen in listings
+var userNames, groupNames sync.Map
+
+// fileOwner returns the names of the owner and group of an entry, or their
+// ids where they have no name
+func fileOwner(info fs.FileInfo) (owner, group string, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+	owner = lookupName(&userNames, uint64(st.Uid), func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+	group = lookupName(&groupNames, uint64(st.Gid), func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+	return owner, group, true
+}
+
+// lookupName resolves id to a name through lookup once, remembering it in cache
+func lookupName(cache *sync.Map, id uint64, lookup func(id string) (string, error)) string {
+	if name, ok := cache.Load(id); ok {
+		return name.(string)
+	}
+	name := strconv.FormatUint(id, 10)
+	if found, err := lookup(name); err == nil {
+		name = found
+	}
+	cache.Store(id, name)
+	return name
+}
+
+// chown gives path the named owner and group, either of which may also be
+// a numeric id; "" leaves it unchanged. Symlinks are followed, as by chmod.
+func chown(path, owner, group string) error {
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := strconv.Atoi(owner)
+		if err != nil {
+			u, err := user.Lookup(owner)
+			if err != nil {
+				return err
+			}
+			id, _ = strconv.Atoi(u.Uid)
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := strconv.Atoi(group)
+		if err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return err
+			}
+			id, _ = strconv.Atoi(g.Gid)
+		}
+		gid = id
+	}
+	return os.Chown(path, uid, gid)
+}
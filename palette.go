@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// palettePage is the name of the command palette
+const palettePage = "palette"
+
+// command is an entry of the command palette: an action, or something
+// else the explorer can do that has no action of its own
+type command struct {
+	title    string
+	category string
+	action   Action // "" for commands run by run
+	run      func()
+}
+
+// commands lists what the command palette offers: every action, and
+// sorting by each key, which only has an action for cycling through them
+func (ui *FileExplorerUI) commands() []command {
+	var cmds []command
+	for _, category := range ui.categories() {
+		for _, info := range category.actions {
+			if info.action == ActionPalette {
+				continue
+			}
+			cmds = append(cmds, command{title: info.title, category: category.name, action: info.action})
+		}
+	}
+	for _, key := range sortKeys {
+		cmds = append(cmds, command{
+			title:    "Sort by " + string(key),
+			category: "View",
+			run:      func() { ui.sortBy(key) },
+		})
+	}
+	return cmds
+}
+
+// rankCommands returns the commands whose title or category matches query,
+// best first, keeping the listed order among equally good matches
+func rankCommands(cmds []command, query string) []command {
+	type ranked struct {
+		command
+		score int
+	}
+	var matches []ranked
+	for _, c := range cmds {
+		score, ok := fuzzyMatch(query, c.title)
+		if !ok {
+			if score, ok = fuzzyMatch(query, c.category+" "+c.title); !ok {
+				continue
+			}
+		}
+		matches = append(matches, ranked{c, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]command, len(matches))
+	for i, m := range matches {
+		result[i] = m.command
+	}
+	return result
+}
+
+// commandKeys renders the keys bound to the action of c, "" if none
+func (ui *FileExplorerUI) commandKeys(c command) string {
+	if c.action == "" {
+		return ""
+	}
+	var keys []string
+	for _, key := range ui.actionKeys(c.action) {
+		keys = append(keys, displayKey(key))
+	}
+	if len(keys) == 0 {
+		return builtinKeys[c.action]
+	}
+	return strings.Join(keys, ", ")
+}
+
+// showPalette opens the command palette: typing narrows everything the
+// explorer can do by fuzzy matching the names, and Enter does the selected
+// one, so features can be found without knowing their keys
+func (ui *FileExplorerUI) showPalette() {
+	const titleWidth = 44
+
+	cmds := ui.commands()
+	input := tview.NewInputField()
+	input.SetLabel("> ")
+	input.SetFieldBackgroundColor(ui.theme.Input)
+	list := tview.NewList()
+	list.ShowSecondaryText(false)
+
+	var shown []command
+	update := func() {
+		shown = rankCommands(cmds, input.GetText())
+		list.Clear()
+		for _, c := range shown {
+			padding := strings.Repeat(" ", max(titleWidth-len([]rune(c.title)), 1))
+			text := fmt.Sprintf("%s%s%s%-24s[-]%s%s[-]", tview.Escape(c.title), padding,
+				colorTag(ui.theme.Muted), tview.Escape(c.category), colorTag(ui.theme.FooterKey), tview.Escape(ui.commandKeys(c)))
+			list.AddItem(text, "", 0, nil)
+		}
+	}
+	update()
+
+	input.SetChangedFunc(func(string) {
+		update()
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			list.InputHandler()(event, nil)
+			return nil
+		case tcell.KeyEnter:
+			if len(shown) == 0 {
+				return nil
+			}
+			c := shown[list.GetCurrentItem()]
+			ui.closePage(palettePage)
+			if c.run != nil {
+				c.run()
+			} else {
+				ui.runAction(c.action)
+			}
+			return nil
+		case tcell.KeyEscape:
+			ui.closePage(palettePage)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+	flex.SetBorder(true)
+	flex.SetTitle(fmt.Sprintf("Commands - %s Run | %s Close", ui.titleKey("Enter"), ui.titleKey("Esc")))
+	ui.showPage(palettePage, centered(flex, 90, 24))
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Pane is a directory listing with its own current directory, filter and
+// marks. The explorer has two of them; the second one is shown in the
+// dual-pane layout, where copies and moves target the other pane.
+type Pane struct {
+	ui    *FileExplorerUI
+	table *tview.Table
+	path  string // directory shown
+
+	filter   string // only names containing this are listed
+	armedDir string // directory awaiting a second activation in DirOpenConfirm mode
+
+	listingPath    string             // directory the listing below belongs to
+	listing        []fs.DirEntry      // entries of the loaded directory, before filtering
+	listingEntries []dirEntry         // the same with their file info, for relist
+	load           *dirLoad           // listing in progress, if any
+	loadCancel     context.CancelFunc // aborts the directory load in progress
+	sizeCancel     context.CancelFunc // stops totalling the listed directories
+	pathList       []string           // paths listed instead of a directory, see ShowPaths
+	marked         map[string]bool    // full paths of the marked entries
+	gitRepo        bool               // whether the listing is in a git work tree, with a Git column
+	history        history            // directories visited in the active tab
+
+	tabs []*tab // browsing locations; the active one is tabs[tab]
+	tab  int
+}
+
+// newPane creates a pane showing path
+func newPane(ui *FileExplorerUI, path string) *Pane {
+	p := &Pane{
+		ui:     ui,
+		table:  tview.NewTable(),
+		path:   path,
+		marked: map[string]bool{},
+		tabs:   []*tab{{}},
+	}
+
+	p.table.SetBorder(true)
+	p.table.SetTitle("Directory Contents")
+	p.table.SetBorderColor(ui.theme.PaneBorder)
+	p.table.SetSelectable(true, false)
+	p.table.SetFixed(1, 0)
+	p.table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.Selection).Foreground(ui.theme.SelectionText))
+
+	// Setup column headers
+	p.setHeaderRow()
+	return p
+}
+
+// Path returns the directory shown in the pane, or "" for a path list
+func (p *Pane) Path() string {
+	return p.path
+}
+
+// ActivePane returns the pane that has the focus
+func (ui *FileExplorerUI) ActivePane() *Pane {
+	return ui.pane
+}
+
+// otherPane returns the pane without the focus
+func (ui *FileExplorerUI) otherPane() *Pane {
+	if ui.pane == ui.panes[0] {
+		return ui.panes[1]
+	}
+	return ui.panes[0]
+}
+
+// visiblePanes returns the panes on screen: both in the dual-pane layout,
+// otherwise the active one
+func (ui *FileExplorerUI) visiblePanes() []*Pane {
+	if ui.dual {
+		return ui.panes[:]
+	}
+	return []*Pane{ui.pane}
+}
+
+// activatePane makes p the pane that commands act on, showing its
+// directory in the header and its selection in the preview
+func (ui *FileExplorerUI) activatePane(p *Pane) {
+	if ui.pane == p {
+		return
+	}
+	ui.pane = p
+	if ui.compact {
+		// The compact layout only has room for the active pane
+		ui.arrangeGrid()
+	} else {
+		ui.paintPaneBorders()
+	}
+
+	ui.setHeader(p)
+	ui.syncTree()
+	if path, ok := p.selectedPath(); ok {
+		ui.previewFile(path)
+	}
+}
+
+// switchPane moves the focus to the other pane in the dual-pane layout
+func (ui *FileExplorerUI) switchPane() {
+	if !ui.dual {
+		return
+	}
+	ui.compactPreview = false
+	ui.setFocus(ui.otherPane().table)
+}
+
+// toggleDual switches between the listing with a preview and two listings
+// side by side. The second pane isn't refreshed while hidden, so it is
+// reloaded whenever it is shown.
+func (ui *FileExplorerUI) toggleDual() {
+	ui.dual = !ui.dual
+	if !ui.dual && ui.pane != ui.panes[0] {
+		ui.activatePane(ui.panes[0])
+	}
+	if other := ui.otherPane(); ui.dual && other.load == nil {
+		other.reload()
+	}
+	ui.arrangeGrid()
+	ui.setFocus(ui.pane.table)
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// pathEntry is a path list entry. Its name is the path as given, so the
+// listing shows and opens it relative to the working directory.
+type pathEntry struct {
+	fs.DirEntry
+	path string
+}
+
+func (e pathEntry) Name() string { return e.path }
+
+// ReadPaths reads a newline-delimited list of paths, such as the output of
+// find, skipping blank lines
+func ReadPaths(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// ShowPaths replaces the directory listing with the given paths, which can
+// be previewed and opened like directory entries. Going up leaves the list
+// for the working directory.
+func (ui *FileExplorerUI) ShowPaths(paths []string) {
+	if paths == nil {
+		paths = []string{}
+	}
+	ui.pane.pathList = paths
+	ui.pane.path = ""
+	ui.pane.loadDirectory(ui.pane.path)
+}
+
+// inPathList reports whether the listing shows a path list rather than a directory
+func (p *Pane) inPathList() bool {
+	return p.pathList != nil
+}
+
+// pathListTitle describes the path list in the header and footer
+func (p *Pane) pathListTitle() string {
+	return fmt.Sprintf("path list (%d)", len(p.pathList))
+}
+
+// streamPathList stats the entries of a path list in fsys in batches,
+// handing each to emit. Paths that no longer exist are left out.
+func streamPathList(ctx context.Context, fsys vfs.FS, paths []string, emit func([]fs.DirEntry)) error {
+	var batch []fs.DirEntry
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := core.Lstat(fsys, path)
+		if err != nil {
+			continue
+		}
+		batch = append(batch, pathEntry{DirEntry: fs.FileInfoToDirEntry(info), path: path})
+		if len(batch) == core.BatchSize {
+			emit(batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		emit(batch)
+	}
+	return nil
+}
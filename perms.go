@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/rivo/tview"
+)
+
+// chmodPage is the name of the permissions dialog
+const chmodPage = "chmod"
+
+var (
+	modeColumn = column{"Mode", "", func(e dirEntry) string {
+		return fmt.Sprintf("%04o", unixMode(e.Info.Mode()))
+	}}
+	ownerColumn = column{"Owner", "", func(e dirEntry) string {
+		owner, _, _ := fileOwner(e.Info)
+		return owner
+	}}
+	groupColumn = column{"Group", "", func(e dirEntry) string {
+		_, group, _ := fileOwner(e.Info)
+		return group
+	}}
+)
+
+// ownerColumns returns the Owner and Group columns where entries have them
+func ownerColumns() []column {
+	if !ownershipSupported {
+		return nil
+	}
+	return []column{ownerColumn, groupColumn}
+}
+
+// unixMode returns the permission and special bits of mode as chmod
+// numbers them
+func unixMode(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+// fileMode is the inverse of unixMode
+func fileMode(bits uint32) fs.FileMode {
+	mode := fs.FileMode(bits & 0o777)
+	if bits&0o4000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// parseMode applies a chmod mode to the mode of an entry: either octal,
+// like 755 or 2775, or symbolic clauses like u+x,go-w or a=rX separated by
+// commas. Clauses without u, g, o or a apply to everyone.
+func parseMode(spec string, mode fs.FileMode) (fs.FileMode, error) {
+	spec = strings.TrimSpace(spec)
+	if bits, err := strconv.ParseUint(spec, 8, 32); err == nil && len(spec) <= 4 {
+		return fileMode(uint32(bits)), nil
+	}
+
+	invalid := fmt.Errorf("invalid mode %q", spec)
+	cur := unixMode(mode)
+	for _, clause := range strings.Split(spec, ",") {
+		// Bits the clause's users cover, including their special bit
+		var who uint32
+		i := 0
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			switch clause[i] {
+			case 'u':
+				who |= 0o4700
+			case 'g':
+				who |= 0o2070
+			case 'o':
+				who |= 0o1007
+			case 'a':
+				who |= 0o7777
+			}
+		}
+		if who == 0 {
+			who = 0o7777
+		}
+		if i == len(clause) {
+			return mode, invalid
+		}
+
+		for i < len(clause) {
+			op := clause[i]
+			if strings.IndexByte("+-=", op) < 0 {
+				return mode, invalid
+			}
+			var bits uint32
+			for i++; i < len(clause) && strings.IndexByte("+-=", clause[i]) < 0; i++ {
+				switch clause[i] {
+				case 'r':
+					bits |= 0o444
+				case 'w':
+					bits |= 0o222
+				case 'x':
+					bits |= 0o111
+				case 'X':
+					// Execute only for directories and what's executable already
+					if mode.IsDir() || cur&0o111 != 0 {
+						bits |= 0o111
+					}
+				case 's':
+					bits |= 0o6000
+				case 't':
+					bits |= 0o1000
+				default:
+					return mode, invalid
+				}
+			}
+			bits &= who
+			switch op {
+			case '+':
+				cur |= bits
+			case '-':
+				cur &^= bits
+			case '=':
+				cur = cur&^who | bits
+			}
+		}
+	}
+	return fileMode(cur), nil
+}
+
+// chmodSelected opens a dialog changing the mode and, where supported, the
+// owner and group of the selected entries. Fields left empty are not
+// changed.
+func (ui *FileExplorerUI) chmodSelected() {
+	if ui.refuseInArchive() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+
+	// A single entry's current values are filled in; those of several
+	// entries are only changed where given
+	var mode, owner, group string
+	title := fmt.Sprintf("%d entries", len(paths))
+	if len(paths) == 1 {
+		title = filepath.Base(paths[0])
+		if info, err := core.Stat(ui.fsys, paths[0]); err == nil {
+			mode = fmt.Sprintf("%04o", unixMode(info.Mode()))
+			owner, group, _ = fileOwner(info)
+		}
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("Mode", mode, 20, nil, nil)
+	if ownershipSupported {
+		form.AddInputField("Owner", owner, 20, nil, nil)
+		form.AddInputField("Group", group, 20, nil, nil)
+	}
+	field := func(label string) string {
+		if item, ok := form.GetFormItemByLabel(label).(*tview.InputField); ok {
+			return strings.TrimSpace(item.GetText())
+		}
+		return ""
+	}
+	form.AddButton("Apply", func() {
+		mode, owner, group := field("Mode"), field("Owner"), field("Group")
+		ui.closePage(chmodPage)
+		ui.applyChmod(paths, mode, owner, group)
+	})
+	form.AddButton("Cancel", func() {
+		ui.closePage(chmodPage)
+	})
+	form.SetCancelFunc(func() {
+		ui.closePage(chmodPage)
+	})
+	form.SetBorder(true)
+	form.SetTitle(" Permissions of " + tview.Escape(title) + " (755, u+x,go-w) ")
+
+	height := 7
+	if ownershipSupported {
+		height += 4
+	}
+	ui.showPage(chmodPage, centered(form, 60, height))
+}
+
+// applyChmod changes the mode, owner and group of paths, skipping those
+// left empty, and reports what failed
+func (ui *FileExplorerUI) applyChmod(paths []string, mode, owner, group string) {
+	var errs []error
+	changed := 0
+	for _, path := range paths {
+		err := chmodEntry(ui.fsys, path, mode, owner, group)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		changed++
+	}
+	ui.pane.clearMarks()
+	ui.autoRefresh()
+	if err := errors.Join(errs...); err != nil {
+		ui.showError(err)
+		return
+	}
+	ui.setFooterStatus(fmt.Sprintf("Changed %d entries", changed))
+}
+
+// chmodEntry applies a mode, owner and group to path in fsys; empty ones,
+// and an owner and group it already has, are left alone. Owners can only be
+// changed on the local disk.
+func chmodEntry(fsys vfs.FS, path, mode, owner, group string) error {
+	info, err := core.Stat(fsys, path)
+	if err != nil {
+		return err
+	}
+	if mode != "" {
+		newMode, err := parseMode(mode, info.Mode())
+		if err != nil {
+			return err
+		}
+		if err := fsys.Chmod(vfs.Name(path), newMode); err != nil {
+			return err
+		}
+	}
+	curOwner, curGroup, _ := fileOwner(info)
+	if owner == curOwner {
+		owner = ""
+	}
+	if group == curGroup {
+		group = ""
+	}
+	if owner != "" || group != "" {
+		if !vfs.IsLocal(fsys) {
+			return errNonLocal
+		}
+		return chown(path, owner, group)
+	}
+	return nil
+}
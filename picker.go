@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+)
+
+// Picker makes the explorer a chooser for other programs, such as shell
+// scripts: Enter on a file picks it and ends the explorer, and Alt-Enter
+// (the pick action) picks the selection whatever it is, directories
+// included. Picked returns what was picked.
+type Picker struct {
+	Multi    bool // pick the marked entries, if any, rather than only the one under the cursor
+	DirsOnly bool // list and pick only directories
+}
+
+// WithPicker runs the explorer as a chooser of files or directories
+func WithPicker(p Picker) Option {
+	return func(o *options) {
+		o.picker = &p
+	}
+}
+
+// Picked returns the paths picked once the explorer has ended, or nil if
+// it was quit without picking anything
+func (ui *FileExplorerUI) Picked() []string {
+	return slices.Clone(ui.picked)
+}
+
+// pickable reports whether e can be picked, and listed, in picker mode:
+// anything unless only directories are, including symlinks to them
+func (ui *FileExplorerUI) pickable(dir string, e dirEntry) bool {
+	if ui.picker == nil || !ui.picker.DirsOnly || e.IsDir() {
+		return true
+	}
+	return e.Info.Mode()&fs.ModeSymlink != 0 && isDir(ui.fsys, filepath.Join(dir, e.Name()))
+}
+
+// pickFile picks the file at path on Enter, or the marked entries when
+// several can be picked. Directories aren't picked on Enter, so they can
+// still be entered; it reports whether anything was picked.
+func (ui *FileExplorerUI) pickFile(path string, info fs.FileInfo) bool {
+	if ui.picker.Multi && len(ui.pane.marked) > 0 {
+		return ui.pick()
+	}
+	if info.IsDir() || ui.picker.DirsOnly {
+		return false
+	}
+	ui.finishPick([]string{path})
+	return true
+}
+
+// pick picks the selection: the marked entries when several can be
+// picked, or else the entry under the cursor. It reports false for
+// nothing to pick.
+func (ui *FileExplorerUI) pick() bool {
+	var paths []string
+	if ui.picker.Multi {
+		paths = ui.SelectedPaths()
+	} else if path, ok := ui.pane.selectedEntry(); ok {
+		paths = []string{path}
+	}
+	if ui.picker.DirsOnly {
+		paths = slices.DeleteFunc(paths, func(path string) bool {
+			return !isDir(ui.fsys, path)
+		})
+	}
+	if len(paths) == 0 {
+		ui.setFooterError("Nothing to pick here")
+		return false
+	}
+	ui.finishPick(paths)
+	return true
+}
+
+// finishPick ends the explorer with paths picked
+func (ui *FileExplorerUI) finishPick(paths []string) {
+	ui.picked = paths
+	ui.exit()
+}
+
+// pickerHint tells how to pick with the keys in effect, shown in the
+// footer on starting
+func (ui *FileExplorerUI) pickerHint() string {
+	key := func(action Action) string {
+		return ui.commandKeys(command{action: action})
+	}
+	switch p := ui.picker; {
+	case p.DirsOnly && p.Multi:
+		return fmt.Sprintf("Pick directories with %s, marking several with %s first", key(ActionPick), key(ActionMark))
+	case p.DirsOnly:
+		return fmt.Sprintf("Pick a directory with %s", key(ActionPick))
+	case p.Multi:
+		return fmt.Sprintf("Pick a file with %s, or mark several with %s first", key(ActionOpen), key(ActionMark))
+	}
+	return fmt.Sprintf("Pick a file with %s", key(ActionOpen))
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// placesPage is the name of the places menu
+const placesPage = "places"
+
+// userDirs are the standard directories below home offered as places
+var userDirs = []string{"Desktop", "Documents", "Downloads", "Music", "Pictures", "Videos"}
+
+// place is a named location in the places menu
+type place struct {
+	name string
+	path string
+}
+
+// listPlaces gathers the user's standard directories and the mounted
+// volumes. Mounts are read anew every time since they come and go.
+func listPlaces() []place {
+	var places []place
+	if home, err := os.UserHomeDir(); err == nil {
+		places = append(places, place{"Home", home})
+		for _, name := range userDirs {
+			dir := filepath.Join(home, name)
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				places = append(places, place{name, dir})
+			}
+		}
+	}
+	places = append(places, place{"Temporary files", os.TempDir()})
+
+	for _, mount := range listMounts() {
+		places = append(places, place{"Mount", mount})
+	}
+	return places
+}
+
+// showPlaces opens a menu of special locations to jump to
+func (ui *FileExplorerUI) showPlaces() {
+	if ui.refuseNonLocal() {
+		return
+	}
+	places := listPlaces()
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle("Places")
+	for _, p := range places {
+		list.AddItem(p.name, p.path, 0, nil)
+	}
+
+	list.SetSelectedFunc(func(index int, _, path string, _ rune) {
+		ui.closePage(placesPage)
+		ui.navigate(path)
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(placesPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			ui.closePage(placesPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(placesPage, centered(list, 60, min(2*len(places)+2, 24)))
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rivo/tview"
+)
+
+// DefaultPluginsDir returns the directory plugins are loaded from,
+// ~/.config/gofiles/plugins on Linux, or "" if there is no config directory
+func DefaultPluginsDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofiles", "plugins")
+}
+
+// pluginAction is an action a plugin adds
+type pluginAction struct {
+	action Action
+	title  string
+	run    func() error
+}
+
+// pluginPreviewer renders the preview of local files whose name matches
+// one of the patterns, taking the place of the built-in preview
+type pluginPreviewer struct {
+	patterns []string // filepath.Match patterns of base names
+	preview  func(path string) (string, error)
+}
+
+// pluginColumn is a listing column a plugin adds after the built-in ones
+type pluginColumn struct {
+	title string
+	text  func(path string, e dirEntry) (string, error)
+}
+
+// plugins holds what plugins have added to the explorer. Bindings of
+// plugin actions are kept apart from the keymap, which the config replaces
+// on reload; the keymap wins where both bind a key.
+type plugins struct {
+	actions    []pluginAction
+	keys       map[string]Action
+	previewers []pluginPreviewer
+	columns    []pluginColumn
+}
+
+// addAction registers a plugin action, which mustn't take the name of a
+// built-in action or another plugin's
+func (p *plugins) addAction(a pluginAction) error {
+	if a.action == "" {
+		return fmt.Errorf("action without a name")
+	}
+	if _, ok := p.action(a.action); ok || isAction(a.action) {
+		return fmt.Errorf("action %q already exists", a.action)
+	}
+	if a.title == "" {
+		a.title = string(a.action)
+	}
+	p.actions = append(p.actions, a)
+	return nil
+}
+
+// action returns the plugin action named name
+func (p *plugins) action(name Action) (pluginAction, bool) {
+	i := slices.IndexFunc(p.actions, func(a pluginAction) bool { return a.action == name })
+	if i < 0 {
+		return pluginAction{}, false
+	}
+	return p.actions[i], true
+}
+
+// bind binds key to the plugin action named name
+func (p *plugins) bind(key string, name Action) error {
+	key, err := parseKeyName(key)
+	if err != nil {
+		return err
+	}
+	if _, ok := p.action(name); !ok {
+		return fmt.Errorf("unknown action %q", name)
+	}
+	if p.keys == nil {
+		p.keys = map[string]Action{}
+	}
+	p.keys[key] = name
+	return nil
+}
+
+// keysOf returns the keys bound to the plugin action named name, sorted
+func (p *plugins) keysOf(name Action) []string {
+	var keys []string
+	for key, action := range p.keys {
+		if action == name {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// addPreviewer registers a plugin previewer
+func (p *plugins) addPreviewer(v pluginPreviewer) error {
+	if len(v.patterns) == 0 {
+		return fmt.Errorf("previewer without patterns")
+	}
+	for _, pattern := range v.patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("previewer pattern %q: %w", pattern, err)
+		}
+	}
+	p.previewers = append(p.previewers, v)
+	return nil
+}
+
+// previewer returns the first plugin previewer for path
+func (p *plugins) previewer(path string) (pluginPreviewer, bool) {
+	name := filepath.Base(path)
+	for _, v := range p.previewers {
+		for _, pattern := range v.patterns {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return v, true
+			}
+		}
+	}
+	return pluginPreviewer{}, false
+}
+
+// renderPluginPreview renders the preview of path with a plugin previewer.
+// stable reports whether the text can be cached, which it can unless the
+// previewer failed.
+func renderPluginPreview(v pluginPreviewer, path string) (text string, stable bool) {
+	text, err := v.preview(path)
+	if err != nil {
+		return fmt.Sprintf("Error: %s", tview.Escape(err.Error())), false
+	}
+	return text, true
+}
+
+// addColumn registers a plugin column
+func (p *plugins) addColumn(c pluginColumn) error {
+	if c.title == "" {
+		return fmt.Errorf("column without a title")
+	}
+	p.columns = append(p.columns, c)
+	return nil
+}
+
+// listingColumns returns the plugin columns of a listing of dir
+func (p *plugins) listingColumns(dir string) []column {
+	cols := make([]column, len(p.columns))
+	for i, c := range p.columns {
+		cols[i] = column{c.title, "", func(e dirEntry) string {
+			text, err := c.text(filepath.Join(dir, e.Name()), e)
+			if err != nil {
+				return "?"
+			}
+			return text
+		}}
+	}
+	return cols
+}
+
+// boundAction returns the action bound to key, in the keymap or by a
+// plugin
+func (ui *FileExplorerUI) boundAction(key string) (Action, bool) {
+	if action, ok := ui.keymap[key]; ok {
+		return action, true
+	}
+	action, ok := ui.plugins.keys[key]
+	return action, ok
+}
+
+// actionKeys returns the keys bound to action, in the keymap or by a
+// plugin
+func (ui *FileExplorerUI) actionKeys(action Action) []string {
+	if keys := ui.keymap.Keys(action); len(keys) > 0 {
+		return keys
+	}
+	var keys []string
+	for _, key := range ui.plugins.keysOf(action) {
+		if _, ok := ui.keymap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// runPluginAction runs the plugin action named name, reporting whether
+// there is one
+func (ui *FileExplorerUI) runPluginAction(name Action) bool {
+	a, ok := ui.plugins.action(name)
+	if !ok {
+		return false
+	}
+	if err := a.run(); err != nil {
+		ui.showError(fmt.Errorf("%s: %w", a.action, err))
+	}
+	return true
+}
+
+// pluginCategory lists the plugin actions for the help screen and the
+// command palette
+func (ui *FileExplorerUI) pluginCategory() (actionCategory, bool) {
+	if len(ui.plugins.actions) == 0 {
+		return actionCategory{}, false
+	}
+	category := actionCategory{name: "Plugins"}
+	for _, a := range ui.plugins.actions {
+		category.actions = append(category.actions, actionInfo{a.action, a.title})
+	}
+	return category, true
+}
+
+// loadPlugins loads the plugins in dir, reporting those that fail
+func (ui *FileExplorerUI) loadPlugins(dir string) {
+	if dir == "" {
+		return
+	}
+	if err := loadLuaPlugins(ui, dir); err != nil {
+		ui.showError(err)
+	}
+}
+
+// pluginFiles returns the Lua plugins in dir, in name order. A missing
+// directory has none.
+func pluginFiles(dir string) ([]string, error) {
+	return filepath.Glob(filepath.Join(dir, "*.lua"))
+}
@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/rivo/tview"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaHost runs the Lua plugins in a single interpreter. An interpreter
+// isn't safe for concurrent use and previews are rendered in the
+// background, so every call into it holds mu. Functions changing the UI
+// are deferred until the call returns, as they may call into it again.
+type luaHost struct {
+	ui *FileExplorerUI
+	mu sync.Mutex
+	L  *lua.LState
+}
+
+// loadLuaPlugins runs the Lua plugins in dir, which register what they add
+// through the gofiles module
+func loadLuaPlugins(ui *FileExplorerUI, dir string) error {
+	files, err := pluginFiles(dir)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	h := &luaHost{ui: ui, L: lua.NewState()}
+	h.L.SetGlobal("gofiles", h.module())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var errs []error
+	for _, file := range files {
+		if err := h.L.DoFile(file); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", filepath.Base(file), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// module builds the gofiles module, the API plugins are given
+func (h *luaHost) module() *lua.LTable {
+	return h.L.SetFuncs(h.L.NewTable(), map[string]lua.LGFunction{
+		"action":    h.action,
+		"bind":      h.bind,
+		"previewer": h.previewer,
+		"column":    h.column,
+		"dir":       h.dir,
+		"current":   h.current,
+		"selected":  h.selected,
+		"navigate":  h.navigate,
+		"refresh":   h.refresh,
+		"run":       h.run,
+		"status":    h.status,
+		"error":     h.error,
+	})
+}
+
+// call calls fn with args and returns its first result
+func (h *luaHost) call(fn *lua.LFunction, args ...lua.LValue) (lua.LValue, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args...); err != nil {
+		return lua.LNil, err
+	}
+	ret := h.L.Get(-1)
+	h.L.Pop(1)
+	return ret, nil
+}
+
+// later runs f on the UI goroutine once the current call into Lua returns
+func (h *luaHost) later(f func()) {
+	h.ui.afterUpdate(f)
+}
+
+// luaFunction returns the function in field name of spec, raising an error
+// if there is none
+func luaFunction(L *lua.LState, spec *lua.LTable, name string) *lua.LFunction {
+	fn, ok := spec.RawGetString(name).(*lua.LFunction)
+	if !ok {
+		L.RaiseError("%s must be a function", name)
+	}
+	return fn
+}
+
+// action registers an action:
+// gofiles.action{name = "...", title = "...", key = "...", run = function() end}.
+// The title and the key are optional.
+func (h *luaHost) action(L *lua.LState) int {
+	spec := L.CheckTable(1)
+	name := Action(lua.LVAsString(spec.RawGetString("name")))
+	run := luaFunction(L, spec, "run")
+	err := h.ui.plugins.addAction(pluginAction{
+		action: name,
+		title:  lua.LVAsString(spec.RawGetString("title")),
+		run: func() error {
+			_, err := h.call(run)
+			return err
+		},
+	})
+	if err != nil {
+		L.RaiseError("%s", err)
+	}
+	if key := lua.LVAsString(spec.RawGetString("key")); key != "" {
+		if err := h.ui.plugins.bind(key, name); err != nil {
+			L.RaiseError("%s", err)
+		}
+	}
+	return 0
+}
+
+// bind binds a key to a plugin action: gofiles.bind("alt-g", "name")
+func (h *luaHost) bind(L *lua.LState) int {
+	if err := h.ui.plugins.bind(L.CheckString(1), Action(L.CheckString(2))); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+// previewer registers a previewer for local files matching a pattern or a
+// list of them: gofiles.previewer{pattern = "*.csv", preview = function(path)
+// return text end}. The text may contain color tags.
+func (h *luaHost) previewer(L *lua.LState) int {
+	spec := L.CheckTable(1)
+	var patterns []string
+	switch v := spec.RawGetString("pattern").(type) {
+	case lua.LString:
+		patterns = []string{string(v)}
+	case *lua.LTable:
+		v.ForEach(func(_, pattern lua.LValue) {
+			patterns = append(patterns, lua.LVAsString(pattern))
+		})
+	}
+	preview := luaFunction(L, spec, "preview")
+	err := h.ui.plugins.addPreviewer(pluginPreviewer{
+		patterns: patterns,
+		preview: func(path string) (string, error) {
+			text, err := h.call(preview, lua.LString(path))
+			return lua.LVAsString(text), err
+		},
+	})
+	if err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+// column registers a listing column: gofiles.column{title = "...",
+// value = function(entry) return text end}. The entry has the fields name,
+// path, dir, size, mode and modified, in seconds since the epoch.
+func (h *luaHost) column(L *lua.LState) int {
+	spec := L.CheckTable(1)
+	value := luaFunction(L, spec, "value")
+	err := h.ui.plugins.addColumn(pluginColumn{
+		title: lua.LVAsString(spec.RawGetString("title")),
+		text: func(path string, e dirEntry) (string, error) {
+			entry := h.L.NewTable()
+			entry.RawSetString("name", lua.LString(e.Name()))
+			entry.RawSetString("path", lua.LString(path))
+			entry.RawSetString("dir", lua.LBool(e.IsDir()))
+			entry.RawSetString("size", lua.LNumber(e.Size()))
+			entry.RawSetString("mode", lua.LString(e.Info.Mode().String()))
+			entry.RawSetString("modified", lua.LNumber(e.Info.ModTime().Unix()))
+			text, err := h.call(value, entry)
+			return lua.LVAsString(text), err
+		},
+	})
+	if err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+// dir returns the directory of the active pane: gofiles.dir()
+func (h *luaHost) dir(L *lua.LState) int {
+	L.Push(lua.LString(h.ui.pane.path))
+	return 1
+}
+
+// current returns the path of the entry under the cursor, or nil:
+// gofiles.current()
+func (h *luaHost) current(L *lua.LState) int {
+	path, ok := h.ui.pane.selectedPath()
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(lua.LString(path))
+	return 1
+}
+
+// selected returns the paths of the marked entries, or else of the one
+// under the cursor: gofiles.selected()
+func (h *luaHost) selected(L *lua.LState) int {
+	paths := L.NewTable()
+	for _, path := range h.ui.SelectedPaths() {
+		paths.Append(lua.LString(path))
+	}
+	L.Push(paths)
+	return 1
+}
+
+// navigate goes to a directory: gofiles.navigate(path)
+func (h *luaHost) navigate(L *lua.LState) int {
+	path := L.CheckString(1)
+	h.later(func() { h.ui.navigate(path) })
+	return 0
+}
+
+// refresh lists the directories again: gofiles.refresh()
+func (h *luaHost) refresh(L *lua.LState) int {
+	h.later(h.ui.autoRefresh)
+	return 0
+}
+
+// run runs a command line attached to the terminal, with any further
+// arguments passed to it as "$@": gofiles.run("less", path)
+func (h *luaHost) run(L *lua.LState) int {
+	command := L.CheckString(1)
+	var args []string
+	for i := 2; i <= L.GetTop(); i++ {
+		args = append(args, L.CheckString(i))
+	}
+	h.later(func() { h.ui.runSuspended(command, shellWithArgs(command, args...)) })
+	return 0
+}
+
+// status shows a message in the footer: gofiles.status(text)
+func (h *luaHost) status(L *lua.LState) int {
+	text := L.CheckString(1)
+	h.later(func() { h.ui.setFooterStatus(tview.Escape(text)) })
+	return 0
+}
+
+// error reports an error: gofiles.error(text)
+func (h *luaHost) error(L *lua.LState) int {
+	text := L.CheckString(1)
+	h.later(func() { h.ui.showError(errors.New(text)) })
+	return 0
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// previewCacheSize bounds the number of cached previews. Each is at most
+// preview limit long, so the cache stays within a few megabytes.
+const previewCacheSize = 32
+
+// previewKey identifies a version of a file and how it is previewed; a
+// changed mtime or size invalidates its cached preview
+type previewKey struct {
+	path    string
+	modTime time.Time
+	size    int64
+	mode    previewMode
+}
+
+// previewCache keeps the most recently rendered file previews. It is safe
+// for use by the preview goroutines.
+type previewCache struct {
+	mu      sync.Mutex
+	order   *list.List // of previewKey, most recently used first
+	entries map[string]*list.Element
+	texts   map[previewKey]renderedPreview
+}
+
+func newPreviewCache() *previewCache {
+	return &previewCache{
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		texts:   map[previewKey]renderedPreview{},
+	}
+}
+
+// get returns the cached preview for key, if the file hasn't changed since
+func (c *previewCache) get(key previewKey) (renderedPreview, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	text, ok := c.texts[key]
+	if ok {
+		c.order.MoveToFront(c.entries[key.path])
+	}
+	return text, ok
+}
+
+// put caches r as the preview of key, replacing older versions of the
+// same file and evicting the least recently used file when full
+func (c *previewCache) put(key previewKey, r renderedPreview) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key.path]; ok {
+		c.remove(e)
+	}
+	c.entries[key.path] = c.order.PushFront(key)
+	c.texts[key] = r
+
+	if c.order.Len() > previewCacheSize {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *previewCache) remove(e *list.Element) {
+	key := c.order.Remove(e).(previewKey)
+	delete(c.entries, key.path)
+	delete(c.texts, key)
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+const (
+	// progressDelay is how long an operation runs before its progress
+	// dialog appears, so quick ones don't flash it
+	progressDelay = 400 * time.Millisecond
+	// progressInterval is how often progress dialogs are updated
+	progressInterval = 250 * time.Millisecond
+	// progressBarWidth is the width of the bar in progress dialogs
+	progressBarWidth = 20
+)
+
+// progressDialog is a modal showing the progress of a long operation: what
+// it is doing, a bar of how much is done, the speed and the time left.
+// Cancel stops the operation; Background, if offered, hides the dialog and
+// lets the operation run on.
+type progressDialog struct {
+	ui     *FileExplorerUI
+	page   string
+	modal  *tview.Modal
+	label  string
+	stats  ops.Stats
+	amount func(int64) string // renders a number of units, formatSize by default
+	shown  bool
+	closed bool
+}
+
+// newProgressDialog creates the progress dialog of an operation, which
+// appears after progressDelay unless it has been closed by then. cancel is
+// called when Cancel is pressed and hide, unless nil, when Background is.
+// Either closes the dialog.
+func (ui *FileExplorerUI) newProgressDialog(label string, cancel, hide func()) *progressDialog {
+	d := &progressDialog{ui: ui, label: label, amount: formatSize}
+	d.page = fmt.Sprintf("progress %p", d)
+
+	buttons := []string{"Cancel"}
+	if hide != nil {
+		buttons = []string{"Background", "Cancel"}
+	}
+	d.modal = tview.NewModal().
+		AddButtons(buttons).
+		SetDoneFunc(func(_ int, button string) {
+			d.close()
+			if button == "Cancel" {
+				cancel()
+			} else if hide != nil {
+				hide()
+			}
+		})
+	d.render()
+
+	time.AfterFunc(progressDelay, func() {
+		ui.queueUpdateDraw(func() {
+			if !d.closed {
+				d.shown = true
+				ui.showPage(d.page, d.modal)
+			}
+		})
+	})
+	return d
+}
+
+// update shows the latest progress, with what the operation is doing now
+// if label isn't empty. It must be called on the UI goroutine.
+func (d *progressDialog) update(label string, stats ops.Stats) {
+	if label != "" {
+		d.label = label
+	}
+	d.stats = stats
+	d.render()
+}
+
+// render sets the text of the dialog from its label and stats
+func (d *progressDialog) render() {
+	var b strings.Builder
+	b.WriteString(tview.Escape(d.label))
+	if s := d.stats; s.Total > 0 {
+		filled := int(s.Done * progressBarWidth / s.Total)
+		fmt.Fprintf(&b, "\n\n%s%s\n%d%%, %s of %s", strings.Repeat("█", filled),
+			strings.Repeat("░", progressBarWidth-filled), s.Done*100/s.Total,
+			d.amount(s.Done), d.amount(s.Total))
+	} else if s.Done > 0 {
+		b.WriteString("\n\n" + d.amount(s.Done))
+	}
+	if rate := d.stats.Rate(); rate > 0 && d.stats.Elapsed >= time.Second {
+		fmt.Fprintf(&b, "\n%s/s", d.amount(int64(rate)))
+		if left, ok := d.stats.Remaining(); ok {
+			fmt.Fprintf(&b, ", %s left", left.Round(time.Second))
+		}
+	}
+	d.modal.SetText(b.String())
+}
+
+// close removes the dialog, or keeps it from appearing. It must be called
+// on the UI goroutine.
+func (d *progressDialog) close() {
+	if d.closed {
+		return
+	}
+	d.closed = true
+	if d.shown {
+		d.ui.closePage(d.page)
+	}
+}
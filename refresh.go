@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"context"
+	"time"
+)
+
+// startAutoRefresh reloads the listing every RefreshInterval, replacing any
+// previously running refresher. A zero interval disables it.
+func (ui *FileExplorerUI) startAutoRefresh() {
+	if ui.refreshCancel != nil {
+		ui.refreshCancel()
+		ui.refreshCancel = nil
+	}
+	interval := ui.config.RefreshInterval
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	ui.refreshCancel = cancel
+
+	ui.goBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ui.queueUpdateDraw(func() {
+					if ctx.Err() == nil {
+						ui.autoRefresh()
+					}
+				})
+			}
+		}
+	})
+}
+
+// autoRefresh reloads the listings unless a dialog or view is open, skipping
+// panes whose load is still in progress and keeping the selection and the
+// footer message as they were. The hidden pane of the single-pane layout is
+// left alone.
+func (ui *FileExplorerUI) autoRefresh() {
+	if front, _ := ui.pages.GetFrontPage(); front != mainPage {
+		return
+	}
+	msg := ui.footerMsg
+	for _, p := range ui.visiblePanes() {
+		if p.load == nil {
+			p.reload()
+		}
+	}
+	ui.footerMsg = msg
+	ui.renderFooter()
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// runTestUI runs an explorer of dir configured by cfg on a simulated
+// screen, stopping it at the end of the test
+func runTestUI(t *testing.T, dir string, cfg Config) *FileExplorerUI {
+	t.Helper()
+	setTestHome(t)
+
+	ui := NewFileExplorerUI(WithConfig(cfg), WithConfigPath(""), WithStartPath(dir))
+	screen := tcell.NewSimulationScreen("")
+	screen.SetSize(120, 40)
+	ui.app.SetScreen(screen)
+	done := make(chan error, 1)
+	go func() { done <- ui.app.Run() }()
+	t.Cleanup(func() {
+		ui.Stop()
+		<-done
+		ui.shutdown()
+	})
+	return ui
+}
+
+// setTestHome keeps the config, plugins and visited directories of the
+// user out of a test
+func setTestHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+}
+
+// onUI runs f on the UI goroutine and waits for it
+func onUI(ui *FileExplorerUI, f func()) {
+	done := make(chan struct{})
+	ui.host.QueueUpdateDraw(func() {
+		f()
+		close(done)
+	})
+	<-done
+}
+
+// waitListed waits for name to be listed in the active pane
+func waitListed(t *testing.T, ui *FileExplorerUI, name string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var listed bool
+		onUI(ui, func() {
+			listed = ui.pane.load == nil && slices.ContainsFunc(ui.pane.listing, func(e os.DirEntry) bool {
+				return e.Name() == name
+			})
+		})
+		if listed {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s wasn't listed after reloading", name)
+}
+
+func TestReloadKeepsFocus(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+	}{
+		{"refresh", func(c *Config) { c.RefreshInterval = 20 * time.Millisecond }},
+		{"watch", func(c *Config) { c.Watch = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := DefaultConfig()
+			tt.config(&cfg)
+			ui := runTestUI(t, dir, cfg)
+
+			for i, focus := range []tview.Primitive{ui.contentPane, ui.tree, ui.header} {
+				onUI(ui, func() { ui.setFocus(focus) })
+				name := filepath.Join(dir, string(rune('a'+i)))
+				if err := os.WriteFile(name, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				waitListed(t, ui, filepath.Base(name))
+				onUI(ui, func() {
+					if got := ui.app.GetFocus(); got != focus {
+						t.Errorf("focus moved to %T from %T on reloading", got, focus)
+					}
+				})
+			}
+		})
+	}
+}
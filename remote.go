@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+// downloadSelected copies the selected entries from a file system other
+// than the local disk to a local directory asked from the user, by
+// default the working directory. It runs in the background with the
+// progress in a dialog and in the footer.
+func (ui *FileExplorerUI) downloadSelected() {
+	paths := ui.SelectedPaths()
+	if len(paths) == 0 {
+		return
+	}
+	title := "Download to"
+	if len(paths) > 1 {
+		title = fmt.Sprintf("%s (%d entries)", title, len(paths))
+	}
+	wd, _ := os.Getwd()
+	ui.prompt(title, wd, func(dir string) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			return
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(wd, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			ui.setFooterError(tview.Escape(dir + " is not a local directory"))
+			return
+		}
+		ui.pane.clearMarks()
+		ui.download(paths, dir)
+	})
+}
+
+// download copies paths into the local directory dir in the background
+func (ui *FileExplorerUI) download(paths []string, dir string) {
+	fsys := ui.fsys
+	ctx, cancel := context.WithCancel(ui.ctx)
+	done := ops.NewCounter(0)
+	var current atomic.Value
+	current.Store(filepath.Base(paths[0]))
+	ui.setFooterStatus(tview.Escape("Downloading " + filepath.Base(paths[0])))
+	dialog := ui.newProgressDialog("Downloading "+filepath.Base(paths[0]), cancel, func() {})
+
+	ui.goBackground(func() {
+		for stats := range done.Watch(ctx, progressInterval) {
+			label := fmt.Sprintf("Downloading %s", current.Load())
+			ui.queueUpdateDraw(func() {
+				ui.setFooterStatus(tview.Escape(fmt.Sprintf("%s, %s", label, formatSize(stats.Done))))
+				dialog.update(label, stats)
+			})
+		}
+	})
+
+	ui.goBackground(func() {
+		defer cancel()
+		var errs []error
+		for _, path := range paths {
+			current.Store(filepath.Base(path))
+			err := core.Download(ctx, fsys, path, filepath.Join(dir, filepath.Base(path)), done.Add)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		stopped := ctx.Err() != nil
+
+		ui.queueUpdateDraw(func() {
+			dialog.close()
+			switch err := errors.Join(errs...); {
+			case stopped:
+				ui.setFooterStatus("Download cancelled")
+			case err != nil:
+				ui.showError(err)
+			default:
+				ui.setFooterStatus(tview.Escape(fmt.Sprintf("Downloaded %d entries (%s) to %s", len(paths), formatSize(done.Stats().Done), dir)))
+			}
+		})
+	})
+}
+
+// removeRemote deletes paths with everything in them in the background,
+// on file systems other than the local disk
+func (ui *FileExplorerUI) removeRemote(paths []string) {
+	fsys := ui.fsys
+	ui.setFooterStatus(tview.Escape("Deleting " + filepath.Base(paths[0]) + "..."))
+	ui.goBackground(func() {
+		var errs []error
+		for _, path := range paths {
+			if err := core.RemoveAll(ui.ctx, fsys, path); err != nil {
+				errs = append(errs, err)
+			}
+			if ui.ctx.Err() != nil {
+				break
+			}
+		}
+		ui.queueUpdateDraw(func() {
+			ui.autoRefresh()
+			if err := errors.Join(errs...); err != nil {
+				ui.showError(err)
+				return
+			}
+			ui.setFooterStatus(fmt.Sprintf("Deleted %d entries", len(paths)))
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// RenameStyle selects how the rename action asks for the new name
+type RenameStyle string
+
+const (
+	// RenameInline edits the name directly in the listing
+	RenameInline RenameStyle = "inline"
+	// RenamePrompt asks for the new name in a dialog
+	RenamePrompt RenameStyle = "prompt"
+)
+
+// renamePage is the name of the inline rename editor overlay
+const renamePage = "rename"
+
+// renameSelected starts renaming the selected entry in the configured style
+func (ui *FileExplorerUI) renameSelected() {
+	if ui.refuseInArchive() {
+		return
+	}
+	path, ok := ui.pane.selectedEntry()
+	if !ok {
+		return
+	}
+
+	done := func(newName string) {
+		if err := ui.renameEntry(path, newName); err != nil {
+			ui.showError(err)
+		}
+	}
+
+	if ui.config.RenameStyle == RenamePrompt {
+		ui.prompt("Rename", filepath.Base(path), done)
+		return
+	}
+	ui.editNameInline(filepath.Base(path), done)
+}
+
+// editNameInline lays an input field over the selected Name cell. Enter
+// commits the edit through done, Escape cancels it.
+func (ui *FileExplorerUI) editNameInline(name string, done func(newName string)) {
+	row, _ := ui.pane.table.GetSelection()
+	rowOffset, _ := ui.pane.table.GetOffset()
+	x, y, width, _ := ui.pane.table.GetInnerRect()
+
+	input := tview.NewInputField().SetText(name)
+	input.SetFieldBackgroundColor(ui.theme.Selection)
+	input.SetRect(x, y+row-rowOffset, min(width, max(len(name)+10, 30)), 1)
+	input.SetDoneFunc(func(key tcell.Key) {
+		text := input.GetText()
+		ui.closePage(renamePage)
+		if key == tcell.KeyEnter {
+			done(text)
+		}
+	})
+
+	// Don't resize: the field must stay on top of the row being renamed
+	ui.pages.AddPage(renamePage, input, false, true)
+	ui.setFocus(input)
+}
+
+// renameEntry renames path to newName within the same directory, refusing
+// invalid names and existing targets, and selects the result
+func (ui *FileExplorerUI) renameEntry(path, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" || newName == filepath.Base(path) {
+		return nil
+	}
+	target, err := core.Rename(ui.fsys, path, newName)
+	if err != nil {
+		return err
+	}
+	ui.undoLog.Add(ops.Entry{Op: ops.OpRename, Src: path, Dst: target})
+
+	ui.pane.loadDirectory(ui.pane.path)
+	ui.pane.selectName(newName)
+	ui.setFooterStatus(tview.Escape(fmt.Sprintf("Renamed %s to %s", filepath.Base(path), newName)))
+	return nil
+}
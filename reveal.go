@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/core"
+)
+
+// Reveal navigates to the directory containing path and selects path in
+// the listing. If path or some of its parents no longer exist, the deepest
+// existing ancestor is shown instead and an error is returned. Once the
+// application is running it must be called on the UI goroutine.
+func (ui *FileExplorerUI) Reveal(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	// Find the deepest part of the path that still exists
+	existing := abs
+	for {
+		_, statErr := core.Lstat(ui.fsys, existing)
+		if statErr == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return statErr
+		}
+		existing = parent
+	}
+
+	if existing != abs {
+		ui.changeDir(existing)
+		err := fmt.Errorf("%s not found, showing %s", abs, existing)
+		ui.showError(err)
+		return err
+	}
+
+	dir, name := filepath.Dir(abs), filepath.Base(abs)
+	if dir == abs {
+		// The root has no parent to select it in
+		ui.changeDir(abs)
+		return nil
+	}
+
+	// Make sure the filter doesn't hide the target
+	if !ui.pane.matchesFilter(name) {
+		ui.pane.filter = ""
+		ui.pane.updateDirTitle()
+	}
+	ui.changeDir(dir)
+
+	if !ui.showHidden && core.IsHidden(name) {
+		err := fmt.Errorf("%s is hidden", name)
+		ui.showError(err)
+		return err
+	}
+	ui.pane.selectName(name)
+	return nil
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"context"
+	"io/fs"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// treeSummary totals the files below a set of paths
+type treeSummary struct {
+	files int64
+	dirs  int64
+	bytes int64
+}
+
+// String renders the summary as e.g. "1,203 files in 12 directories, 2.4 GB"
+func (s treeSummary) String() string {
+	text := plural(s.files, "file", "files")
+	if s.dirs > 0 {
+		text += " in " + plural(s.dirs, "directory", "directories")
+	}
+	return text + ", " + formatSize(s.bytes)
+}
+
+// plural renders a count with the matching form of a noun
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return formatCount(n) + " " + many
+}
+
+// treeScan walks paths recursively and totals what it finds. The running
+// totals can be read with progress while the walk is in flight.
+type treeScan struct {
+	files, dirs, bytes atomic.Int64
+}
+
+// progress returns the totals counted so far
+func (s *treeScan) progress() treeSummary {
+	return treeSummary{files: s.files.Load(), dirs: s.dirs.Load(), bytes: s.bytes.Load()}
+}
+
+// run walks every path in fsys, stopping early with ctx's error once it is
+// cancelled. Symlinks are counted, not followed; unreadable entries are
+// skipped.
+func (s *treeScan) run(ctx context.Context, fsys vfs.FS, paths []string) (treeSummary, error) {
+	for _, root := range paths {
+		err := core.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				s.dirs.Add(1)
+				return nil
+			}
+			s.files.Add(1)
+			if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+				s.bytes.Add(info.Size())
+			}
+			return nil
+		})
+		if err != nil {
+			return s.progress(), err
+		}
+	}
+	return s.progress(), nil
+}
+
+// anyDir reports whether one of the paths is a directory (not following symlinks)
+func anyDir(fsys vfs.FS, paths []string) bool {
+	for _, path := range paths {
+		if info, err := core.Lstat(fsys, path); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCount renders n with thousands separators, e.g. 1,203
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	start := 0
+	if n < 0 {
+		start = 1
+	}
+	for i := len(s) - 3; i > start; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
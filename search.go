@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// searchPage is the name of the search results view
+const searchPage = "search"
+
+// searchBatchSize is how many matches are collected before they are shown
+const searchBatchSize = 64
+
+// searchMatcher returns a case-insensitive matcher for names. Patterns with
+// glob characters (*, ? or [) must match the whole name, other patterns
+// match names containing them.
+func searchMatcher(pattern string) (func(name string) bool, error) {
+	pattern = strings.ToLower(pattern)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return func(name string) bool {
+			return strings.Contains(strings.ToLower(name), pattern)
+		}, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return func(name string) bool {
+		ok, _ := filepath.Match(pattern, strings.ToLower(name))
+		return ok
+	}, nil
+}
+
+// searchOptions bound a search
+type searchOptions struct {
+	maxDepth   int // directory levels below the root to descend; 0 is unlimited
+	limit      int // matches to stop after; 0 is unlimited
+	showHidden bool
+}
+
+// searchResult is an entry found by a search
+type searchResult struct {
+	path string
+	dir  bool
+}
+
+// walkTree calls visit for the entries below root in fsys in lexical order. Hidden
+// entries are skipped unless opts.showHidden is set, as are directories
+// beyond opts.maxDepth and directories that can't be read. visit may return
+// filepath.SkipDir or filepath.SkipAll like a WalkDirFunc.
+func walkTree(ctx context.Context, fsys vfs.FS, root string, opts searchOptions, visit func(path string, d fs.DirEntry) error) error {
+	return core.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if path == root {
+			return err
+		}
+		if err != nil {
+			// Keep going past directories we can't read
+			return nil
+		}
+		if !opts.showHidden && core.IsHidden(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if err := visit(path, d); err != nil {
+			return err
+		}
+
+		if d.IsDir() && opts.maxDepth > 0 {
+			rel, _ := filepath.Rel(root, path)
+			if strings.Count(rel, string(filepath.Separator))+1 >= opts.maxDepth {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+}
+
+// searchTree passes the entries below root whose names match to emit, in
+// batches, counting the entries looked at in scanned. It reports whether
+// the search stopped at the match limit.
+func searchTree(ctx context.Context, fsys vfs.FS, root string, match func(string) bool, opts searchOptions, scanned *ops.Counter, emit func([]searchResult)) (limited bool, err error) {
+	var batch []searchResult
+	found := 0
+	err = walkTree(ctx, fsys, root, opts, func(path string, d fs.DirEntry) error {
+		scanned.Add(1)
+		if !match(d.Name()) {
+			return nil
+		}
+		batch = append(batch, searchResult{path, d.IsDir()})
+		found++
+		if len(batch) == searchBatchSize {
+			emit(batch)
+			batch = nil
+		}
+		if opts.limit > 0 && found >= opts.limit {
+			limited = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if len(batch) > 0 {
+		emit(batch)
+	}
+	return limited, err
+}
+
+// promptSearch asks for a name pattern and searches the tree below the
+// current directory for it
+func (ui *FileExplorerUI) promptSearch() {
+	root := ui.pane.path
+	if ui.pane.inPathList() {
+		ui.setFooterError("Search needs a directory, leave the path list first")
+		return
+	}
+	ui.prompt("Search names below "+root+" (substring or glob)", "", func(pattern string) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			return
+		}
+		match, err := searchMatcher(pattern)
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.showSearch(root, pattern, match)
+	})
+}
+
+// showSearch opens the results view and fills it from a background search,
+// whose progress is shown in a dialog until hidden. Enter reveals the selected match in the listing; Esc stops a running
+// search, or else closes the view.
+func (ui *FileExplorerUI) showSearch(root, pattern string, match func(string) bool) {
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetBorderColor(ui.theme.ResultsBorder)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.Selection).Foreground(ui.theme.SelectionText))
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	var results []searchResult
+	running := true
+	status := "searching..., Esc stops"
+
+	setTitle := func() {
+		table.SetTitle(fmt.Sprintf("Search %q in %s - %s %s(%s)[-]",
+			pattern, root, plural(int64(len(results)), "match", "matches"), colorTag(ui.theme.Muted), status))
+	}
+	setTitle()
+
+	table.SetSelectedFunc(func(row, _ int) {
+		if row >= len(results) {
+			return
+		}
+		cancel()
+		ui.closePage(searchPage)
+		ui.Reveal(results[row].path)
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyEscape {
+			return event
+		}
+		if running {
+			cancel()
+			return nil
+		}
+		ui.closePage(searchPage)
+		return nil
+	})
+
+	opts := searchOptions{
+		maxDepth:   ui.config.SearchMaxDepth,
+		limit:      ui.config.SearchLimit,
+		showHidden: ui.showHidden,
+	}
+	scanned := ops.NewCounter(0)
+	dialog := ui.newProgressDialog("Searching names below "+root, cancel, func() {})
+	dialog.amount = func(n int64) string { return plural(n, "entry", "entries") }
+	ui.goBackground(func() {
+		for stats := range scanned.Watch(ctx, progressInterval) {
+			ui.queueUpdateDraw(func() { dialog.update("", stats) })
+		}
+	})
+
+	ui.goBackground(func() {
+		defer cancel()
+		emit := func(batch []searchResult) {
+			ui.queueUpdateDraw(func() {
+				for _, result := range batch {
+					rel, err := filepath.Rel(root, result.path)
+					if err != nil {
+						rel = result.path
+					}
+					cell := tview.NewTableCell(tview.Escape(rel))
+					if result.dir {
+						cell.SetTextColor(ui.theme.Directory)
+					}
+					table.SetCell(len(results), 0, cell)
+					results = append(results, result)
+				}
+				setTitle()
+			})
+		}
+		limited, err := searchTree(ctx, ui.fsys, root, match, opts, scanned, emit)
+		ui.queueUpdateDraw(func() {
+			running = false
+			dialog.close()
+			switch {
+			case limited:
+				status = fmt.Sprintf("stopped at the limit of %d", opts.limit)
+			case errors.Is(err, context.Canceled):
+				status = "stopped"
+			case err != nil:
+				status = "failed"
+				ui.showError(err)
+			default:
+				status = "done"
+			}
+			if len(results) == 0 {
+				table.SetCell(0, 0, tview.NewTableCell("(no matches)").
+					SetTextColor(ui.theme.Dim).
+					SetSelectable(false))
+			}
+			setTitle()
+		})
+	})
+
+	ui.showPage(searchPage, panel(table))
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Session is the state of the explorer that is restored on the next start:
+// the tabs of each pane, the order and columns of the listings and the
+// layout
+type Session struct {
+	Sort        SortKey       `toml:"sort"`
+	SortReverse bool          `toml:"sort_reverse"`
+	Columns     ColumnPreset  `toml:"columns"`
+	DualPane    bool          `toml:"dual_pane"`
+	ShowTree    bool          `toml:"show_tree"`
+	ActivePane  int           `toml:"active_pane"`
+	Panes       []PaneSession `toml:"pane"`
+}
+
+// PaneSession is the state of a pane: its tabs and which one is active
+type PaneSession struct {
+	Tab  int          `toml:"active_tab"`
+	Tabs []TabSession `toml:"tab"`
+}
+
+// TabSession is the location of a tab and the entry selected there
+type TabSession struct {
+	Path     string `toml:"path"`
+	Selected string `toml:"selected,omitempty"`
+	Filter   string `toml:"filter,omitempty"`
+}
+
+// DefaultSessionPath returns the location of the user's session file, next
+// to the config file
+func DefaultSessionPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofiles", "session.toml")
+}
+
+// LoadSession reads the session file at path. A missing file is not an
+// error, but there is no session to restore: it returns nil.
+func LoadSession(path string) (*Session, error) {
+	if path == "" {
+		return nil, nil
+	}
+	var s Session
+	if _, err := toml.DecodeFile(path, &s); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("session %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the session to the file at path
+func (s *Session) Save(path string) error {
+	if path == "" {
+		return errors.New("no session file")
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(s); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// Session returns the current state of the explorer. Tabs showing path
+// lists aren't part of it, there would be no telling where they came from.
+func (ui *FileExplorerUI) Session() *Session {
+	s := &Session{
+		Sort:        ui.sortKey,
+		SortReverse: ui.sortReverse,
+		Columns:     ui.columnPreset,
+		DualPane:    ui.dual,
+		ShowTree:    ui.showTree,
+	}
+	for i, p := range ui.panes {
+		if p == ui.pane {
+			s.ActivePane = i
+		}
+		p.saveTab()
+		var ps PaneSession
+		for j, t := range p.tabs {
+			if t.pathList != nil {
+				continue
+			}
+			if j == p.tab {
+				ps.Tab = len(ps.Tabs)
+			}
+			ps.Tabs = append(ps.Tabs, TabSession{Path: t.path, Selected: t.selected, Filter: t.filter})
+		}
+		s.Panes = append(s.Panes, ps)
+	}
+	return s
+}
+
+// RestoreSession brings the explorer back to the state in s. Tabs whose
+// directories no longer exist are left out, and settings that aren't valid
+// keep their current values.
+func (ui *FileExplorerUI) RestoreSession(s *Session) {
+	if s == nil {
+		return
+	}
+	if slices.Contains(sortKeys, s.Sort) {
+		ui.sortKey, ui.sortReverse = s.Sort, s.SortReverse
+	}
+	if slices.Contains(columnPresets, s.Columns) {
+		ui.columnPreset = s.Columns
+	}
+
+	for i, ps := range s.Panes {
+		if i >= len(ui.panes) {
+			break
+		}
+		var tabs []*tab
+		active := 0
+		for j, ts := range ps.Tabs {
+			if info, err := statPath(ui.fsys, ts.Path); err != nil || !info.IsDir() {
+				continue
+			}
+			if j <= ps.Tab {
+				active = len(tabs)
+			}
+			tabs = append(tabs, &tab{
+				path:     ts.Path,
+				filter:   ts.Filter,
+				selected: ts.Selected,
+				marked:   map[string]bool{},
+			})
+		}
+		if len(tabs) == 0 {
+			continue
+		}
+		p := ui.panes[i]
+		p.tabs = tabs
+		p.restoreTab(active)
+	}
+
+	ui.dual = s.DualPane
+	ui.showTree = s.ShowTree
+	if other := ui.panes[1]; ui.dual && other.load == nil && other.listingPath != other.path {
+		other.reload()
+	}
+	active := ui.panes[0]
+	if ui.dual && s.ActivePane == 1 {
+		active = ui.panes[1]
+	}
+	ui.activatePane(active)
+	ui.arrangeGrid()
+	ui.setHeader(ui.pane)
+	ui.syncTree()
+	ui.setFocus(ui.pane.table)
+}
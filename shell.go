@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// shellMaxLines caps how much output of a shell command is kept
+const shellMaxLines = 10000
+
+// expandPlaceholders replaces %f in a command line with the selected paths
+// and %d with the current directory, each quoted for the shell. %% stands
+// for a single %.
+func expandPlaceholders(command string, paths []string, dir string) string {
+	var b strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] != '%' || i+1 == len(command) {
+			b.WriteByte(command[i])
+			continue
+		}
+		switch command[i+1] {
+		case 'f':
+			quoted := make([]string, len(paths))
+			for j, path := range paths {
+				quoted[j] = shellQuote(path)
+			}
+			b.WriteString(strings.Join(quoted, " "))
+		case 'd':
+			b.WriteString(shellQuote(dir))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteString(command[i : i+2])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// promptShell asks for a shell command to run in the current directory
+func (ui *FileExplorerUI) promptShell() {
+	if ui.refuseInArchive() || ui.refuseNonLocal() || ui.pane.inPathList() {
+		return
+	}
+	paths := ui.SelectedPaths()
+	dir := ui.pane.path
+	ui.prompt("Run in "+dir+" (%f selection, %d directory)", "", func(command string) {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			return
+		}
+		ui.runShell(expandPlaceholders(command, paths, dir), dir)
+	})
+}
+
+// outputWriter appends what a command writes to a text view
+type outputWriter struct {
+	ui   *FileExplorerUI
+	view *tview.TextView
+}
+
+func (w outputWriter) Write(b []byte) (int, error) {
+	text := string(b)
+	w.ui.queueUpdateDraw(func() {
+		fmt.Fprint(w.view, text)
+		w.view.ScrollToEnd()
+	})
+	return len(b), nil
+}
+
+// runShell runs command in dir with its output streamed into a scrollable
+// panel. Escape kills a running command and closes the panel once it has
+// finished, refreshing the listings. The command gets no input.
+func (ui *FileExplorerUI) runShell(command, dir string) {
+	const name = "shell"
+	ctx, cancel := context.WithCancel(ui.ctx)
+	finished := false // set on the UI goroutine once the output is complete
+
+	view := tview.NewTextView().SetScrollable(true).SetMaxLines(shellMaxLines)
+	view.SetBorder(true)
+	view.SetTitle(" $ " + tview.Escape(command) + " ")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyEscape {
+			return event
+		}
+		if !finished {
+			cancel()
+			return nil
+		}
+		ui.closePage(name)
+		ui.autoRefresh()
+		return nil
+	})
+	ui.showPage(name, panel(view))
+
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	out := outputWriter{ui: ui, view: view}
+	cmd.Stdout, cmd.Stderr = out, out
+	// Children of a killed shell may keep the output open; stop waiting for
+	// them shortly after
+	cmd.WaitDelay = time.Second
+
+	ui.goBackground(func() {
+		err := cmd.Run()
+		cancelled := ctx.Err() != nil
+		cancel()
+		ui.queueUpdateDraw(func() {
+			var status string
+			var exitErr *exec.ExitError
+			switch {
+			case cancelled:
+				status = "killed"
+			case errors.As(err, &exitErr):
+				status = fmt.Sprintf("exit status %d", exitErr.ExitCode())
+			case err != nil:
+				status = err.Error()
+			default:
+				status = "done"
+			}
+			fmt.Fprintf(view, "\n-- %s, Esc closes --\n", status)
+			view.ScrollToEnd()
+			finished = true
+		})
+	})
+}
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"time"
+)
+
+// shutdownTimeout bounds how long quitting waits for background work to
+// wind down before giving up on it
+const shutdownTimeout = 2 * time.Second
+
+// goBackground runs fn in a goroutine that shutdown waits for. fn must
+// return promptly once ui.ctx is cancelled.
+func (ui *FileExplorerUI) goBackground(fn func()) {
+	ui.workers.Add(1)
+	go func() {
+		defer ui.workers.Done()
+		fn()
+	}()
+}
+
+// queueUpdateDraw runs f on the UI goroutine, unless the UI is shutting
+// down: a stopped application no longer drains its queue, so background
+// work posting to it could block forever.
+func (ui *FileExplorerUI) queueUpdateDraw(f func()) {
+	if ui.ctx.Err() != nil {
+		return
+	}
+	ui.host.QueueUpdateDraw(f)
+}
+
+// afterUpdate runs f on the UI goroutine once the current update or draw
+// is done. It is for the UI goroutine itself, where queueUpdateDraw would
+// wait for an update that can't run until it returns.
+func (ui *FileExplorerUI) afterUpdate(f func()) {
+	ui.goBackground(func() {
+		ui.queueUpdateDraw(f)
+	})
+}
+
+// Stop cancels all background work and ends the event loop, which makes
+// Start return once the workers have finished
+func (ui *FileExplorerUI) Stop() {
+	ui.cancel()
+	if ui.app != nil {
+		ui.app.Stop()
+	}
+}
+
+// shutdown cancels all background work and waits up to shutdownTimeout for
+// it to finish. It reports whether every worker finished in time.
+func (ui *FileExplorerUI) shutdown() bool {
+	ui.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		ui.workers.Wait()
+		ui.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(shutdownTimeout):
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/core"
+)
+
+// goSibling enters the directory next to the current one in its parent,
+// delta steps away in name order. Files are skipped, and the motion wraps
+// around at either end.
+func (ui *FileExplorerUI) goSibling(delta int) {
+	parent := filepath.Dir(ui.pane.path)
+	if ui.pane.inPathList() {
+		return
+	}
+	if parent == ui.pane.path {
+		ui.setFooterStatus("No sibling directories at the root")
+		return
+	}
+
+	entries, err := core.ReadDir(ui.ctx, ui.fsys, parent)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+
+	current := filepath.Base(ui.pane.path)
+	var dirs []string
+	at := -1
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != current && !ui.showHidden && core.IsHidden(name) {
+			continue
+		}
+		if !entry.IsDir() {
+			// Follow symlinks to directories
+			info, err := core.Stat(ui.fsys, filepath.Join(parent, name))
+			if err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		if name == current {
+			at = len(dirs)
+		}
+		dirs = append(dirs, name)
+	}
+	if at < 0 || len(dirs) < 2 {
+		ui.setFooterStatus("No sibling directories")
+		return
+	}
+
+	next := at + delta
+	wrapped := next < 0 || next >= len(dirs)
+	next = (next%len(dirs) + len(dirs)) % len(dirs)
+	ui.navigate(filepath.Join(parent, dirs[next]))
+	if wrapped {
+		if delta > 0 {
+			ui.setFooterStatus("Wrapped around to the first sibling")
+		} else {
+			ui.setFooterStatus("Wrapped around to the last sibling")
+		}
+	}
+}
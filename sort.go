@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aktagon/gofiles/core"
+)
+
+// SortKey identifies the column the listing is ordered by
+type SortKey = core.SortKey
+
+// Supported sort keys
+const (
+	SortName     = core.SortName
+	SortSize     = core.SortSize
+	SortModified = core.SortModified
+	SortType     = core.SortType
+)
+
+// sortKeys is the order in which sort keys are cycled through
+var sortKeys = core.SortKeys
+
+// dirEntry is a listed entry together with its git status and MIME type
+type dirEntry struct {
+	core.Entry
+	git  gitStatus // when listed in a git work tree
+	mime string    // detected MIME type, when the MIME column is shown
+}
+
+// sortEntries orders entries by key, falling back to the name for ties.
+// With dirsFirst, directories come before everything else in either
+// direction.
+func sortEntries(entries []dirEntry, key SortKey, reverse, dirsFirst bool) {
+	order := core.Order{Key: key, Reverse: reverse, DirsFirst: dirsFirst}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return order.Less(entries[i].Entry, entries[j].Entry)
+	})
+}
+
+// sortBy orders the listing by key, toggling the direction when the listing
+// is already sorted by it
+func (ui *FileExplorerUI) sortBy(key SortKey) {
+	if ui.sortKey == key {
+		ui.sortReverse = !ui.sortReverse
+	} else {
+		ui.sortKey, ui.sortReverse = key, false
+	}
+	ui.resort()
+}
+
+// cycleSort orders the listing by the next sort key, ascending
+func (ui *FileExplorerUI) cycleSort() {
+	next := sortKeys[0]
+	for i, key := range sortKeys {
+		if key == ui.sortKey {
+			next = sortKeys[(i+1)%len(sortKeys)]
+			break
+		}
+	}
+	ui.sortKey, ui.sortReverse = next, false
+	ui.resort()
+}
+
+// reverseSort flips the direction of the listing's order
+func (ui *FileExplorerUI) reverseSort() {
+	ui.sortReverse = !ui.sortReverse
+	ui.resort()
+}
+
+// resort lists the visible panes again in the new order and reports it
+func (ui *FileExplorerUI) resort() {
+	for _, p := range ui.visiblePanes() {
+		p.reload()
+	}
+
+	direction := "ascending"
+	if ui.sortReverse {
+		direction = "descending"
+	}
+	ui.setFooterStatus(fmt.Sprintf("Sorted by %s, %s", ui.sortKey, direction))
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/aktagon/gofiles/vfs/dockerfs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// sourcesPage is the name of the sources picker
+const sourcesPage = "sources"
+
+const (
+	// localSource names the local disk in the sources picker
+	localSource = "Local disk"
+	// startSource names the file system given with WithFS
+	startSource = "Start file system"
+)
+
+// source is a file system the explorer can switch to
+type source struct {
+	name   string
+	detail string
+	local  bool
+	open   func() (vfs.FS, string, error) // the file system and where to start in it
+}
+
+// listSources gathers the local disk, the file system the explorer was
+// started with and the running Docker containers. A Docker daemon that
+// can't be reached is left out quietly unless its socket exists.
+func (ui *FileExplorerUI) listSources() ([]source, error) {
+	sources := []source{{
+		name:   localSource,
+		detail: "The working directory",
+		local:  true,
+		open: func() (vfs.FS, string, error) {
+			wd, err := os.Getwd()
+			return vfs.OS(), wd, err
+		},
+	}}
+	if ui.startFS != nil {
+		sources = append(sources, source{
+			name:   startSource,
+			detail: ui.startDir,
+			open: func() (vfs.FS, string, error) {
+				return ui.startFS, ui.startDir, nil
+			},
+		})
+	}
+
+	client, err := dockerfs.NewClient("")
+	if err != nil {
+		return sources, err
+	}
+	containers, err := client.Containers()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return sources, err
+	}
+	for _, c := range containers {
+		sources = append(sources, source{
+			name:   "Docker: " + c.Name,
+			detail: c.Image + ", " + c.Status,
+			open: func() (vfs.FS, string, error) {
+				fsys, err := client.FS(c.ID)
+				return fsys, vfs.Path("."), err
+			},
+		})
+	}
+	return sources, nil
+}
+
+// showSources opens a picker of the file systems to browse: the local
+// disk, the one the explorer started with and the running Docker
+// containers
+func (ui *FileExplorerUI) showSources() {
+	sources, err := ui.listSources()
+	if err != nil {
+		ui.setFooterError(tview.Escape("Docker: " + err.Error()))
+	}
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle("Sources")
+	for i, s := range sources {
+		list.AddItem(s.name, s.detail, 0, nil)
+		if s.name == ui.source {
+			list.SetCurrentItem(i)
+		}
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		ui.closePage(sourcesPage)
+		s := sources[index]
+		fsys, dir, err := s.open()
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.switchSource(s.name, fsys, s.local, dir)
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(sourcesPage)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 {
+			ui.closePage(sourcesPage)
+			return nil
+		}
+		return event
+	})
+
+	ui.showPage(sourcesPage, centered(list, 60, min(2*len(sources)+2, 24)))
+}
+
+// switchSource browses fsys from dir in both panes, dropping their tabs,
+// history and marks, which belong to the file system left
+func (ui *FileExplorerUI) switchSource(name string, fsys vfs.FS, local bool, dir string) {
+	ui.fsys, ui.local, ui.source = fsys, local, name
+	ui.previews = newPreviewCache()
+	ui.dirSizes = newDirSizeCache()
+	for _, p := range ui.panes {
+		p.tabs = []*tab{{path: dir, marked: map[string]bool{}}}
+		p.restoreTab(0)
+	}
+	ui.startWatcher()
+	ui.setHeader(ui.pane)
+	ui.syncTree()
+	ui.setFooterStatus(tview.Escape("Browsing " + name))
+}
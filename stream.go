@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/rivo/tview"
+)
+
+const (
+	// previewTitle is the title of the preview pane
+	previewTitle = "File Preview"
+	// previewChunkLines is how many lines of a large text file are added to
+	// the preview at a time
+	previewChunkLines = 1000
+	// previewStreamLimit caps how much of a large text file the preview
+	// holds, as all of it stays in memory
+	previewStreamLimit = 16 * 1024 * 1024
+)
+
+// previewStream is a text file too large for the preview limit, which is
+// shown a part at a time: more is read whenever the preview is scrolled
+// near the end of what has been loaded
+type previewStream struct {
+	ctx      context.Context // cancelled once another file is previewed
+	path     string
+	size     int64
+	encoding string // of the text, "" for UTF-8
+	offset   int64  // bytes shown so far
+	loading  bool   // whether a read is in flight
+}
+
+// done reports whether nothing more is to be loaded
+func (s *previewStream) done() bool {
+	return s.offset >= s.size || s.offset >= previewStreamLimit
+}
+
+// title describes how much of the file the preview shows, and its encoding
+// unless UTF-8
+func (s *previewStream) title() string {
+	var details []string
+	if s.encoding != "" {
+		details = append(details, s.encoding)
+	}
+	switch {
+	case s.offset >= s.size:
+	case s.offset >= previewStreamLimit:
+		details = append(details, fmt.Sprintf("first %s of %s", formatSize(s.offset), formatSize(s.size)))
+	default:
+		details = append(details, fmt.Sprintf("%s of %s, scroll for more", formatSize(s.offset), formatSize(s.size)))
+	}
+	if len(details) == 0 {
+		return previewTitle
+	}
+	return fmt.Sprintf("%s (%s)", previewTitle, strings.Join(details, ", "))
+}
+
+// streamable reports whether a file is previewed with a previewStream: a
+// text file larger than the preview limit, images and documents being
+// shown in other ways. It returns the encoding of the text. Streams are
+// read a line at a time, so UTF-16, whose newlines are two bytes, isn't
+// streamed.
+func streamable(fsys vfs.FS, path string, info fs.FileInfo, mode previewMode) (encoding string, ok bool) {
+	if !info.Mode().IsRegular() || info.Size() <= mode.limit || mode.hex {
+		return "", false
+	}
+	head, err := core.ReadHead(fsys, path, core.SniffSize)
+	if err != nil || !core.IsTextType(core.DetectType(filepath.Base(path), head)) {
+		return "", false
+	}
+	encoding, _ = core.DetectEncoding(head)
+	return encoding, encoding != core.UTF16LE && encoding != core.UTF16BE
+}
+
+// readChunk reads the lines of the file at path in fsys that follow offset, up to
+// previewChunkLines of them and about defaultPreviewLimit bytes, and returns them
+// transcoded from encoding with the offset after them. Long lines are read
+// in pieces rather than held in memory whole.
+func readChunk(ctx context.Context, fsys vfs.FS, path string, offset int64, encoding string) (text string, next int64, err error) {
+	f, err := core.Open(fsys, path)
+	if err != nil {
+		return "", offset, err
+	}
+	defer f.Close()
+	if err := core.Seek(f, offset); err != nil {
+		return "", offset, err
+	}
+
+	r := bufio.NewReader(f)
+	var chunk []byte
+	for lines := 0; lines < previewChunkLines && len(chunk) < defaultPreviewLimit; {
+		if err := ctx.Err(); err != nil {
+			return "", offset, err
+		}
+		line, err := r.ReadSlice('\n')
+		chunk = append(chunk, line...)
+		if err == io.EOF {
+			break
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", offset, err
+		}
+		lines++
+	}
+	decoded, err := core.Decode(chunk, encoding)
+	if err != nil {
+		return "", offset, err
+	}
+	return string(decoded), offset + int64(len(chunk)), nil
+}
+
+// startPreviewStream shows the first chunk of a large text file and starts
+// following the preview's scrolling to load the rest. It runs in the
+// background, as part of previewFile.
+func (ui *FileExplorerUI) startPreviewStream(ctx context.Context, path string, size int64, encoding string) {
+	text, next, err := readChunk(ctx, ui.fsys, path, 0, encoding)
+	ui.queueUpdateDraw(func() {
+		if ctx.Err() != nil {
+			return
+		}
+		ui.graphic = nil
+		if err != nil {
+			ui.contentPane.SetText(fmt.Sprintf("Error reading file: %s", err))
+			return
+		}
+		s := &previewStream{ctx: ctx, path: path, size: size, encoding: encoding, offset: next}
+		ui.previewStream = s
+		ui.contentPane.SetText(tview.Escape(text))
+		ui.contentPane.ScrollToBeginning()
+		ui.contentPane.SetTitle(s.title())
+	})
+}
+
+// continuePreviewStream loads the next chunk of the streamed preview once
+// it has been scrolled within a screen of the end. It is called before
+// every draw, so scrolling by any means is noticed.
+func (ui *FileExplorerUI) continuePreviewStream() {
+	s := ui.previewStream
+	if s == nil || s.loading || s.done() {
+		return
+	}
+	row, _ := ui.contentPane.GetScrollOffset()
+	_, _, _, height := ui.contentPane.GetInnerRect()
+	if row+2*height < ui.contentPane.GetWrappedLineCount() {
+		return
+	}
+
+	s.loading = true
+	ui.goBackground(func() {
+		text, next, err := readChunk(s.ctx, ui.fsys, s.path, s.offset, s.encoding)
+		ui.queueUpdateDraw(func() {
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.loading = false
+			if err != nil {
+				// Stop streaming rather than retrying on every draw
+				s.size = s.offset
+				ui.showError(err)
+				return
+			}
+			if next == s.offset {
+				// The file shrank since it was opened
+				s.size = s.offset
+			}
+			s.offset = next
+			fmt.Fprint(ui.contentPane, tview.Escape(text))
+			ui.contentPane.SetTitle(s.title())
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// summaryPage is the name of the directory summary overlay
+const summaryPage = "summary"
+
+// typeTotal aggregates the entries of one file type
+type typeTotal struct {
+	name  string
+	count int
+	size  int64
+}
+
+// summarizeListing groups the listed entries of the loaded directory by
+// extension, largest total size first
+func (ui *FileExplorerUI) summarizeListing() []typeTotal {
+	totals := map[string]*typeTotal{}
+	for _, file := range ui.pane.listing {
+		if !ui.showHidden && core.IsHidden(file.Name()) || !ui.pane.matchesFilter(file.Name()) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		key := strings.ToLower(filepath.Ext(file.Name()))
+		switch {
+		case file.IsDir():
+			key = "(directories)"
+		case key == "":
+			key = "(no extension)"
+		}
+
+		t, ok := totals[key]
+		if !ok {
+			t = &typeTotal{name: key}
+			totals[key] = t
+		}
+		t.count++
+		if !file.IsDir() {
+			t.size += info.Size()
+		}
+	}
+
+	result := make([]typeTotal, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].size != result[j].size {
+			return result[i].size > result[j].size
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// showSummary opens a bar chart of the current directory's composition by file type
+func (ui *FileExplorerUI) showSummary() {
+	const barWidth = 30
+
+	totals := ui.summarizeListing()
+	var largest, all int64
+	for _, t := range totals {
+		largest = max(largest, t.size)
+		all += t.size
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[::b]%-16s %7s %10s[::-]\n", "Type", "Count", "Size")
+	for _, t := range totals {
+		fmt.Fprintf(&b, "%-16s %7d %10s %s%s[-]\n",
+			tview.Escape(t.name), t.count, formatSize(t.size), colorTag(ui.theme.Match), bar(t.size, largest, barWidth))
+	}
+	fmt.Fprintf(&b, "\n[::b]Total:[::-] %s in %d types", formatSize(all), len(totals))
+
+	view := tview.NewTextView().SetDynamicColors(true).SetText(b.String())
+	view.SetBorder(true)
+	view.SetTitle("Summary - " + ui.pane.path)
+	view.SetDoneFunc(func(tcell.Key) {
+		ui.closePage(summaryPage)
+	})
+
+	ui.showPage(summaryPage, panel(view))
+}
+
+// bar renders value/total as a bar of up to width cells, using partial
+// block characters for eighths of a cell
+func bar(value, total int64, width int) string {
+	if total <= 0 || value <= 0 {
+		return ""
+	}
+	const partials = " ▏▎▍▌▋▊▉"
+
+	eighths := int(value * int64(width) * 8 / total)
+	full, rest := eighths/8, eighths%8
+	s := strings.Repeat("█", full)
+	if rest > 0 {
+		s += string([]rune(partials)[rest])
+	}
+	if s == "" {
+		s = "▏" // keep tiny non-zero values visible
+	}
+	return s
+}
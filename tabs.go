@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// tab is a browsing location kept by a pane. The pane's own fields hold the
+// state of its active tab; the others are saved here until switched to.
+type tab struct {
+	path     string
+	pathList []string
+	filter   string
+	selected string          // name of the selected entry
+	marked   map[string]bool // full paths of the marked entries
+	history  history
+}
+
+// saveTab stores the pane's state in its active tab
+func (p *Pane) saveTab() {
+	t := p.tabs[p.tab]
+	t.path = p.path
+	t.pathList = p.pathList
+	t.filter = p.filter
+	t.marked = p.marked
+	t.history = p.history
+	t.selected = ""
+	if row, _ := p.table.GetSelection(); row > 0 {
+		t.selected = p.rowName(row)
+	}
+}
+
+// restoreTab makes tab i the active one and lists its location
+func (p *Pane) restoreTab(i int) {
+	p.tab = i
+	t := p.tabs[i]
+	p.path = t.path
+	p.pathList = t.pathList
+	p.filter = t.filter
+	p.marked = t.marked
+	p.history = t.history
+	p.loadDirectory(p.path)
+	if t.selected != "" {
+		p.selectName(t.selected)
+	}
+}
+
+// tabLabel names a tab in the tab bar after the last element of its path
+func tabLabel(path string, pathList []string) string {
+	if pathList != nil {
+		return "paths"
+	}
+	return filepath.Base(path)
+}
+
+// tabBar renders the pane's tabs for its title, the active one highlighted,
+// or "" while there is only one
+func (p *Pane) tabBar() string {
+	if len(p.tabs) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	for i, t := range p.tabs {
+		label := tabLabel(t.path, t.pathList)
+		if i == p.tab {
+			label = tabLabel(p.path, p.pathList)
+			fmt.Fprintf(&b, "%s %d %s [-:-]", styleTag(p.ui.theme.TabActiveText, p.ui.theme.TabActive, ""), i+1, tview.Escape(label))
+		} else {
+			fmt.Fprintf(&b, " %d %s ", i+1, tview.Escape(label))
+		}
+	}
+	return b.String()
+}
+
+// newTab opens a tab at the current location next to the active one
+func (ui *FileExplorerUI) newTab() {
+	p := ui.pane
+	p.saveTab()
+	current := p.tabs[p.tab]
+	t := &tab{
+		path:     current.path,
+		pathList: current.pathList,
+		selected: current.selected,
+		marked:   map[string]bool{},
+		history:  current.history.clone(),
+	}
+	p.tabs = append(p.tabs[:p.tab+1], append([]*tab{t}, p.tabs[p.tab+1:]...)...)
+	p.restoreTab(p.tab + 1)
+	ui.setFooterStatus(fmt.Sprintf("Opened tab %d of %d", p.tab+1, len(p.tabs)))
+}
+
+// closeTab closes the active tab, switching to the one after it
+func (ui *FileExplorerUI) closeTab() {
+	p := ui.pane
+	if len(p.tabs) == 1 {
+		ui.setFooterError("Can't close the last tab")
+		return
+	}
+	p.tabs = append(p.tabs[:p.tab], p.tabs[p.tab+1:]...)
+	p.restoreTab(min(p.tab, len(p.tabs)-1))
+	ui.setFooterStatus(fmt.Sprintf("Closed tab, %d left", len(p.tabs)))
+}
+
+// cycleTab switches to the tab delta positions away, wrapping around
+func (ui *FileExplorerUI) cycleTab(delta int) {
+	p := ui.pane
+	n := len(p.tabs)
+	if n == 1 {
+		return
+	}
+	p.saveTab()
+	p.restoreTab(((p.tab+delta)%n + n) % n)
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+// templatesPage is the name of the template picker overlay
+const templatesPage = "templates"
+
+// newFromTemplate lets the user pick a configured template and a file name,
+// then creates the file in the current directory with the template contents
+func (ui *FileExplorerUI) newFromTemplate() {
+	if ui.refuseInArchive() {
+		return
+	}
+	if len(ui.config.Templates) == 0 {
+		ui.setFooterError("no templates configured")
+		return
+	}
+
+	names := make([]string, 0, len(ui.config.Templates))
+	for name := range ui.config.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle("New File from Template")
+	for _, name := range names {
+		list.AddItem(name, "", 0, nil)
+	}
+
+	list.SetSelectedFunc(func(_ int, name, _ string, _ rune) {
+		ui.closePage(templatesPage)
+		ui.prompt("File name", "", func(fileName string) {
+			if err := ui.createFile(fileName, []byte(ui.config.Templates[name])); err != nil {
+				ui.showError(err)
+			}
+		})
+	})
+	list.SetDoneFunc(func() {
+		ui.closePage(templatesPage)
+	})
+
+	ui.showPage(templatesPage, centered(list, 40, min(len(names), 15)+2))
+}
+
+// createFile creates name in the current directory with the given contents,
+// refusing to overwrite an existing entry, and selects the new file
+func (ui *FileExplorerUI) createFile(name string, contents []byte) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	path, err := core.CreateFile(ui.fsys, ui.pane.path, name, contents)
+	if err != nil {
+		return err
+	}
+	ui.undoLog.Add(ops.Entry{Op: ops.OpCreate, Dst: path})
+
+	ui.pane.loadDirectory(ui.pane.path)
+	ui.pane.selectName(name)
+	ui.setFooterStatus(tview.Escape("Created " + name))
+	return nil
+}
@@ -0,0 +1,380 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme is the set of colors the explorer is drawn with
+type Theme struct {
+	Background tcell.Color // behind everything
+	Text       tcell.Color // text, borders and titles without a color of their own
+
+	HeaderBackground tcell.Color
+	HeaderText       tcell.Color
+	FooterBackground tcell.Color
+	FooterText       tcell.Color
+	FooterKey        tcell.Color // keys in the footer hints
+	Error            tcell.Color // errors in the footer
+	RootBanner       tcell.Color // background of the header's running-as-root warning
+	RootBannerText   tcell.Color
+	TabActive        tcell.Color // background of the current tab in the header
+	TabActiveText    tcell.Color
+
+	PaneBorder         tcell.Color // the listing, or the pane with the focus
+	InactivePaneBorder tcell.Color // the other pane in the dual-pane layout
+	PreviewBorder      tcell.Color
+	ResultsBorder      tcell.Color // search and grep results
+	Selection          tcell.Color // background of the selected row
+	SelectionText      tcell.Color
+	Marked             tcell.Color // background of marked rows
+	Input              tcell.Color // background of prompts and other input fields
+
+	Directory   tcell.Color
+	Special     tcell.Color // fifos, sockets and devices
+	SpecialBits tcell.Color // entries with setuid, setgid or sticky bits
+	Dim         tcell.Color // placeholders like "(empty directory)"
+	Match       tcell.Color // filter matches, grep line numbers and summary bars
+
+	Title              tcell.Color // keys in dialog titles, labels and preview headings
+	Muted              tcell.Color // asides like progress, counts and hex offsets
+	Warning            tcell.Color // notes calling for care, like an active filter
+	Code               tcell.Color // code in rendered Markdown
+	MatchHighlight     tcell.Color // background of the matched text in grep results
+	MatchHighlightText tcell.Color
+
+	DiffAdded   tcell.Color // lines added in git diffs
+	DiffRemoved tcell.Color // lines removed in git diffs
+	DiffHunk    tcell.Color // hunk headers of git diffs
+
+	Danger          tcell.Color // destructive dialogs and the trash
+	DangerSelection tcell.Color // background of the selected row in the trash
+}
+
+// themes are the built-in themes by name
+var themes = map[string]Theme{
+	"dark": {
+		Background:         tcell.ColorBlack,
+		Text:               tcell.ColorWhite,
+		HeaderBackground:   tcell.ColorDarkBlue,
+		HeaderText:         tcell.ColorBlue,
+		FooterBackground:   tcell.ColorDarkGray,
+		FooterText:         tcell.ColorWhite,
+		FooterKey:          tcell.ColorYellow,
+		Error:              tcell.ColorRed,
+		RootBanner:         tcell.ColorRed,
+		RootBannerText:     tcell.ColorWhite,
+		TabActive:          tcell.ColorGreen,
+		TabActiveText:      tcell.ColorBlack,
+		PaneBorder:         tcell.ColorGreen,
+		InactivePaneBorder: tcell.ColorGray,
+		PreviewBorder:      tcell.ColorYellow,
+		ResultsBorder:      tcell.ColorYellow,
+		Selection:          tcell.ColorDarkGreen,
+		SelectionText:      tcell.ColorWhite,
+		Marked:             tcell.ColorDarkCyan,
+		Input:              tcell.ColorBlack,
+		Directory:          tcell.ColorBlue,
+		Special:            tcell.ColorYellow,
+		SpecialBits:        tcell.ColorRed,
+		Dim:                tcell.ColorGray,
+		Match:              tcell.ColorGreen,
+		Title:              tcell.ColorYellow,
+		Muted:              tcell.ColorGray,
+		Warning:            tcell.ColorYellow,
+		Code:               tcell.ColorAqua,
+		MatchHighlight:     tcell.ColorYellow,
+		MatchHighlightText: tcell.ColorBlack,
+		DiffAdded:          tcell.ColorGreen,
+		DiffRemoved:        tcell.ColorRed,
+		DiffHunk:           tcell.ColorDarkCyan,
+		Danger:             tcell.ColorRed,
+		DangerSelection:    tcell.ColorDarkRed,
+	},
+	"light": {
+		Background:         tcell.ColorWhite,
+		Text:               tcell.ColorBlack,
+		HeaderBackground:   tcell.NewHexColor(0xc6d9f1),
+		HeaderText:         tcell.ColorBlack,
+		FooterBackground:   tcell.ColorLightGray,
+		FooterText:         tcell.ColorBlack,
+		FooterKey:          tcell.NewHexColor(0xaf5f00),
+		Error:              tcell.NewHexColor(0xc00000),
+		RootBanner:         tcell.NewHexColor(0xc00000),
+		RootBannerText:     tcell.ColorWhite,
+		TabActive:          tcell.NewHexColor(0x87d787),
+		TabActiveText:      tcell.ColorBlack,
+		PaneBorder:         tcell.ColorGreen,
+		InactivePaneBorder: tcell.ColorDarkGray,
+		PreviewBorder:      tcell.NewHexColor(0xaf8700),
+		ResultsBorder:      tcell.NewHexColor(0xaf8700),
+		Selection:          tcell.NewHexColor(0xb3d7ff),
+		SelectionText:      tcell.ColorBlack,
+		Marked:             tcell.NewHexColor(0xc4ecef),
+		Input:              tcell.NewHexColor(0xeeeeee),
+		Directory:          tcell.NewHexColor(0x0044cc),
+		Special:            tcell.NewHexColor(0xaf8700),
+		SpecialBits:        tcell.NewHexColor(0xc00000),
+		Dim:                tcell.ColorGray,
+		Match:              tcell.ColorGreen,
+		Title:              tcell.NewHexColor(0xaf5f00),
+		Muted:              tcell.ColorGray,
+		Warning:            tcell.NewHexColor(0xaf8700),
+		Code:               tcell.NewHexColor(0x005f87),
+		MatchHighlight:     tcell.NewHexColor(0xffd75f),
+		MatchHighlightText: tcell.ColorBlack,
+		DiffAdded:          tcell.NewHexColor(0x008700),
+		DiffRemoved:        tcell.NewHexColor(0xc00000),
+		DiffHunk:           tcell.NewHexColor(0x005f87),
+		Danger:             tcell.NewHexColor(0xc00000),
+		DangerSelection:    tcell.NewHexColor(0xffc4c4),
+	},
+	"solarized": {
+		Background:         tcell.NewHexColor(0x002b36),
+		Text:               tcell.NewHexColor(0x839496),
+		HeaderBackground:   tcell.NewHexColor(0x073642),
+		HeaderText:         tcell.NewHexColor(0x93a1a1),
+		FooterBackground:   tcell.NewHexColor(0x073642),
+		FooterText:         tcell.NewHexColor(0x93a1a1),
+		FooterKey:          tcell.NewHexColor(0xb58900),
+		Error:              tcell.NewHexColor(0xdc322f),
+		RootBanner:         tcell.NewHexColor(0xdc322f),
+		RootBannerText:     tcell.NewHexColor(0xfdf6e3),
+		TabActive:          tcell.NewHexColor(0x859900),
+		TabActiveText:      tcell.NewHexColor(0x002b36),
+		PaneBorder:         tcell.NewHexColor(0x859900),
+		InactivePaneBorder: tcell.NewHexColor(0x586e75),
+		PreviewBorder:      tcell.NewHexColor(0xb58900),
+		ResultsBorder:      tcell.NewHexColor(0xb58900),
+		Selection:          tcell.NewHexColor(0x586e75),
+		SelectionText:      tcell.NewHexColor(0xfdf6e3),
+		Marked:             tcell.NewHexColor(0x0a4a4f),
+		Input:              tcell.NewHexColor(0x073642),
+		Directory:          tcell.NewHexColor(0x268bd2),
+		Special:            tcell.NewHexColor(0xb58900),
+		SpecialBits:        tcell.NewHexColor(0xdc322f),
+		Dim:                tcell.NewHexColor(0x586e75),
+		Match:              tcell.NewHexColor(0x859900),
+		Title:              tcell.NewHexColor(0xb58900),
+		Muted:              tcell.NewHexColor(0x586e75),
+		Warning:            tcell.NewHexColor(0xcb4b16),
+		Code:               tcell.NewHexColor(0x2aa198),
+		MatchHighlight:     tcell.NewHexColor(0xb58900),
+		MatchHighlightText: tcell.NewHexColor(0x002b36),
+		DiffAdded:          tcell.NewHexColor(0x859900),
+		DiffRemoved:        tcell.NewHexColor(0xdc322f),
+		DiffHunk:           tcell.NewHexColor(0x2aa198),
+		Danger:             tcell.NewHexColor(0xdc322f),
+		DangerSelection:    tcell.NewHexColor(0x6c1a18),
+	},
+	"monochrome": {
+		Background:         tcell.ColorBlack,
+		Text:               tcell.ColorWhite,
+		HeaderBackground:   tcell.ColorWhite,
+		HeaderText:         tcell.ColorBlack,
+		FooterBackground:   tcell.ColorWhite,
+		FooterText:         tcell.ColorBlack,
+		FooterKey:          tcell.ColorBlack,
+		Error:              tcell.ColorBlack,
+		RootBanner:         tcell.ColorWhite,
+		RootBannerText:     tcell.ColorBlack,
+		TabActive:          tcell.ColorWhite,
+		TabActiveText:      tcell.ColorBlack,
+		PaneBorder:         tcell.ColorWhite,
+		InactivePaneBorder: tcell.ColorGray,
+		PreviewBorder:      tcell.ColorWhite,
+		ResultsBorder:      tcell.ColorWhite,
+		Selection:          tcell.ColorWhite,
+		SelectionText:      tcell.ColorBlack,
+		Marked:             tcell.ColorDimGray,
+		Input:              tcell.ColorBlack,
+		Directory:          tcell.ColorWhite,
+		Special:            tcell.ColorWhite,
+		SpecialBits:        tcell.ColorWhite,
+		Dim:                tcell.ColorGray,
+		Match:              tcell.ColorWhite,
+		Title:              tcell.ColorWhite,
+		Muted:              tcell.ColorGray,
+		Warning:            tcell.ColorWhite,
+		Code:               tcell.ColorWhite,
+		MatchHighlight:     tcell.ColorWhite,
+		MatchHighlightText: tcell.ColorBlack,
+		DiffAdded:          tcell.ColorWhite,
+		DiffRemoved:        tcell.ColorGray,
+		DiffHunk:           tcell.ColorWhite,
+		Danger:             tcell.ColorWhite,
+		DangerSelection:    tcell.ColorGray,
+	},
+}
+
+// ThemeNames returns the names of the built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeColors maps the keys of a theme file to the colors they set
+func themeColors(t *Theme) map[string]*tcell.Color {
+	return map[string]*tcell.Color{
+		"background":           &t.Background,
+		"text":                 &t.Text,
+		"header_background":    &t.HeaderBackground,
+		"header_text":          &t.HeaderText,
+		"footer_background":    &t.FooterBackground,
+		"footer_text":          &t.FooterText,
+		"footer_key":           &t.FooterKey,
+		"error":                &t.Error,
+		"root_banner":          &t.RootBanner,
+		"root_banner_text":     &t.RootBannerText,
+		"tab_active":           &t.TabActive,
+		"tab_active_text":      &t.TabActiveText,
+		"pane_border":          &t.PaneBorder,
+		"inactive_pane_border": &t.InactivePaneBorder,
+		"preview_border":       &t.PreviewBorder,
+		"results_border":       &t.ResultsBorder,
+		"selection":            &t.Selection,
+		"selection_text":       &t.SelectionText,
+		"marked":               &t.Marked,
+		"input":                &t.Input,
+		"directory":            &t.Directory,
+		"special":              &t.Special,
+		"special_bits":         &t.SpecialBits,
+		"dim":                  &t.Dim,
+		"match":                &t.Match,
+		"title":                &t.Title,
+		"muted":                &t.Muted,
+		"warning":              &t.Warning,
+		"code":                 &t.Code,
+		"match_highlight":      &t.MatchHighlight,
+		"match_highlight_text": &t.MatchHighlightText,
+		"diff_added":           &t.DiffAdded,
+		"diff_removed":         &t.DiffRemoved,
+		"diff_hunk":            &t.DiffHunk,
+		"danger":               &t.Danger,
+		"danger_selection":     &t.DangerSelection,
+	}
+}
+
+// LoadTheme returns the built-in theme of the given name or else reads a
+// theme from the YAML file at that path. A theme file sets colors by key,
+// e.g. "directory: '#268bd2'", on top of the theme named by its base key,
+// dark by default.
+func LoadTheme(name string) (Theme, error) {
+	if theme, ok := themes[name]; ok {
+		return theme, nil
+	}
+	if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected one of %s or a .yaml file",
+			name, strings.Join(ThemeNames(), ", "))
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme: %w", err)
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+
+	base := "dark"
+	if values["base"] != "" {
+		base = values["base"]
+	}
+	theme, ok := themes[base]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base %q", name, base)
+	}
+	colors := themeColors(&theme)
+	for key, value := range values {
+		if key == "base" {
+			continue
+		}
+		color, ok := colors[key]
+		if !ok {
+			return Theme{}, fmt.Errorf("theme %s: unknown color %q", name, key)
+		}
+		if *color, err = parseColorName(value); err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %s: %w", name, key, err)
+		}
+	}
+	return theme, nil
+}
+
+// colorTag returns the tview style tag switching text to color
+func colorTag(color tcell.Color) string {
+	if color == tcell.ColorDefault {
+		return "[-]"
+	}
+	return "[" + color.String() + "]"
+}
+
+// styleTag returns the tview style tag switching text to fg on bg with the
+// attributes attrs, e.g. "b" for bold
+func styleTag(fg, bg tcell.Color, attrs string) string {
+	name := func(c tcell.Color) string {
+		if c == tcell.ColorDefault {
+			return "-"
+		}
+		return c.String()
+	}
+	return "[" + name(fg) + ":" + name(bg) + ":" + attrs + "]"
+}
+
+// titleKey returns key as shown in the key hints of a dialog title, which
+// return to the title's own color after it
+func (ui *FileExplorerUI) titleKey(key string) string {
+	return colorTag(ui.theme.Title) + key + "[-]"
+}
+
+// applyTheme recolors the explorer with the current theme. tview's default
+// styles are set too, so dialogs opened afterwards match it.
+func (ui *FileExplorerUI) applyTheme() {
+	t := ui.theme
+	tview.Styles.PrimitiveBackgroundColor = t.Background
+	tview.Styles.ContrastBackgroundColor = t.Selection
+	tview.Styles.MoreContrastBackgroundColor = t.Marked
+	tview.Styles.BorderColor = t.Text
+	tview.Styles.TitleColor = t.Text
+	tview.Styles.GraphicsColor = t.Text
+	tview.Styles.PrimaryTextColor = t.Text
+	tview.Styles.SecondaryTextColor = t.FooterKey
+	tview.Styles.TertiaryTextColor = t.Match
+	tview.Styles.InverseTextColor = t.Directory
+	tview.Styles.ContrastSecondaryTextColor = t.SelectionText
+
+	ui.header.SetBackgroundColor(t.HeaderBackground)
+	ui.contentPane.SetBackgroundColor(t.Background)
+	ui.contentPane.SetTextColor(t.Text)
+	ui.contentPane.SetBorderColor(t.PreviewBorder)
+	ui.contentPane.SetTitleColor(t.Text)
+	ui.footer.SetBackgroundColor(t.FooterBackground)
+	ui.footer.SetTextColor(t.FooterText)
+	ui.grid.SetBackgroundColor(t.Background)
+	ui.tree.SetBackgroundColor(t.Background)
+	ui.tree.SetTitleColor(t.Text)
+	ui.tree.SetGraphicsColor(t.Text)
+	if root := ui.tree.GetRoot(); root != nil {
+		root.Walk(func(node, _ *tview.TreeNode) bool {
+			ui.paintTreeNode(node)
+			return true
+		})
+	}
+	for _, p := range ui.panes {
+		p.table.SetBackgroundColor(t.Background)
+		p.table.SetTitleColor(t.Text)
+		p.table.SetSelectedStyle(tcell.StyleDefault.Background(t.Selection).Foreground(t.SelectionText))
+	}
+	ui.paintPaneBorders()
+	ui.renderFooter()
+}
@@ -0,0 +1,14 @@
+//go:build darwin || windows
+
+package trash
+
+// Supported reports whether the platform's trash is the freedesktop.org
+// one. The Trash of macOS and the Recycle Bin of Windows are kept by the
+// system in ways of their own.
+const Supported = false
+
+// Home fails with ErrUnsupported: moving entries into a freedesktop.org
+// trash here would hide them where the system's trash can't find them
+func Home() (string, error) {
+	return "", ErrUnsupported
+}
@@ -0,0 +1,24 @@
+//go:build !darwin && !windows
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Supported reports whether the platform's trash is the freedesktop.org one
+const Supported = true
+
+// Home returns the user's home trash directory
+func Home() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
@@ -0,0 +1,223 @@
+// Package trash reads and manipulates the user's trash can as described by
+// the freedesktop.org Trash specification. Only the home trash
+// ($XDG_DATA_HOME/Trash) is handled; per-volume trash directories are not.
+// The Trash of macOS and the Recycle Bin of Windows aren't handled either:
+// there Supported is false and the functions fail with ErrUnsupported.
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deletionDateLayout is the timestamp format used in .trashinfo files
+const deletionDateLayout = "2006-01-02T15:04:05"
+
+var (
+	// ErrOriginMissing is returned by Restore when the directory the item was
+	// deleted from no longer exists
+	ErrOriginMissing = errors.New("original location no longer exists")
+	// ErrExists is returned by Restore when the destination is already taken
+	ErrExists = errors.New("destination already exists")
+	// ErrUnsupported is returned on platforms whose trash isn't the
+	// freedesktop.org one, see Supported
+	ErrUnsupported = errors.New("the system trash is not supported on " + runtime.GOOS)
+)
+
+// Item is a single entry in the trash
+type Item struct {
+	Name         string    // name of the entry inside the trash
+	OriginalPath string    // absolute path the entry was deleted from
+	DeletedAt    time.Time // when the entry was moved to the trash
+	Root         string    // trash directory holding the entry
+}
+
+// FilePath returns the location of the trashed data
+func (it Item) FilePath() string {
+	return filepath.Join(it.Root, "files", it.Name)
+}
+
+// InfoPath returns the location of the item's .trashinfo metadata
+func (it Item) InfoPath() string {
+	return filepath.Join(it.Root, "info", it.Name+".trashinfo")
+}
+
+// List returns the items in the home trash, most recently deleted first.
+// Entries with unreadable or malformed metadata are skipped.
+func List() ([]Item, error) {
+	root, err := Home()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := os.ReadDir(filepath.Join(root, "info"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var items []Item
+	for _, entry := range infos {
+		name, ok := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		item, err := readInfo(root, name)
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// readInfo parses the .trashinfo file of the named entry
+func readInfo(root, name string) (Item, error) {
+	item := Item{Name: name, Root: root}
+
+	f, err := os.Open(item.InfoPath())
+	if err != nil {
+		return item, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	inSection := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "[Trash Info]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inSection || !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			path, err := url.PathUnescape(value)
+			if err != nil {
+				return item, fmt.Errorf("%s: bad Path: %w", item.InfoPath(), err)
+			}
+			item.OriginalPath = path
+		case "DeletionDate":
+			// A missing or malformed date is tolerated by the spec
+			item.DeletedAt, _ = time.ParseInLocation(deletionDateLayout, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return item, err
+	}
+	if item.OriginalPath == "" {
+		return item, fmt.Errorf("%s: missing Path", item.InfoPath())
+	}
+	if !filepath.IsAbs(item.OriginalPath) {
+		// Relative paths are only used by per-volume trash directories,
+		// which resolve against the volume root
+		item.OriginalPath = filepath.Join(filepath.Dir(root), item.OriginalPath)
+	}
+	return item, nil
+}
+
+// Reserve picks a free name in the home trash for the entry at path and
+// writes its metadata, creating the trash if needed. The caller then moves
+// the entry to the item's FilePath, or removes the InfoPath if that fails.
+func Reserve(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	root, err := Home()
+	if err != nil {
+		return Item{}, err
+	}
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o700); err != nil {
+			return Item{}, err
+		}
+	}
+
+	now := time.Now()
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: abs}).EscapedPath(), now.Format(deletionDateLayout))
+	base := filepath.Base(abs)
+	for i := 1; ; i++ {
+		item := Item{Name: base, OriginalPath: abs, DeletedAt: now, Root: root}
+		if i > 1 {
+			item.Name = fmt.Sprintf("%s.%d", base, i)
+		}
+		// Creating the info file exclusively claims the name
+		f, err := os.OpenFile(item.InfoPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		_, err = f.WriteString(info)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			// A leftover file without metadata may hold the name too
+			if _, statErr := os.Lstat(item.FilePath()); statErr == nil {
+				os.Remove(item.InfoPath())
+				continue
+			}
+			return item, nil
+		}
+		os.Remove(item.InfoPath())
+		return Item{}, err
+	}
+}
+
+// Restore moves the item back to its original path with move, which is
+// given where the item is kept and where it goes. os.Rename will do for
+// items trashed on their own filesystem, but not for those copied into the
+// home trash from others.
+func Restore(item Item, move func(src, dst string) error) error {
+	if _, err := os.Stat(filepath.Dir(item.OriginalPath)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrOriginMissing
+		}
+		return err
+	}
+	return RestoreTo(item, item.OriginalPath, move)
+}
+
+// RestoreTo moves the item out of the trash to dest, which must not exist,
+// with move as for Restore
+func RestoreTo(item Item, dest string, move func(src, dst string) error) error {
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("%s: %w", dest, ErrExists)
+	}
+	if err := move(item.FilePath(), dest); err != nil {
+		return err
+	}
+	return os.Remove(item.InfoPath())
+}
+
+// Purge permanently deletes the item and its metadata
+func Purge(item Item) error {
+	if err := os.RemoveAll(item.FilePath()); err != nil {
+		return err
+	}
+	if err := os.Remove(item.InfoPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/ops"
+	"github.com/aktagon/gofiles/trash"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// trashPage is the name of the trash browser overlay
+const trashPage = "trash"
+
+// showTrash opens a view listing the items in the trash, from which they
+// can be restored to their original location or purged for good
+func (ui *FileExplorerUI) showTrash() {
+	if !trash.Supported {
+		ui.showError(trash.ErrUnsupported)
+		return
+	}
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetTitle(fmt.Sprintf("Trash - %s Restore | %s Purge | %s Close",
+		ui.titleKey("Enter"), ui.titleKey("Delete"), ui.titleKey("Esc")))
+	table.SetBorderColor(ui.theme.Danger)
+	table.SetSelectable(true, false)
+	table.SetFixed(1, 0)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.DangerSelection).Foreground(ui.theme.SelectionText))
+
+	var items []trash.Item
+	reload := func() {
+		var err error
+		items, err = trash.List()
+		ui.fillTrashTable(table, items)
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		ui.setFooterStatus(fmt.Sprintf("%d items in trash", len(items)))
+	}
+
+	// selected returns the item under the cursor, if any
+	selected := func() (trash.Item, bool) {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(items) {
+			return trash.Item{}, false
+		}
+		return items[row-1], true
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if item, ok := selected(); ok {
+			ui.restoreTrashItem(item, reload)
+		}
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			ui.closePage(trashPage)
+			ui.pane.loadDirectory(ui.pane.path)
+			return nil
+		case tcell.KeyDelete:
+			if item, ok := selected(); ok {
+				ui.purgeTrashItem(item, reload)
+			}
+			return nil
+		}
+		return event
+	})
+
+	reload()
+	ui.showPage(trashPage, panel(table))
+}
+
+// fillTrashTable renders the trash items into table
+func (ui *FileExplorerUI) fillTrashTable(table *tview.Table, items []trash.Item) {
+	table.Clear()
+	table.SetCell(0, 0, tview.NewTableCell("Name").SetAttributes(tcell.AttrBold).SetSelectable(false))
+	table.SetCell(0, 1, tview.NewTableCell("Original Path").SetAttributes(tcell.AttrBold).SetSelectable(false))
+	table.SetCell(0, 2, tview.NewTableCell("Deleted").SetAttributes(tcell.AttrBold).SetSelectable(false))
+
+	for i, item := range items {
+		deleted := "-"
+		if !item.DeletedAt.IsZero() {
+			deleted = item.DeletedAt.Format("2006-01-02 15:04:05")
+		}
+		table.SetCell(i+1, 0, tview.NewTableCell(filepath.Base(item.OriginalPath)))
+		table.SetCell(i+1, 1, tview.NewTableCell(item.OriginalPath).SetExpansion(1))
+		table.SetCell(i+1, 2, tview.NewTableCell(deleted))
+	}
+
+	if len(items) == 0 {
+		table.SetCell(1, 0, tview.NewTableCell("(trash is empty)").SetTextColor(ui.theme.Dim).SetSelectable(false))
+		return
+	}
+	table.Select(1, 0)
+}
+
+// restoreTrashItem puts item back where it came from, asking for a new
+// destination when the original directory is gone. Items trashed from
+// other filesystems are copied back, in the background.
+func (ui *FileExplorerUI) restoreTrashItem(item trash.Item, reload func()) {
+	move := func(src, dst string) error { return ops.Untrash(ui.ctx, src, dst) }
+	restored := func(dest string, err error) {
+		ui.queueUpdateDraw(func() {
+			if err != nil {
+				ui.showError(err)
+				return
+			}
+			reload()
+			ui.setFooterStatus(tview.Escape("Restored " + dest))
+		})
+	}
+	ui.goBackground(func() {
+		err := trash.Restore(item, move)
+		if !errors.Is(err, trash.ErrOriginMissing) {
+			restored(item.OriginalPath, err)
+			return
+		}
+		ui.queueUpdateDraw(func() {
+			initial := filepath.Join(ui.pane.path, filepath.Base(item.OriginalPath))
+			ui.prompt("Original location is gone - restore to", initial, func(dest string) {
+				if dest == "" {
+					return
+				}
+				ui.goBackground(func() {
+					restored(dest, trash.RestoreTo(item, dest, move))
+				})
+			})
+		})
+	})
+}
+
+// purgeTrashItem permanently deletes item after confirmation
+func (ui *FileExplorerUI) purgeTrashItem(item trash.Item, reload func()) {
+	question := fmt.Sprintf("Permanently delete %s from the trash? This cannot be undone.", item.OriginalPath)
+	ui.confirmDanger(questionPurge, question, "Purge", []string{item.FilePath()}, func() {
+		if err := trash.Purge(item); err != nil {
+			ui.showError(err)
+			return
+		}
+		reload()
+		ui.setFooterStatus(tview.Escape("Purged " + item.OriginalPath))
+	})
+}
@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// treeDir is the directory behind a node of the directory tree
+type treeDir struct {
+	path   string
+	loaded bool // whether its subdirectories have been read
+}
+
+// setupTree creates the directory tree shown left of the listing. Its
+// subdirectories are read when a directory is first expanded. Right and
+// Left expand and collapse, Enter and clicks open a directory in the
+// listing, and Escape or Tab go back to the listing.
+func (ui *FileExplorerUI) setupTree() {
+	ui.tree = tview.NewTreeView()
+	ui.tree.SetBorder(true)
+	ui.tree.SetTitle("Tree")
+	ui.tree.SetFocusFunc(func() {
+		ui.tree.SetBorderColor(ui.theme.PaneBorder)
+	})
+	// Blur runs before the tree gives up the focus, so paintPaneBorders
+	// would still see it focused
+	ui.tree.SetBlurFunc(func() {
+		ui.tree.SetBorderColor(ui.theme.InactivePaneBorder)
+	})
+
+	ui.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		dir := node.GetReference().(*treeDir)
+		if err := ui.loadTreeNode(node, false); err != nil {
+			ui.showError(err)
+		}
+		node.Expand()
+		if dir.path != ui.pane.path {
+			ui.navigate(dir.path)
+		}
+	})
+	ui.tree.SetDoneFunc(func(tcell.Key) {
+		ui.setFocus(ui.pane.table)
+	})
+	ui.tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if ui.keymap[keyName(event)] == ActionTree {
+			ui.toggleTree()
+			return nil
+		}
+		node := ui.tree.GetCurrentNode()
+		if node == nil {
+			return event
+		}
+		switch {
+		case event.Key() == tcell.KeyRight || event.Rune() == 'l':
+			if err := ui.loadTreeNode(node, false); err != nil {
+				ui.showError(err)
+			}
+			node.Expand()
+		case event.Key() == tcell.KeyLeft || event.Rune() == 'h':
+			// Collapse, or else go to the parent
+			if node.IsExpanded() && len(node.GetChildren()) > 0 {
+				node.Collapse()
+			} else if path := ui.tree.GetPath(node); len(path) > 1 {
+				ui.tree.SetCurrentNode(path[len(path)-2])
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+}
+
+// newTreeNode creates a collapsed node for the directory at path
+func (ui *FileExplorerUI) newTreeNode(label, path string) *tview.TreeNode {
+	node := tview.NewTreeNode(tview.Escape(label))
+	node.SetReference(&treeDir{path: path})
+	node.Collapse()
+	ui.paintTreeNode(node)
+	return node
+}
+
+// paintTreeNode colors node with the theme
+func (ui *FileExplorerUI) paintTreeNode(node *tview.TreeNode) {
+	node.SetTextStyle(tcell.StyleDefault.Foreground(ui.theme.Directory).Background(ui.theme.Background))
+	node.SetSelectedTextStyle(tcell.StyleDefault.Foreground(ui.theme.SelectionText).Background(ui.theme.Selection))
+}
+
+// loadTreeNode reads the subdirectories of the directory behind node, unless
+// that has been done before and force isn't set. Nodes of subdirectories
+// that are still there are kept, along with what is expanded below them.
+func (ui *FileExplorerUI) loadTreeNode(node *tview.TreeNode, force bool) error {
+	dir := node.GetReference().(*treeDir)
+	if dir.loaded && !force {
+		return nil
+	}
+	dir.loaded = true
+	entries, err := core.ReadDir(ui.ctx, ui.fsys, dir.path)
+	if err != nil {
+		node.ClearChildren()
+		return err
+	}
+
+	old := map[string]*tview.TreeNode{}
+	for _, child := range node.GetChildren() {
+		old[child.GetReference().(*treeDir).path] = child
+	}
+	var children []*tview.TreeNode
+	for _, entry := range entries {
+		name := entry.Name()
+		if !ui.showHidden && core.IsHidden(name) {
+			continue
+		}
+		path := filepath.Join(dir.path, name)
+		if !entry.IsDir() {
+			// Follow symlinks to directories
+			if entry.Type()&fs.ModeSymlink == 0 {
+				continue
+			}
+			if !isDir(ui.fsys, path) {
+				continue
+			}
+		}
+		child := old[path]
+		if child == nil {
+			child = ui.newTreeNode(name, path)
+		}
+		children = append(children, child)
+	}
+	node.SetChildren(children)
+	return nil
+}
+
+// syncTree expands the tree down to the directory of the active pane and
+// rereads that directory's subdirectories. When the pane has gone to
+// another directory, it is selected. Path lists and archives leave the tree
+// as it is.
+func (ui *FileExplorerUI) syncTree() {
+	p := ui.pane
+	if !ui.showTree || p.inPathList() || inArchive(ui.fsys, p.path) {
+		return
+	}
+
+	top := filepath.VolumeName(p.path) + string(filepath.Separator)
+	root := ui.tree.GetRoot()
+	if root == nil || root.GetReference().(*treeDir).path != top {
+		root = ui.newTreeNode(top, top)
+		ui.tree.SetRoot(root)
+		ui.treePath = ""
+	}
+
+	node := root
+	if rel, err := filepath.Rel(top, p.path); err == nil && rel != "." {
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			dir := node.GetReference().(*treeDir)
+			ui.loadTreeNode(node, false)
+			node.Expand()
+			path := filepath.Join(dir.path, name)
+			var next *tview.TreeNode
+			for _, child := range node.GetChildren() {
+				if child.GetReference().(*treeDir).path == path {
+					next = child
+					break
+				}
+			}
+			if next == nil {
+				// Hidden, or created since its parent was read
+				next = ui.newTreeNode(name, path)
+				node.AddChild(next)
+			}
+			node = next
+		}
+	}
+	ui.loadTreeNode(node, true)
+
+	if p.path != ui.treePath {
+		ui.treePath = p.path
+		node.Expand()
+		ui.tree.SetCurrentNode(node)
+	}
+}
+
+// toggleTree shows the directory tree and moves the focus to it. With the
+// tree shown, it moves there from the listing, or hides the tree when
+// pressed in it.
+func (ui *FileExplorerUI) toggleTree() {
+	if ui.compact {
+		ui.setFooterError("The tree isn't shown in the compact layout")
+		return
+	}
+	switch {
+	case !ui.showTree:
+		ui.showTree = true
+		ui.arrangeGrid()
+		ui.syncTree()
+		ui.setFocus(ui.tree)
+	case ui.tree.HasFocus():
+		ui.showTree = false
+		ui.arrangeGrid()
+		ui.setFocus(ui.pane.table)
+	default:
+		ui.setFocus(ui.tree)
+	}
+}
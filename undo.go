@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aktagon/gofiles/ops"
+	"github.com/rivo/tview"
+)
+
+// undoLimit is how many operations can be undone
+const undoLimit = 100
+
+// undo reverses the most recent rename, move, move to the trash or
+// creation in the background, then refreshes the listings
+func (ui *FileExplorerUI) undo() {
+	if ui.refuseNonLocal() {
+		return
+	}
+	if _, ok := ui.undoLog.Last(); !ok {
+		ui.setFooterError("nothing to undo")
+		return
+	}
+	ui.goBackground(func() {
+		e, err := ui.undoLog.Undo(ui.ctx)
+		ui.queueUpdateDraw(func() {
+			ui.autoRefresh()
+			if err != nil {
+				ui.showError(fmt.Errorf("undo %s: %w", describeEntry(e), err))
+				return
+			}
+			ui.setFooterStatus(tview.Escape("Undid " + describeEntry(e)))
+		})
+	})
+}
+
+// describeEntry names a recorded operation, e.g. "rename of a.txt"
+func describeEntry(e ops.Entry) string {
+	name := filepath.Base(e.Src)
+	if e.Op == ops.OpCreate {
+		name = filepath.Base(e.Dst)
+	}
+	return fmt.Sprintf("%s of %s", e.Op, name)
+}
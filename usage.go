@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/aktagon/gofiles/core"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// usagePage is the name of the disk usage analyzer
+	usagePage = "usage"
+	// usageBarWidth is the width of the bars of the disk usage analyzer
+	usageBarWidth = 20
+	// usageRedrawInterval is how often the analyzer shows the progress of
+	// directories still being totalled
+	usageRedrawInterval = 250 * time.Millisecond
+)
+
+// usageEntry is an entry of the directory shown by the disk usage analyzer
+type usageEntry struct {
+	name string
+	dir  bool
+	size int64
+	scan *treeScan // totalling a directory, nil once done
+}
+
+// currentSize returns the size of e, or what has been counted so far of a
+// directory still being totalled
+func (e *usageEntry) currentSize() int64 {
+	if e.scan != nil {
+		return e.scan.progress().bytes
+	}
+	return e.size
+}
+
+// showUsage opens the disk usage analyzer on the current directory. It
+// lists the entries largest first, totalling the files below each
+// subdirectory with a pool of workers, with the share each takes up of the
+// directory. Enter or Right goes into a directory and Backspace or Left to
+// the parent; Enter on a file selects it in the listing. Esc stops a running
+// scan, or else closes the analyzer. Totals are kept while it is open, so
+// going back up doesn't scan again.
+func (ui *FileExplorerUI) showUsage() {
+	if ui.pane.inPathList() || inArchive(ui.fsys, ui.pane.path) {
+		ui.setFooterError("Disk usage needs a directory on disk")
+		return
+	}
+
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetBorderColor(ui.theme.ResultsBorder)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(tcell.StyleDefault.Background(ui.theme.Selection).Foreground(ui.theme.SelectionText))
+
+	sizes := map[string]int64{} // totals of the directories scanned so far
+	var (
+		dir     string
+		entries []*usageEntry
+		cancel  context.CancelFunc = func() {}
+		pending int
+		stopped bool
+	)
+
+	// draw lists the entries largest first, keeping the selected one selected
+	draw := func() {
+		var selected string
+		if row, _ := table.GetSelection(); row < len(entries) {
+			selected = entries[row].name
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			si, sj := entries[i].currentSize(), entries[j].currentSize()
+			if si != sj {
+				return si > sj
+			}
+			return entries[i].name < entries[j].name
+		})
+
+		var total, largest int64
+		for _, e := range entries {
+			total += e.currentSize()
+			largest = max(largest, e.currentSize())
+		}
+
+		table.Clear()
+		for row, e := range entries {
+			size := e.currentSize()
+			sizeText := formatSize(size)
+			if e.scan != nil {
+				sizeText = "≥" + sizeText
+			}
+			percent := "-"
+			if total > 0 {
+				percent = fmt.Sprintf("%.1f%%", float64(size)*100/float64(total))
+			}
+			name := tview.Escape(e.name)
+			nameColor := ui.theme.Text
+			if e.dir {
+				name += string(filepath.Separator)
+				nameColor = ui.theme.Directory
+			}
+			table.SetCell(row, 0, tview.NewTableCell(sizeText).SetAlign(tview.AlignRight))
+			table.SetCell(row, 1, tview.NewTableCell(percent).SetAlign(tview.AlignRight))
+			table.SetCell(row, 2, tview.NewTableCell(bar(size, largest, usageBarWidth)).
+				SetTextColor(ui.theme.Match))
+			table.SetCell(row, 3, tview.NewTableCell(name).SetTextColor(nameColor).SetExpansion(1))
+			if e.name == selected {
+				table.Select(row, 0)
+			}
+		}
+		if len(entries) == 0 {
+			table.SetCell(0, 0, tview.NewTableCell("(empty)").SetTextColor(ui.theme.Dim).SetSelectable(false))
+		}
+
+		status := "done"
+		switch {
+		case pending > 0 && stopped:
+			status = "stopped, totals incomplete"
+		case pending > 0:
+			status = fmt.Sprintf("scanning %d, Esc stops", pending)
+		}
+		table.SetTitle(fmt.Sprintf("Disk Usage - %s - %s %s(%s)[-]",
+			tview.Escape(dir), formatSize(total), colorTag(ui.theme.Muted), status))
+	}
+
+	// open lists path and totals its subdirectories in the background
+	var open func(path string)
+	open = func(path string) {
+		cancel()
+		children, err := core.ReadDir(ui.ctx, ui.fsys, path)
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+		dir, entries, pending, stopped = path, nil, 0, false
+
+		var scans []*usageEntry
+		for _, child := range children {
+			e := &usageEntry{name: child.Name(), dir: child.IsDir()}
+			if e.dir {
+				if size, ok := sizes[filepath.Join(path, e.name)]; ok {
+					e.size = size
+				} else {
+					e.scan = &treeScan{}
+					scans = append(scans, e)
+				}
+			} else if info, err := child.Info(); err == nil && info.Mode().IsRegular() {
+				e.size = info.Size()
+			}
+			entries = append(entries, e)
+		}
+		pending = len(scans)
+		table.Select(0, 0)
+		draw()
+		table.ScrollToBeginning()
+		if pending == 0 {
+			return
+		}
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(ui.ctx)
+		jobs := make(chan *usageEntry, len(scans))
+		for _, e := range scans {
+			jobs <- e
+		}
+		close(jobs)
+		for range min(runtime.NumCPU(), len(scans)) {
+			ui.goBackground(func() {
+				for e := range jobs {
+					size, err := e.scan.run(ctx, ui.fsys, []string{filepath.Join(path, e.name)})
+					ui.queueUpdateDraw(func() {
+						if ctx.Err() != nil || err != nil {
+							return // stopped, or left for another directory
+						}
+						e.size, e.scan = size.bytes, nil
+						sizes[filepath.Join(path, e.name)] = size.bytes
+						pending--
+						if pending == 0 {
+							cancel() // stops the progress updates
+						}
+						draw()
+					})
+				}
+			})
+		}
+
+		// Show the progress of the directories still being totalled
+		ui.goBackground(func() {
+			ticker := time.NewTicker(usageRedrawInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					ui.queueUpdateDraw(func() {
+						if ctx.Err() == nil && pending > 0 {
+							draw()
+						}
+					})
+				}
+			}
+		})
+	}
+
+	selectedEntry := func() *usageEntry {
+		row, _ := table.GetSelection()
+		if row >= len(entries) {
+			return nil
+		}
+		return entries[row]
+	}
+	goUp := func() {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		name := filepath.Base(dir)
+		open(parent)
+		for row, e := range entries {
+			if e.name == name {
+				table.Select(row, 0)
+			}
+		}
+	}
+	closeUsage := func() {
+		cancel()
+		ui.closePage(usagePage)
+	}
+
+	table.SetSelectedFunc(func(int, int) {
+		e := selectedEntry()
+		switch {
+		case e == nil:
+		case e.dir:
+			open(filepath.Join(dir, e.name))
+		default:
+			closeUsage()
+			ui.Reveal(filepath.Join(dir, e.name))
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			if pending > 0 && !stopped {
+				cancel()
+				stopped = true
+				draw()
+				return nil
+			}
+			closeUsage()
+		case event.Key() == tcell.KeyBackspace || event.Key() == tcell.KeyBackspace2 ||
+			event.Key() == tcell.KeyLeft || event.Rune() == 'h':
+			goUp()
+		case event.Key() == tcell.KeyRight || event.Rune() == 'l':
+			if e := selectedEntry(); e != nil && e.dir {
+				open(filepath.Join(dir, e.name))
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+
+	open(ui.pane.path)
+	if dir == "" {
+		return
+	}
+	ui.showPage(usagePage, panel(table))
+}
@@ -0,0 +1,415 @@
+// Package davfs browses WebDAV servers, such as Nextcloud, ownCloud or
+// Apache's mod_dav. Directories are listed with PROPFIND, files are read
+// with ranged GETs and written with PUT, and the attributes of listed
+// entries are remembered for a few seconds.
+package davfs
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// Config are the settings for reaching a server
+type Config struct {
+	// User and Password log in with basic authentication
+	User     string
+	Password string
+	// Prompt asks the user question, showing the answer as it is typed if
+	// echo is set. It is used for the password when the server asks for one
+	// that wasn't given.
+	Prompt func(question string, echo bool) (string, error)
+	// Client sends the requests; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// FS is the tree below a URL on a WebDAV server. It is safe for
+// concurrent use.
+type FS struct {
+	root *url.URL // ending in a slash
+	cfg  Config
+
+	attrs vfs.AttrCache // attributes of listed entries by name
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// Open returns the tree below the http or https URL root, after checking
+// that it is a collection. The password is asked for if the server wants
+// one and none was given.
+func Open(root string, cfg Config) (*FS, error) {
+	u, err := url.Parse(root)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	f := &FS{root: u, cfg: cfg}
+
+	info, err := f.Stat(".")
+	if errors.Is(err, errUnauthorized) && cfg.Prompt != nil && cfg.Password == "" {
+		if f.cfg.User == "" {
+			if f.cfg.User, err = cfg.Prompt(fmt.Sprintf("User for %s: ", u.Host), true); err != nil {
+				return nil, err
+			}
+		}
+		if f.cfg.Password, err = cfg.Prompt(fmt.Sprintf("%s@%s's password: ", f.cfg.User, u.Host), false); err != nil {
+			return nil, err
+		}
+		info, err = f.Stat(".")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u.Redacted(), err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a collection", u.Redacted())
+	}
+	return f, nil
+}
+
+// errUnauthorized is a request refused for want of a login
+var errUnauthorized = errors.New("login required")
+
+// url returns the URL of name, with a trailing slash for collections
+func (f *FS) url(name string, collection bool) *url.URL {
+	u := *f.root
+	if name != "." {
+		u.Path += name
+		if collection {
+			u.Path += "/"
+		}
+	}
+	return &u
+}
+
+// request sends a request for name, returning an error for responses other
+// than 2xx ones
+func (f *FS) request(method, name string, collection bool, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, f.url(name, collection).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if f.cfg.User != "" || f.cfg.Password != "" {
+		req.SetBasicAuth(f.cfg.User, f.cfg.Password)
+	}
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusConflict:
+		// A missing parent collection is a conflict
+		return nil, fs.ErrNotExist
+	case http.StatusUnauthorized:
+		return nil, errUnauthorized
+	case http.StatusForbidden:
+		return nil, fs.ErrPermission
+	case http.StatusMethodNotAllowed, http.StatusPreconditionFailed:
+		// MKCOL on an existing entry, or PUT with If-None-Match on one
+		return nil, fs.ErrExist
+	}
+	return nil, errors.New(resp.Status)
+}
+
+// propfindBody asks for the properties shown
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+
+// multistatus is the response to PROPFIND
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength int64  `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// propfind returns the properties of name at depth 0, or of its entries
+// too at depth 1, by unescaped path
+func (f *FS) propfind(name string, depth int) (map[string]fileInfo, error) {
+	header := http.Header{
+		"Depth":        {strconv.Itoa(depth)},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	}
+	resp, err := f.request("PROPFIND", name, depth > 0, header, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	infos := map[string]fileInfo{}
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		p := strings.TrimSuffix(href.Path, "/")
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			modTime, _ := http.ParseTime(ps.Prop.LastModified)
+			infos[p] = fileInfo{
+				name:    path.Base(p),
+				size:    ps.Prop.ContentLength,
+				modTime: modTime,
+				dir:     ps.Prop.ResourceType.Collection != nil,
+			}
+		}
+	}
+	return infos, nil
+}
+
+// fileInfo describes a resource
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.dir }
+func (i fileInfo) Sys() any           { return nil }
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if info, ok := f.attrs.Get(name); ok {
+		return info, nil
+	}
+	infos, err := f.propfind(name, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	for _, info := range infos {
+		if name != "." {
+			info.name = path.Base(name)
+		}
+		return info, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Lstat is Stat, WebDAV has no symbolic links
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	return f.Stat(name)
+}
+
+// ReadDir lists the collection name, remembering the attributes of its
+// entries
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	infos, err := f.propfind(name, 1)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	self := strings.TrimSuffix(f.url(name, false).Path, "/")
+	var entries []fs.DirEntry
+	for p, info := range infos {
+		// The collection itself is listed too
+		if p == self {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+		f.attrs.Put(path.Join(name, info.name), info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// Open opens the file name for reading. It is fetched as it is read, from
+// the offset last sought to, and can be read at offsets with ranged
+// requests.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return vfs.NewDir(name, info, func() ([]fs.DirEntry, error) {
+			return f.ReadDir(name)
+		}), nil
+	}
+	return vfs.NewRangeFile(name, info, func(start, end int64) (io.ReadCloser, error) {
+		rng := fmt.Sprintf("bytes=%d-", start)
+		if end >= 0 {
+			rng += strconv.FormatInt(end, 10)
+		}
+		resp, err := f.request(http.MethodGet, name, false, http.Header{"Range": {rng}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		// Servers may ignore the range and send everything
+		if resp.StatusCode != http.StatusPartialContent && start > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, start); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+		return resp.Body, nil
+	}), nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Mkdir creates the collection name. Servers have no permissions to give
+// it.
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	defer f.attrs.Forget()
+	resp, err := f.request("MKCOL", name, true, nil, nil)
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Create creates the file name, which must not exist, uploading what is
+// written as it is written. Close reports whether the upload succeeded.
+func (f *FS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := f.Stat(name); err == nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	pr, pw := io.Pipe()
+	u := &upload{pw: pw, done: make(chan error, 1)}
+	go func() {
+		defer f.attrs.Forget()
+		resp, err := f.request(http.MethodPut, name, false, http.Header{"If-None-Match": {"*"}}, pr)
+		if err == nil {
+			resp.Body.Close()
+		} else {
+			err = &fs.PathError{Op: "create", Path: name, Err: err}
+		}
+		pr.CloseWithError(err)
+		u.done <- err
+	}()
+	return u, nil
+}
+
+// upload is a file being written with PUT
+type upload struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (u *upload) Write(p []byte) (int, error) { return u.pw.Write(p) }
+
+func (u *upload) Close() error {
+	u.pw.Close()
+	return <-u.done
+}
+
+// Rename moves oldname to newname, replacing what is there
+func (f *FS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || !fs.ValidPath(newname) {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: fs.ErrInvalid}
+	}
+	defer f.attrs.Forget()
+	header := http.Header{
+		"Destination": {f.url(newname, false).String()},
+		"Overwrite":   {"T"},
+	}
+	resp, err := f.request("MOVE", oldname, false, header, nil)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Remove removes the file or empty collection name. DELETE removes
+// collections with everything in them, so they are listed first.
+func (f *FS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := f.Stat(name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	defer f.attrs.Forget()
+	resp, err := f.request(http.MethodDelete, name, info.IsDir(), nil, nil)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Chmod is unsupported, WebDAV has no permissions
+func (f *FS) Chmod(name string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: errors.ErrUnsupported}
+}
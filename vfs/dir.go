@@ -0,0 +1,110 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"sync"
+	"time"
+)
+
+// NewDir returns an open directory for file systems that list directories
+// whole, such as remote ones: list is called on its first ReadDir
+func NewDir(name string, info fs.FileInfo, list func() ([]fs.DirEntry, error)) fs.ReadDirFile {
+	return &dir{name: name, info: info, list: list}
+}
+
+type dir struct {
+	name    string
+	info    fs.FileInfo
+	list    func() ([]fs.DirEntry, error)
+	entries []fs.DirEntry
+	listed  bool
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error { return nil }
+
+// ReadDir returns the next n entries, or all the rest for n <= 0
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.listed {
+		entries, err := d.list()
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.listed = entries, true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// AttrTTL is how long AttrCache reuses the attributes of listed entries
+const AttrTTL = 5 * time.Second
+
+// AttrCache remembers the attributes of the entries of directories listed
+// whole, as remote file systems do, so stating them right after the listing
+// needs no round trip. The zero value is an empty cache; it is safe for
+// concurrent use.
+type AttrCache struct {
+	mu     sync.Mutex
+	attrs  map[string]attr // by the name of the entry
+	pruned time.Time       // when expired attributes were last dropped
+}
+
+// attr is an entry's attributes and when they were listed
+type attr struct {
+	info fs.FileInfo
+	at   time.Time
+}
+
+// Put remembers the attributes of the entry name. Attributes that expired
+// are dropped at most once every AttrTTL, so the cache holds little more
+// than the directories listed lately.
+func (c *AttrCache) Put(name string, info fs.FileInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.attrs == nil {
+		c.attrs = map[string]attr{}
+	}
+	if now.Sub(c.pruned) > AttrTTL {
+		maps.DeleteFunc(c.attrs, func(_ string, a attr) bool { return now.Sub(a.at) > AttrTTL })
+		c.pruned = now
+	}
+	c.attrs[name] = attr{info, now}
+}
+
+// Get returns the attributes of name remembered from a listing no longer
+// than AttrTTL ago
+func (c *AttrCache) Get(name string) (fs.FileInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a, ok := c.attrs[name]
+	if !ok || time.Since(a.at) > AttrTTL {
+		return nil, false
+	}
+	return a.info, true
+}
+
+// Forget drops the remembered attributes after a change
+func (c *AttrCache) Forget() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.attrs)
+}
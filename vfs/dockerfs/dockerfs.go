@@ -0,0 +1,376 @@
+// Package dockerfs browses the file systems of Docker containers through
+// the archive endpoints of the Docker Engine API, without running anything
+// in them. Entries are described with HEAD requests and read from the tar
+// archives the daemon sends for them. Archives of directories hold their
+// whole tree, so listing a large one takes as long as reading its headers.
+package dockerfs
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+const (
+	// defaultHost is the daemon's socket unless DOCKER_HOST says otherwise
+	defaultHost = "unix:///var/run/docker.sock"
+)
+
+// Client talks to a Docker daemon
+type Client struct {
+	http *http.Client
+	base string // URL the API paths are added to
+}
+
+// NewClient returns a client of the daemon at host, a unix:// socket or a
+// tcp:// address, by default that of DOCKER_HOST or the usual socket
+func NewClient(host string) (*Client, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = defaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("docker host: %w", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", u.Path)
+			},
+		}
+		return &Client{http: &http.Client{Transport: transport}, base: "http://docker"}, nil
+	case "tcp", "http":
+		return &Client{http: &http.Client{}, base: "http://" + u.Host}, nil
+	}
+	return nil, fmt.Errorf("docker host %s: unsupported scheme %q", host, u.Scheme)
+}
+
+// get sends a request for the API path p, returning an error for responses
+// other than 2xx ones
+func (c *Client) get(method, p string, query url.Values) (*http.Response, error) {
+	u := c.base + p
+	if query != nil {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fs.ErrNotExist
+	}
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&e) == nil && e.Message != "" {
+		return nil, errors.New(e.Message)
+	}
+	return nil, errors.New(resp.Status)
+}
+
+// Container is a running container
+type Container struct {
+	ID     string
+	Name   string
+	Image  string
+	Status string // e.g. "Up 2 hours"
+}
+
+// Containers lists the running containers
+func (c *Client) Containers() ([]Container, error) {
+	resp, err := c.get(http.MethodGet, "/containers/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var list []struct {
+		ID     string `json:"Id"`
+		Names  []string
+		Image  string
+		Status string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	containers := make([]Container, len(list))
+	for i, l := range list {
+		containers[i] = Container{ID: l.ID, Image: l.Image, Status: l.Status, Name: l.ID[:min(12, len(l.ID))]}
+		if len(l.Names) > 0 {
+			containers[i].Name = strings.TrimPrefix(l.Names[0], "/")
+		}
+	}
+	slices.SortFunc(containers, func(a, b Container) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return containers, nil
+}
+
+// FS is a container's file system. It can be read but not changed, and is
+// safe for concurrent use.
+type FS struct {
+	client    *Client
+	container string // ID
+
+	attrs vfs.AttrCache // attributes of listed entries by name
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// FS returns the file system of the container with the name or ID
+// container, after checking that it exists
+func (c *Client) FS(container string) (*FS, error) {
+	resp, err := c.get(http.MethodGet, "/containers/"+url.PathEscape(container)+"/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("container %s: %w", container, err)
+	}
+	defer resp.Body.Close()
+	var details struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, err
+	}
+	return &FS{client: c, container: details.ID}, nil
+}
+
+// archive requests the archive of name, or only its description with HEAD
+func (f *FS) archive(method, name string) (*http.Response, error) {
+	return f.client.get(method, "/containers/"+f.container+"/archive", url.Values{"path": {path.Join("/", name)}})
+}
+
+// pathStat is the description of an entry sent along with its archive
+type pathStat struct {
+	Name       string      `json:"name"`
+	Size       int64       `json:"size"`
+	Mode       fs.FileMode `json:"mode"`
+	Mtime      time.Time   `json:"mtime"`
+	LinkTarget string      `json:"linkTarget"`
+}
+
+// fileInfo is a pathStat as an fs.FileInfo
+type fileInfo struct{ s pathStat }
+
+func (i fileInfo) Name() string       { return i.s.Name }
+func (i fileInfo) Size() int64        { return i.s.Size }
+func (i fileInfo) Mode() fs.FileMode  { return i.s.Mode }
+func (i fileInfo) ModTime() time.Time { return i.s.Mtime }
+func (i fileInfo) IsDir() bool        { return i.s.Mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
+
+// stat describes name without following a symbolic link, returning the
+// path it resolves to as well
+func (f *FS) stat(op, name string) (fileInfo, string, error) {
+	if !fs.ValidPath(name) {
+		return fileInfo{}, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	resp, err := f.archive(http.MethodHead, name)
+	if err != nil {
+		return fileInfo{}, "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	resp.Body.Close()
+	var s pathStat
+	header, err := base64.StdEncoding.DecodeString(resp.Header.Get("X-Docker-Container-Path-Stat"))
+	if err == nil {
+		err = json.Unmarshal(header, &s)
+	}
+	if err != nil {
+		return fileInfo{}, "", &fs.PathError{Op: op, Path: name, Err: fmt.Errorf("bad path stat: %w", err)}
+	}
+	if name == "." {
+		s.Name = "/"
+	}
+	return fileInfo{s}, s.LinkTarget, nil
+}
+
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	if info, ok := f.attrs.Get(name); ok {
+		return info, nil
+	}
+	info, _, err := f.stat("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Stat describes name, following a symbolic link to the entry its path
+// resolves to
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if info, ok := f.attrs.Get(name); ok && info.Mode()&fs.ModeSymlink == 0 {
+		return info, nil
+	}
+	info, target, err := f.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink == 0 || target == "" {
+		return info, nil
+	}
+	resolved, _, err := f.stat("stat", vfs.Name(target))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	resolved.s.Name = info.Name()
+	return resolved, nil
+}
+
+// ReadDir lists the directory name from the headers of its archive,
+// remembering the attributes of its entries
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	resp, err := f.archive(http.MethodGet, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	defer resp.Body.Close()
+
+	// The archive is rooted at the directory itself, under its base name
+	var root string
+	var infos []fs.FileInfo
+	tr := tar.NewReader(resp.Body)
+	for first := true; ; first = false {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		entry := strings.Trim(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if first {
+			if !hdr.FileInfo().IsDir() {
+				return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+			}
+			root = entry
+			continue
+		}
+		if root != "" {
+			rest, ok := strings.CutPrefix(entry, root+"/")
+			if !ok {
+				continue
+			}
+			entry = rest
+		}
+		if entry != "" && !strings.Contains(entry, "/") {
+			infos = append(infos, hdr.FileInfo())
+		}
+	}
+
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+		f.attrs.Put(path.Join(name, info.Name()), info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// Open opens the file name for reading, as the only entry of its archive;
+// directories are listed whole when first read
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return vfs.NewDir(name, info, func() ([]fs.DirEntry, error) {
+			return f.ReadDir(name)
+		}), nil
+	}
+	resp, err := f.archive(http.MethodGet, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	tr := tar.NewReader(resp.Body)
+	// A link's archive holds the link; its target's is asked for by path
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			resp.Body.Close()
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			break
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			resp.Body.Close()
+			target := hdr.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join("/", path.Dir(name), target)
+			}
+			return f.Open(vfs.Name(target))
+		}
+	}
+	return &file{info: info, Reader: tr, body: resp.Body}, nil
+}
+
+// file is a file being read from its archive
+type file struct {
+	info fs.FileInfo
+	io.Reader
+	body io.Closer
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return f.body.Close() }
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Rename(oldname, newname string) error {
+	return &fs.PathError{Op: "rename", Path: oldname, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Chmod(name string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: vfs.ErrReadOnly}
+}
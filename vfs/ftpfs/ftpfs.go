@@ -0,0 +1,547 @@
+// Package ftpfs browses FTP servers in passive mode, in the clear or over
+// TLS. A connection runs one command at a time, so an FS keeps a few logged
+// in connections to hand out, and dials again when the server has dropped
+// one. Reads resume where they stopped when a transfer breaks off.
+package ftpfs
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/textproto"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/jlaffaye/ftp"
+)
+
+const (
+	// maxConns is how many connections are open to a server at once
+	maxConns = 4
+	// dialTimeout bounds connecting and logging in
+	dialTimeout = 30 * time.Second
+)
+
+// Config are the settings for connecting to a server. The zero value logs
+// in anonymously, or asks for the password of a named user, in the clear.
+type Config struct {
+	// Password is the user's password
+	Password string
+	// Prompt asks the user question, showing the answer as it is typed if
+	// echo is set. It is used for the password of a named user when none
+	// was given.
+	Prompt func(question string, echo bool) (string, error)
+	// TLS secures the connections, upgrading them with AUTH TLS unless
+	// ImplicitTLS is set, for servers speaking TLS from the start
+	TLS         *tls.Config
+	ImplicitTLS bool
+	// DisableEPSV sticks to PASV, for servers behind NAT that mishandle
+	// extended passive mode
+	DisableEPSV bool
+}
+
+// FS is an FTP server's file system. It is safe for concurrent use.
+type FS struct {
+	addr string
+	user string
+	cfg  Config
+
+	slots chan struct{} // held by each connection in use
+
+	mu    sync.Mutex
+	idle  []*ftp.ServerConn
+	attrs vfs.AttrCache // attributes of listed entries by remote path
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// Dial logs in to the server at addr, "host" or "host:port", as user, or
+// anonymously if user is empty. The first connection is made right away, so
+// the password prompt and errors come before anything is browsed.
+func Dial(addr, user string, cfg Config) (*FS, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := "21"
+		if cfg.TLS != nil && cfg.ImplicitTLS {
+			port = "990"
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+	if cfg.TLS != nil && cfg.TLS.ServerName == "" {
+		cfg.TLS = cfg.TLS.Clone()
+		cfg.TLS.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	switch {
+	case user == "":
+		user, cfg.Password = "anonymous", "anonymous"
+	case cfg.Password == "" && cfg.Prompt != nil:
+		password, err := cfg.Prompt(fmt.Sprintf("%s@%s's password: ", user, addr), false)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Password = password
+	}
+
+	f := &FS{addr: addr, user: user, cfg: cfg, slots: make(chan struct{}, maxConns)}
+	c, err := f.acquire()
+	if err != nil {
+		return nil, err
+	}
+	f.release(c, false)
+	return f, nil
+}
+
+// dial connects and logs in
+func (f *FS) dial() (*ftp.ServerConn, error) {
+	opts := []ftp.DialOption{
+		ftp.DialWithTimeout(dialTimeout),
+		ftp.DialWithDisabledEPSV(f.cfg.DisableEPSV),
+	}
+	switch {
+	case f.cfg.TLS != nil && f.cfg.ImplicitTLS:
+		opts = append(opts, ftp.DialWithTLS(f.cfg.TLS))
+	case f.cfg.TLS != nil:
+		opts = append(opts, ftp.DialWithExplicitTLS(f.cfg.TLS))
+	}
+	c, err := ftp.Dial(f.addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ftp %s: %w", f.addr, err)
+	}
+	if err := c.Login(f.user, f.cfg.Password); err != nil {
+		c.Quit()
+		return nil, fmt.Errorf("ftp %s: %w", f.addr, err)
+	}
+	return c, nil
+}
+
+// acquire returns an idle connection, or a new one, waiting while maxConns
+// are in use
+func (f *FS) acquire() (*ftp.ServerConn, error) {
+	f.slots <- struct{}{}
+	f.mu.Lock()
+	if n := len(f.idle); n > 0 {
+		c := f.idle[n-1]
+		f.idle = f.idle[:n-1]
+		f.mu.Unlock()
+		return c, nil
+	}
+	f.mu.Unlock()
+	c, err := f.dial()
+	if err != nil {
+		<-f.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+// release hands c back for reuse, or hangs it up if it is broken
+func (f *FS) release(c *ftp.ServerConn, broken bool) {
+	if broken {
+		c.Quit()
+	} else {
+		f.mu.Lock()
+		f.idle = append(f.idle, c)
+		f.mu.Unlock()
+	}
+	<-f.slots
+}
+
+// do runs op on a connection, trying once more on another if the
+// connection turned out to be gone, as idle ones are after a while
+func (f *FS) do(op func(c *ftp.ServerConn) error) error {
+	for retried := false; ; retried = true {
+		c, err := f.acquire()
+		if err != nil {
+			return err
+		}
+		err = op(c)
+		f.release(c, lost(err))
+		if !lost(err) || retried {
+			return err
+		}
+	}
+}
+
+// lost reports whether err means the connection is gone: anything but a
+// reply from the server, or a reply that it is closing the connection
+func lost(err error) bool {
+	if err == nil {
+		return false
+	}
+	var reply *textproto.Error
+	return !errors.As(err, &reply) || reply.Code == ftp.StatusNotAvailable
+}
+
+// Home returns the directory the user starts in
+func (f *FS) Home() (string, error) {
+	var dir string
+	err := f.do(func(c *ftp.ServerConn) (err error) {
+		dir, err = c.CurrentDir()
+		return err
+	})
+	return dir, err
+}
+
+// Close logs out of the idle connections
+func (f *FS) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.idle {
+		c.Quit()
+	}
+	f.idle = nil
+	return nil
+}
+
+// remote returns the remote path of name, or an error for an invalid name
+func remote(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join("/", name), nil
+}
+
+// fileInfo describes a listed entry
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
+
+// newFileInfo describes e; listings don't tell permissions apart
+func newFileInfo(e *ftp.Entry) fileInfo {
+	info := fileInfo{name: e.Name, size: int64(e.Size), modTime: e.Time, mode: 0o644}
+	switch e.Type {
+	case ftp.EntryTypeFolder:
+		info.mode = fs.ModeDir | 0o755
+	case ftp.EntryTypeLink:
+		info.mode = fs.ModeSymlink | 0o777
+	}
+	return info
+}
+
+// Lstat describes name from the listing of its directory, since not every
+// server can describe a single entry
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := remote("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	if p == "/" {
+		return fileInfo{name: "/", mode: fs.ModeDir | 0o755}, nil
+	}
+	if info, ok := f.attrs.Get(p); ok {
+		return info, nil
+	}
+	if _, err := f.ReadDir(path.Dir(name)); err != nil {
+		// A directory that can't be listed is taken not to exist
+		if !lost(err) {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "lstat", Path: p, Err: err}
+	}
+	if info, ok := f.attrs.Get(p); ok {
+		return info, nil
+	}
+	return nil, &fs.PathError{Op: "lstat", Path: p, Err: fs.ErrNotExist}
+}
+
+// Stat is Lstat, finding out what symbolic links point to by changing to
+// them as directories, or else asking for their size as files
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	info, err := f.Lstat(name)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return info, err
+	}
+	p, _ := remote("stat", name)
+	target := fileInfo{name: info.Name(), modTime: info.ModTime(), mode: 0o644}
+	err = f.do(func(c *ftp.ServerConn) error {
+		if c.ChangeDir(p) == nil {
+			target.mode = fs.ModeDir | 0o755
+			return nil
+		}
+		size, err := c.FileSize(p)
+		target.size = size
+		return err
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: err}
+	}
+	return target, nil
+}
+
+// ReadDir lists the directory name, remembering the attributes of its
+// entries
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := remote("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	var list []*ftp.Entry
+	err = f.do(func(c *ftp.ServerConn) (err error) {
+		list, err = c.List(p)
+		return err
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: p, Err: err}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(list))
+	for _, e := range list {
+		if e.Name == "." || e.Name == ".." {
+			continue
+		}
+		info := newFileInfo(e)
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+		f.attrs.Put(path.Join(p, info.name), info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// Open opens the file name for reading. It is transferred from the offset
+// last sought to as it is read; directories are listed whole when first
+// read.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return vfs.NewDir(name, info, func() ([]fs.DirEntry, error) {
+			return f.ReadDir(name)
+		}), nil
+	}
+	p, _ := remote("open", name)
+	return &file{fsys: f, path: p, info: info}, nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Mkdir creates the directory name. Servers don't tell an existing entry
+// apart from other failures, so that is checked first.
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := remote("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Lstat(name); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *ftp.ServerConn) error {
+		return c.MakeDir(p)
+	})
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: err}
+	}
+	return nil
+}
+
+// Create creates the file name, which must not exist, uploading what is
+// written as it is written. Close reports whether the upload succeeded.
+func (f *FS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	p, err := remote("create", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Lstat(name); err == nil {
+		return nil, &fs.PathError{Op: "create", Path: p, Err: fs.ErrExist}
+	}
+	c, err := f.acquire()
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	u := &upload{pw: pw, done: make(chan error, 1)}
+	go func() {
+		defer f.attrs.Forget()
+		err := c.Stor(p, pr)
+		f.release(c, lost(err))
+		if err != nil {
+			err = &fs.PathError{Op: "create", Path: p, Err: err}
+		}
+		pr.CloseWithError(err)
+		u.done <- err
+	}()
+	return u, nil
+}
+
+// upload is a file being stored
+type upload struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (u *upload) Write(p []byte) (int, error) { return u.pw.Write(p) }
+
+func (u *upload) Close() error {
+	u.pw.Close()
+	return <-u.done
+}
+
+func (f *FS) Rename(oldname, newname string) error {
+	oldp, err := remote("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newp, err := remote("rename", newname)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *ftp.ServerConn) error {
+		return c.Rename(oldp, newp)
+	})
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldp, Err: err}
+	}
+	return nil
+}
+
+func (f *FS) Remove(name string) error {
+	p, err := remote("remove", name)
+	if err != nil {
+		return err
+	}
+	info, err := f.Lstat(name)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *ftp.ServerConn) error {
+		if info.IsDir() {
+			return c.RemoveDir(p)
+		}
+		return c.Delete(p)
+	})
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: err}
+	}
+	return nil
+}
+
+// Chmod is unsupported, FTP has no standard command for it
+func (f *FS) Chmod(name string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: errors.ErrUnsupported}
+}
+
+// file is an open file, holding a connection while it is transferred
+type file struct {
+	fsys   *FS
+	path   string
+	info   fs.FileInfo
+	offset int64
+	conn   *ftp.ServerConn
+	resp   *ftp.Response // the transfer from offset, once started
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+// start begins transferring the file from offset
+func (f *file) start() error {
+	c, err := f.fsys.acquire()
+	if err != nil {
+		return err
+	}
+	resp, err := c.RetrFrom(f.path, uint64(f.offset))
+	if err != nil {
+		f.fsys.release(c, lost(err))
+		return err
+	}
+	f.conn, f.resp = c, resp
+	return nil
+}
+
+// stop ends the transfer, hanging up unless it was read to the end: servers
+// differ in how they take an aborted one
+func (f *file) stop() {
+	if f.resp == nil {
+		return
+	}
+	complete := f.offset >= f.info.Size()
+	err := f.resp.Close()
+	f.fsys.release(f.conn, !complete || err != nil)
+	f.conn, f.resp = nil, nil
+}
+
+// Read reads on from offset. A transfer that breaks off before the end is
+// started again from there, on a new connection, once in a row.
+func (f *file) Read(p []byte) (int, error) {
+	if f.offset >= f.info.Size() {
+		return 0, io.EOF
+	}
+	for retried := false; ; retried = true {
+		if f.resp == nil {
+			if err := f.start(); err != nil {
+				if lost(err) && !retried {
+					continue
+				}
+				return 0, &fs.PathError{Op: "read", Path: f.path, Err: err}
+			}
+		}
+		n, err := f.resp.Read(p)
+		f.offset += int64(n)
+		if err == nil {
+			return n, nil
+		}
+		f.stop()
+		switch {
+		case f.offset >= f.info.Size():
+			return n, io.EOF
+		case n > 0:
+			return n, nil
+		case retried:
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, &fs.PathError{Op: "read", Path: f.path, Err: err}
+		}
+	}
+}
+
+// Seek moves the offset the transfer starts from, stopping a running one
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset {
+		f.stop()
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Close() error {
+	f.stop()
+	return nil
+}
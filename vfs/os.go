@@ -0,0 +1,113 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// OS returns the local file system, rooted at the root directory. Files it
+// opens are *os.File, so they can be seeked and read at offsets.
+func OS() FS {
+	return osFS{}
+}
+
+type osFS struct{}
+
+// path returns the local path of name, or an error for an invalid name
+func (osFS) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return Path(name), nil
+}
+
+func (f osFS) Open(name string) (fs.File, error) {
+	path, err := f.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (f osFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := f.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func (f osFS) Lstat(name string) (fs.FileInfo, error) {
+	path, err := f.path("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Lstat(path)
+}
+
+func (f osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := f.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(path)
+}
+
+func (f osFS) ReadFile(name string) ([]byte, error) {
+	path, err := f.path("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (f osFS) Mkdir(name string, perm fs.FileMode) error {
+	path, err := f.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(path, perm)
+}
+
+func (f osFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	path, err := f.path("create", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+}
+
+func (f osFS) Rename(oldname, newname string) error {
+	oldpath, err := f.path("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newpath, err := f.path("rename", newname)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+func (f osFS) Remove(name string) error {
+	path, err := f.path("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (f osFS) Chmod(name string, mode fs.FileMode) error {
+	path, err := f.path("chmod", name)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
+
+// IsLocal reports whether fsys is the local file system returned by OS
+func IsLocal(fsys FS) bool {
+	_, ok := fsys.(osFS)
+	return ok
+}
@@ -0,0 +1,83 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// NewRangeFile returns an open file for file systems that fetch byte ranges
+// of files, such as HTTP-based ones. get returns the bytes from start to
+// end inclusive, or to the end of the file if end is negative; it may
+// return more than asked, but must start at start. The file is fetched
+// from the offset last sought to as it is read, and can be read at offsets.
+func NewRangeFile(name string, info fs.FileInfo, get func(start, end int64) (io.ReadCloser, error)) fs.File {
+	return &rangeFile{name: name, info: info, get: get}
+}
+
+type rangeFile struct {
+	name   string
+	info   fs.FileInfo
+	get    func(start, end int64) (io.ReadCloser, error)
+	offset int64
+	body   io.ReadCloser // the rest of the file from offset, once requested
+}
+
+func (f *rangeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *rangeFile) Read(p []byte) (int, error) {
+	if f.offset >= f.info.Size() {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		body, err := f.get(f.offset, -1)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.body = body
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *rangeFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.info.Size() {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), f.info.Size())
+	body, err := f.get(off, end-1)
+	if err != nil {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, p[:end-off])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *rangeFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *rangeFile) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	return f.body.Close()
+}
@@ -0,0 +1,130 @@
+package s3fs
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials sign requests on behalf of an AWS user
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // for temporary credentials
+}
+
+// Config are the settings for reaching a bucket
+type Config struct {
+	Credentials
+	// Region is the bucket's region, us-east-1 if empty
+	Region string
+	// Endpoint is the URL of an S3-compatible service, such as MinIO,
+	// instead of AWS. Buckets are then addressed by path.
+	Endpoint string
+	// Client sends the requests; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// LoadConfig reads the settings the AWS command line tools use: the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION
+// (or AWS_DEFAULT_REGION) and AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL)
+// environment variables, or else the AWS_PROFILE (or default) profile in
+// ~/.aws/credentials and ~/.aws/config
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		Credentials: Credentials{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		Region:   firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		Endpoint: firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+	}
+
+	profile := firstEnv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	home, _ := os.UserHomeDir()
+	if cfg.AccessKey == "" {
+		file := firstEnv("AWS_SHARED_CREDENTIALS_FILE")
+		if file == "" {
+			file = filepath.Join(home, ".aws", "credentials")
+		}
+		values, err := readProfile(file, profile)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.AccessKey = values["aws_access_key_id"]
+		cfg.SecretKey = values["aws_secret_access_key"]
+		cfg.SessionToken = values["aws_session_token"]
+	}
+	if cfg.Region == "" || cfg.Endpoint == "" {
+		file := firstEnv("AWS_CONFIG_FILE")
+		if file == "" {
+			file = filepath.Join(home, ".aws", "config")
+		}
+		section := "profile " + profile
+		if profile == "default" {
+			section = profile
+		}
+		values, err := readProfile(file, section)
+		if err != nil {
+			return cfg, err
+		}
+		if cfg.Region == "" {
+			cfg.Region = values["region"]
+		}
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = values["endpoint_url"]
+		}
+	}
+
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return cfg, errors.New("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure ~/.aws/credentials")
+	}
+	return cfg, nil
+}
+
+// firstEnv returns the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// readProfile returns the settings in the [section] of an AWS INI file. A
+// missing file has no settings.
+func readProfile(file, section string) (map[string]string, error) {
+	values := map[string]string{}
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	in := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			in = strings.TrimSpace(line[1:len(line)-1]) == section
+		case in:
+			if name, value, ok := strings.Cut(line, "="); ok {
+				values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return values, scanner.Err()
+}
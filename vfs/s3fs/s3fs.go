@@ -0,0 +1,308 @@
+// Package s3fs browses an S3 bucket, or one of an S3-compatible service,
+// as a read-only file system. Keys are split at slashes into directories,
+// the way the S3 console shows them, and files are read with ranged GETs,
+// so previews and seeks only fetch what they need.
+package s3fs
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aktagon/gofiles/vfs"
+)
+
+// FS is a bucket. It is safe for concurrent use.
+type FS struct {
+	bucket string
+	cfg    Config
+	base   *url.URL // the bucket's URL, ending in a slash
+
+	attrs vfs.AttrCache // attributes of listed entries by name
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// Open returns the bucket named bucket, after checking that it can be
+// listed
+func Open(bucket string, cfg Config) (*FS, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	// Names with dots don't fit the certificates of bucket host names
+	var base string
+	switch {
+	case cfg.Endpoint != "":
+		base = strings.TrimSuffix(cfg.Endpoint, "/") + "/" + bucket + "/"
+	case strings.Contains(bucket, "."):
+		base = "https://s3." + cfg.Region + ".amazonaws.com/" + bucket + "/"
+	default:
+		base = "https://" + bucket + ".s3." + cfg.Region + ".amazonaws.com/"
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	f := &FS{bucket: bucket, cfg: cfg, base: u}
+	if _, err := f.list("", "", 1); err != nil {
+		return nil, fmt.Errorf("s3://%s: %w", bucket, err)
+	}
+	return f, nil
+}
+
+// request sends a signed request for key, the bucket itself if empty
+func (f *FS) request(method, key string, query url.Values, header http.Header) (*http.Response, error) {
+	u := *f.base
+	u.Path += key
+	u.RawPath = escapePath(u.Path)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	f.cfg.sign(req, f.cfg.Region, time.Now())
+
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, responseError(resp)
+}
+
+// responseError describes a failed request, as fs errors where they fit
+func responseError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fs.ErrNotExist
+	case http.StatusForbidden:
+		return fs.ErrPermission
+	}
+	var e struct {
+		Code    string
+		Message string
+	}
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&e) == nil && e.Code != "" {
+		return fmt.Errorf("%s: %s", e.Code, e.Message)
+	}
+	return errors.New(resp.Status)
+}
+
+// listing is a page of ListObjectsV2 results
+type listing struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		LastModified time.Time
+		Size         int64
+	}
+	CommonPrefixes []struct {
+		Prefix string
+	}
+}
+
+// list returns a page of the keys starting with prefix, up to the next
+// slash, continuing from token
+func (f *FS) list(prefix, token string, max int) (*listing, error) {
+	query := url.Values{"list-type": {"2"}, "delimiter": {"/"}, "prefix": {prefix}}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	if max > 0 {
+		query.Set("max-keys", strconv.Itoa(max))
+	}
+	resp, err := f.request(http.MethodGet, "", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var l listing
+	if err := xml.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// fileInfo describes an object, or a common prefix as a directory
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return i.dir }
+func (i fileInfo) Sys() any           { return nil }
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// Stat describes the object name, or else the directory of the keys
+// starting with name and a slash
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return fileInfo{name: f.bucket, dir: true}, nil
+	}
+	if info, ok := f.attrs.Get(name); ok {
+		return info, nil
+	}
+
+	resp, err := f.request(http.MethodHead, name, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+		return fileInfo{name: path.Base(name), size: resp.ContentLength, modTime: modTime}, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	l, err := f.list(name+"/", "", 1)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if len(l.Contents) == 0 && len(l.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fileInfo{name: path.Base(name), dir: true}, nil
+}
+
+// Lstat is Stat, buckets have no symbolic links
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	return f.Stat(name)
+}
+
+// ReadDir lists the keys below the directory name, page by page,
+// remembering their attributes
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	var infos []fileInfo
+	for token := ""; ; {
+		l, err := f.list(prefix, token, 0)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		for _, p := range l.CommonPrefixes {
+			dir := strings.TrimSuffix(strings.TrimPrefix(p.Prefix, prefix), "/")
+			if dir != "" {
+				infos = append(infos, fileInfo{name: dir, dir: true})
+			}
+		}
+		for _, c := range l.Contents {
+			// A key ending in a slash marks an empty directory
+			if file := strings.TrimPrefix(c.Key, prefix); file != "" && !strings.Contains(file, "/") {
+				infos = append(infos, fileInfo{name: file, size: c.Size, modTime: c.LastModified})
+			}
+		}
+		if !l.IsTruncated || l.NextContinuationToken == "" {
+			break
+		}
+		token = l.NextContinuationToken
+	}
+	if len(infos) == 0 && name != "." {
+		// Only an existing directory may be empty
+		if _, err := f.Stat(name); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+		f.attrs.Put(path.Join(name, info.name), info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// Open opens the object name for reading. It is fetched as it is read,
+// from the offset last sought to, and can be read at offsets with ranged
+// requests.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return vfs.NewDir(name, info, func() ([]fs.DirEntry, error) {
+			return f.ReadDir(name)
+		}), nil
+	}
+	return vfs.NewRangeFile(name, info, func(start, end int64) (io.ReadCloser, error) {
+		rng := fmt.Sprintf("bytes=%d-", start)
+		if end >= 0 {
+			rng += strconv.FormatInt(end, 10)
+		}
+		resp, err := f.request(http.MethodGet, name, nil, http.Header{"Range": {rng}})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}), nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Rename(oldname, newname string) error {
+	return &fs.PathError{Op: "rename", Path: oldname, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: vfs.ErrReadOnly}
+}
+
+func (f *FS) Chmod(name string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: vfs.ErrReadOnly}
+}
@@ -0,0 +1,164 @@
+package sftpfs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Config are the settings for connecting to a host. The zero value uses
+// the SSH agent and the user's unencrypted keys, and only connects to hosts
+// in ~/.ssh/known_hosts.
+type Config struct {
+	// Prompt asks the user question, showing the answer as it is typed if
+	// echo is set. It is used for keyboard-interactive and password
+	// authentication, the passphrases of keys and to accept unknown hosts,
+	// which are then added to the known hosts.
+	Prompt func(question string, echo bool) (string, error)
+	// KnownHosts is the file host keys are checked against, by default
+	// ~/.ssh/known_hosts
+	KnownHosts string
+	// Identities are the private key files tried, by default the usual ones
+	// in ~/.ssh
+	Identities []string
+}
+
+// defaultIdentities are the key files in ~/.ssh tried unless told otherwise
+var defaultIdentities = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// clientConfig returns the SSH settings for connecting to addr as user
+func (cfg Config) clientConfig(addr, user string) *ssh.ClientConfig {
+	home, _ := os.UserHomeDir()
+	if cfg.KnownHosts == "" {
+		cfg.KnownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	if cfg.Identities == nil {
+		for _, name := range defaultIdentities {
+			cfg.Identities = append(cfg.Identities, filepath.Join(home, ".ssh", name))
+		}
+	}
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	// Every public key is offered in one method, a client tries each once
+	auth := []ssh.AuthMethod{ssh.PublicKeysCallback(cfg.signers)}
+	if cfg.Prompt != nil {
+		auth = append(auth,
+			ssh.KeyboardInteractive(cfg.challenge),
+			ssh.PasswordCallback(func() (string, error) {
+				return cfg.Prompt(fmt.Sprintf("%s@%s's password: ", user, addr), false)
+			}),
+		)
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: cfg.checkHostKey,
+	}
+}
+
+// signers returns the keys held by the SSH agent and those in the key
+// files. Encrypted key files are only unlocked, asking for their
+// passphrase, when the agent has no keys.
+func (cfg Config) signers() ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	unlock := len(signers) == 0 && cfg.Prompt != nil
+	for _, file := range cfg.Identities {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) && unlock {
+			passphrase, perr := cfg.Prompt(fmt.Sprintf("Passphrase for %s: ", file), false)
+			if perr != nil {
+				return nil, perr
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+		}
+		if err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, nil
+}
+
+// challenge answers keyboard-interactive questions with Prompt
+func (cfg Config) challenge(name, instruction string, questions []string, echos []bool) ([]string, error) {
+	if instruction = strings.TrimSpace(instruction); instruction != "" {
+		if _, err := cfg.Prompt(instruction+" (Enter to continue) ", true); err != nil {
+			return nil, err
+		}
+	}
+	answers := make([]string, len(questions))
+	for i, q := range questions {
+		answer, err := cfg.Prompt(q, echos[i])
+		if err != nil {
+			return nil, err
+		}
+		answers[i] = answer
+	}
+	return answers, nil
+}
+
+// checkHostKey accepts the hosts in the known hosts file. Unknown hosts
+// are accepted, and remembered, if the user agrees; a host whose key has
+// changed is refused.
+func (cfg Config) checkHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if _, err := os.Stat(cfg.KnownHosts); err == nil {
+		check, err := knownhosts.New(cfg.KnownHosts)
+		if err != nil {
+			return err
+		}
+		err = check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+	}
+
+	if cfg.Prompt == nil {
+		return fmt.Errorf("%s is not a known host", hostname)
+	}
+	answer, err := cfg.Prompt(fmt.Sprintf("The authenticity of host %s can't be established.\n%s key fingerprint is %s.\nAre you sure you want to continue connecting (yes/no)? ",
+		hostname, key.Type(), ssh.FingerprintSHA256(key)), true)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+		return fmt.Errorf("host key of %s not accepted", hostname)
+	}
+	return addKnownHost(cfg.KnownHosts, hostname, key)
+}
+
+// addKnownHost appends the key of hostname to the known hosts file
+func addKnownHost(file, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(f, knownhosts.Line([]string{hostname}, key))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
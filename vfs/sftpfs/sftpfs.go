@@ -0,0 +1,362 @@
+// Package sftpfs browses remote hosts over SFTP. An FS keeps one SSH
+// connection to the host, dialing it again when it is lost, and remembers
+// the attributes of the entries it lists for a few seconds, so looking at
+// what was just listed doesn't wait for the network.
+package sftpfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aktagon/gofiles/vfs"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// FS is a remote host's file system. It is safe for concurrent use.
+type FS struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu        sync.Mutex
+	conn      *ssh.Client
+	client    *sftp.Client
+	connected bool          // set once the first connection is made
+	attrs     vfs.AttrCache // lstat results by remote path
+}
+
+var _ vfs.FS = (*FS)(nil)
+
+// Dial connects to the host at addr, "host" or "host:port", as user. The
+// connection is made right away, so authentication prompts and errors come
+// before anything is browsed. The answers are kept to connect again without
+// asking; questions that only come up then are errors.
+func Dial(addr, user string, cfg Config) (*FS, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	f := &FS{addr: addr}
+	cfg.Prompt = f.remember(cfg.Prompt)
+	f.config = cfg.clientConfig(addr, user)
+	if _, err := f.sftp(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.connected = true
+	f.mu.Unlock()
+	return f, nil
+}
+
+// remember wraps prompt to give the same answers when connecting again,
+// when the terminal may no longer be free to ask. It is only called while
+// connecting, with mu held.
+func (f *FS) remember(prompt func(question string, echo bool) (string, error)) func(question string, echo bool) (string, error) {
+	if prompt == nil {
+		return nil
+	}
+	answers := map[string]string{}
+	return func(question string, echo bool) (string, error) {
+		if answer, ok := answers[question]; ok {
+			return answer, nil
+		}
+		if f.connected {
+			return "", fmt.Errorf("sftp %s: can't ask %q again", f.addr, strings.TrimSpace(question))
+		}
+		answer, err := prompt(question, echo)
+		if err == nil {
+			answers[question] = answer
+		}
+		return answer, err
+	}
+}
+
+// Home returns the remote directory the user starts in, their home
+// directory on most servers
+func (f *FS) Home() (string, error) {
+	var dir string
+	err := f.do(func(c *sftp.Client) (err error) {
+		dir, err = c.Getwd()
+		return err
+	})
+	return dir, err
+}
+
+// Close closes the connection
+func (f *FS) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conn == nil {
+		return nil
+	}
+	f.client.Close()
+	err := f.conn.Close()
+	f.conn, f.client = nil, nil
+	return err
+}
+
+// sftp returns the SFTP session, connecting first if there is none
+func (f *FS) sftp() (*sftp.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.client != nil {
+		return f.client, nil
+	}
+	conn, err := ssh.Dial("tcp", f.addr, f.config)
+	if err != nil {
+		return nil, fmt.Errorf("sftp %s: %w", f.addr, err)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sftp %s: %w", f.addr, err)
+	}
+	f.conn, f.client = conn, client
+	// Forget the connection once it drops, so the next call dials again
+	go func() {
+		client.Wait()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.client == client {
+			f.conn.Close()
+			f.conn, f.client = nil, nil
+		}
+	}()
+	return client, nil
+}
+
+// do runs op with the SFTP session, trying once more on a new connection if
+// the connection was lost on the way
+func (f *FS) do(op func(c *sftp.Client) error) error {
+	c, err := f.sftp()
+	if err != nil {
+		return err
+	}
+	err = op(c)
+	if !lost(err) {
+		return err
+	}
+	f.mu.Lock()
+	if f.client == c {
+		f.conn.Close()
+		f.conn, f.client = nil, nil
+	}
+	f.mu.Unlock()
+	if c, err = f.sftp(); err != nil {
+		return err
+	}
+	return op(c)
+}
+
+// lost reports whether err means the connection is gone
+func lost(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
+
+// remote returns the remote path of name, or an error for an invalid name
+func remote(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join("/", name), nil
+}
+
+// pathErr gives errors that don't say which path they are about one
+func pathErr(op, name string, err error) error {
+	var pe *fs.PathError
+	if err == nil || errors.As(err, &pe) {
+		return err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := remote("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	if info, ok := f.attrs.Get(p); ok && info.Mode()&fs.ModeSymlink == 0 {
+		return info, nil
+	}
+	var info fs.FileInfo
+	err = f.do(func(c *sftp.Client) (err error) {
+		info, err = c.Stat(p)
+		return err
+	})
+	return info, pathErr("stat", p, err)
+}
+
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := remote("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	if info, ok := f.attrs.Get(p); ok {
+		return info, nil
+	}
+	var info fs.FileInfo
+	err = f.do(func(c *sftp.Client) (err error) {
+		info, err = c.Lstat(p)
+		return err
+	})
+	return info, pathErr("lstat", p, err)
+}
+
+// ReadDir lists the directory name in one round of requests, remembering
+// the attributes of its entries
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := remote("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	err = f.do(func(c *sftp.Client) (err error) {
+		infos, err = c.ReadDir(p)
+		return err
+	})
+	if err != nil {
+		return nil, pathErr("readdir", p, err)
+	}
+
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fs.FileInfoToDirEntry(info)
+		f.attrs.Put(path.Join(p, info.Name()), info)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// Open opens the file name for reading. Files can be seeked and read at
+// offsets; directories are listed whole when first read.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return vfs.NewDir(name, info, func() ([]fs.DirEntry, error) {
+			return f.ReadDir(name)
+		}), nil
+	}
+	p, _ := remote("open", name)
+	var file *sftp.File
+	err = f.do(func(c *sftp.Client) (err error) {
+		file, err = c.Open(p)
+		return err
+	})
+	if err != nil {
+		return nil, pathErr("open", p, err)
+	}
+	return file, nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Mkdir creates the directory name. Servers don't tell an existing entry
+// apart from other failures, so that is checked first.
+func (f *FS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := remote("mkdir", name)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	if _, err := f.Lstat(name); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+	}
+	err = f.do(func(c *sftp.Client) error {
+		if err := c.Mkdir(p); err != nil {
+			return err
+		}
+		return c.Chmod(p, perm)
+	})
+	return pathErr("mkdir", p, err)
+}
+
+// Create creates the file name, which must not exist, for writing
+func (f *FS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	p, err := remote("create", name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.attrs.Forget()
+	if _, err := f.Lstat(name); err == nil {
+		return nil, &fs.PathError{Op: "create", Path: p, Err: fs.ErrExist}
+	}
+	var file *sftp.File
+	err = f.do(func(c *sftp.Client) (err error) {
+		file, err = c.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+		if err != nil {
+			return err
+		}
+		return file.Chmod(perm)
+	})
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, pathErr("create", p, err)
+	}
+	return file, nil
+}
+
+// Rename renames oldname to newname, with the POSIX semantics of replacing
+// newname where the server supports it
+func (f *FS) Rename(oldname, newname string) error {
+	oldp, err := remote("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newp, err := remote("rename", newname)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *sftp.Client) error {
+		if _, ok := c.HasExtension("posix-rename@openssh.com"); ok {
+			return c.PosixRename(oldp, newp)
+		}
+		return c.Rename(oldp, newp)
+	})
+	return pathErr("rename", oldp, err)
+}
+
+func (f *FS) Remove(name string) error {
+	p, err := remote("remove", name)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *sftp.Client) error {
+		return c.Remove(p)
+	})
+	return pathErr("remove", p, err)
+}
+
+func (f *FS) Chmod(name string, mode fs.FileMode) error {
+	p, err := remote("chmod", name)
+	if err != nil {
+		return err
+	}
+	defer f.attrs.Forget()
+	err = f.do(func(c *sftp.Client) error {
+		return c.Chmod(p, mode)
+	})
+	return pathErr("chmod", p, err)
+}
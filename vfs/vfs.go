@@ -0,0 +1,111 @@
+// Package vfs defines the file system the explorer browses. FS is an io/fs
+// file system extended with the changes the explorer makes itself, so the
+// local disk, an embedded or zip file system, a remote backend or an
+// in-memory file system for tests can be browsed alike.
+//
+// Names are io/fs names: slash-separated and unrooted, "." being the root.
+// Name and Path convert between them and absolute local paths.
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrReadOnly is returned for changes to file systems that can only be read
+var ErrReadOnly = errors.New("read-only file system")
+
+// FS is a file system that can be listed, read and changed
+type FS interface {
+	fs.StatFS
+	fs.ReadDirFS
+	fs.ReadFileFS
+
+	// Lstat is Stat, but describes a symbolic link rather than its target
+	Lstat(name string) (fs.FileInfo, error)
+	// Mkdir creates the directory name, failing with fs.ErrExist if it exists
+	Mkdir(name string, perm fs.FileMode) error
+	// Create creates the file name for writing, failing with fs.ErrExist if
+	// it exists
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	// Rename renames oldname to newname, replacing a file at newname
+	Rename(oldname, newname string) error
+	// Remove removes the file or empty directory name
+	Remove(name string) error
+	// Chmod changes the mode of name
+	Chmod(name string, mode fs.FileMode) error
+}
+
+// Name returns the name of the absolute local path in a file system rooted
+// at the root directory, e.g. "home/me" for "/home/me"
+func Name(path string) string {
+	name := strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// Path returns the absolute local path of name, the inverse of Name
+func Path(name string) string {
+	if runtime.GOOS == "windows" {
+		if strings.HasSuffix(name, ":") {
+			return name + `\`
+		}
+		return filepath.FromSlash(name)
+	}
+	if name == "." {
+		return "/"
+	}
+	return "/" + name
+}
+
+// ReadOnly makes an FS of fsys, such as an embed.FS, a zip.Reader or a
+// fstest.MapFS, whose changes fail with ErrReadOnly
+func ReadOnly(fsys fs.FS) FS {
+	return readOnly{fsys}
+}
+
+type readOnly struct {
+	fs.FS
+}
+
+func (r readOnly) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.FS, name)
+}
+
+func (r readOnly) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(r.FS, name)
+}
+
+func (r readOnly) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.FS, name)
+}
+
+func (r readOnly) Lstat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.FS, name)
+}
+
+func (readOnly) Mkdir(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: ErrReadOnly}
+}
+
+func (readOnly) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: ErrReadOnly}
+}
+
+func (readOnly) Rename(oldname, newname string) error {
+	return &fs.PathError{Op: "rename", Path: oldname, Err: ErrReadOnly}
+}
+
+func (readOnly) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+func (readOnly) Chmod(name string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "chmod", Path: name, Err: ErrReadOnly}
+}
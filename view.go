@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ExplorerView is the file explorer as a primitive that other applications
+// place in their own layouts, e.g. as a panel of a tview.Flex. The
+// explorer keeps its dialogs within the view.
+type ExplorerView struct {
+	ui *FileExplorerUI
+}
+
+var _ tview.Primitive = (*ExplorerView)(nil)
+
+// Host is what an embedded explorer needs of the application showing it
+type Host interface {
+	// QueueUpdateDraw runs f on the application's event goroutine and
+	// redraws the screen; it is how background work updates the view
+	QueueUpdateDraw(f func())
+	// Suspend suspends the screen while f runs programs that take over
+	// the terminal, such as the editor, reporting whether it could
+	Suspend(f func()) bool
+}
+
+// AppHost returns app as the host of an embedded explorer
+func AppHost(app *tview.Application) Host {
+	return appHost{app}
+}
+
+// appHost is a tview.Application as a Host
+type appHost struct {
+	app *tview.Application
+}
+
+func (h appHost) QueueUpdateDraw(f func()) {
+	h.app.QueueUpdateDraw(f)
+}
+
+func (h appHost) Suspend(f func()) bool {
+	return h.app.Suspend(f)
+}
+
+// NewExplorerView creates a file explorer to be shown in a layout of the
+// application host, e.g. AppHost(app), configured by opts like
+// NewFileExplorerUI. The root, the input capture, the mouse and the focus
+// stay up to the application: the view only moves the focus between its
+// own parts while it has it. Close the view once done with it.
+func NewExplorerView(host Host, opts ...Option) *ExplorerView {
+	return &ExplorerView{ui: newFileExplorerUI(nil, host, opts)}
+}
+
+// Explorer returns the explorer shown, for navigating, reading the
+// selection and registering events and actions. It must not be started
+// or stopped; that is up to the application.
+func (v *ExplorerView) Explorer() *FileExplorerUI {
+	return v.ui
+}
+
+// SetQuitFunc sets the function called when the user quits the explorer,
+// by default nothing. It typically removes the view or stops the
+// application.
+func (v *ExplorerView) SetQuitFunc(f func()) *ExplorerView {
+	v.ui.onQuit = f
+	return v
+}
+
+// Close cancels the explorer's background work, waiting a while for it to
+// finish, and saves the directories visited
+func (v *ExplorerView) Close() error {
+	v.ui.shutdown()
+	return v.ui.frecency.Save()
+}
+
+// Draw draws the explorer, switching between the two-column and compact
+// layouts to fit the width it is given
+func (v *ExplorerView) Draw(screen tcell.Screen) {
+	_, _, width, _ := v.ui.pages.GetRect()
+	v.ui.beforeDraw(screen, width)
+	v.ui.pages.Draw(screen)
+}
+
+// GetRect returns the position and size of the view
+func (v *ExplorerView) GetRect() (int, int, int, int) {
+	return v.ui.pages.GetRect()
+}
+
+// SetRect sets the position and size of the view
+func (v *ExplorerView) SetRect(x, y, width, height int) {
+	v.ui.pages.SetRect(x, y, width, height)
+}
+
+// InputHandler handles the keys of the explorer, including those that work
+// in its dialogs too
+func (v *ExplorerView) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	handler := v.ui.pages.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		v.ui.hostFocus = setFocus
+		if event = v.ui.captureGlobalKeys(event); event != nil {
+			handler(event, setFocus)
+		}
+	}
+}
+
+// Focus gives the focus to the explorer: to the dialog on top, if any, or
+// else to the part that had it last, the active pane by default
+func (v *ExplorerView) Focus(delegate func(p tview.Primitive)) {
+	v.ui.hostFocus = delegate
+	if front, p := v.ui.pages.GetFrontPage(); front != mainPage && p != nil {
+		v.ui.focused = p
+		delegate(p)
+		return
+	}
+	switch v.ui.focused {
+	case v.ui.tree, v.ui.header, v.ui.contentPane, v.ui.panes[0].table, v.ui.panes[1].table:
+	default:
+		v.ui.focused = v.ui.pane.table
+	}
+	delegate(v.ui.focused)
+}
+
+// HasFocus reports whether a part of the explorer has the focus
+func (v *ExplorerView) HasFocus() bool {
+	return v.ui.pages.HasFocus()
+}
+
+// Blur is called when the explorer loses the focus
+func (v *ExplorerView) Blur() {
+	v.ui.pages.Blur()
+}
+
+// MouseHandler handles the mouse within the explorer
+func (v *ExplorerView) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	handler := v.ui.pages.MouseHandler()
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (bool, tview.Primitive) {
+		v.ui.hostFocus = setFocus
+		return handler(action, event, setFocus)
+	}
+}
+
+// PasteHandler handles text pasted into the explorer
+func (v *ExplorerView) PasteHandler() func(text string, setFocus func(p tview.Primitive)) {
+	handler := v.ui.pages.PasteHandler()
+	return func(text string, setFocus func(p tview.Primitive)) {
+		v.ui.hostFocus = setFocus
+		handler(text, setFocus)
+	}
+}
+
+// setFocus moves the focus to p. An embedded explorer only moves it while
+// it has the focus; otherwise p gets it once the host gives the focus back.
+func (ui *FileExplorerUI) setFocus(p tview.Primitive) {
+	if !ui.embedded {
+		ui.app.SetFocus(p)
+		return
+	}
+	// The part with the focus may be a dialog that was just removed
+	focused := ui.pages.HasFocus() || ui.focused != nil && ui.focused.HasFocus()
+	ui.focused = p
+	if focused && ui.hostFocus != nil {
+		ui.hostFocus(p)
+	}
+}
+
+// exit ends the explorer on quitting: it stops the application it owns, or
+// tells the application embedding it
+func (ui *FileExplorerUI) exit() {
+	if !ui.embedded {
+		ui.Stop()
+		return
+	}
+	if ui.onQuit != nil {
+		ui.onQuit()
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestExplorerViewFocus(t *testing.T) {
+	setTestHome(t)
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.RefreshInterval = 20 * time.Millisecond
+
+	app := tview.NewApplication()
+	screen := tcell.NewSimulationScreen("")
+	screen.SetSize(120, 40)
+	app.SetScreen(screen)
+	view := NewExplorerView(AppHost(app), WithConfig(cfg), WithConfigPath(""), WithStartPath(dir))
+	input := tview.NewInputField()
+	app.SetRoot(tview.NewFlex().
+		AddItem(input, 0, 1, true).
+		AddItem(view, 0, 1, false), true)
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+	t.Cleanup(func() {
+		app.Stop()
+		<-done
+		view.Close()
+	})
+	ui := view.Explorer()
+
+	// Loading and refreshing leave the application's widgets focused
+	if err := os.WriteFile(filepath.Join(dir, "a"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitListed(t, ui, "a")
+	onUI(ui, func() {
+		if got := app.GetFocus(); got != input {
+			t.Errorf("focus moved to %T from the application's input", got)
+		}
+	})
+
+	// A dialog opened without the focus gets it with the view
+	onUI(ui, func() {
+		ui.showGoto()
+		if got := app.GetFocus(); got != input {
+			t.Errorf("focus moved to %T on opening a dialog", got)
+		}
+		app.SetFocus(view)
+		if !view.HasFocus() {
+			t.Error("the view doesn't have the focus it was given")
+		}
+		if front, _ := ui.pages.GetFrontPage(); front != gotoPage {
+			t.Errorf("front page is %q, want %q", front, gotoPage)
+		}
+	})
+
+	// Closing the dialog returns the focus to the listing
+	onUI(ui, func() {
+		ui.closePage(gotoPage)
+		if got := app.GetFocus(); got != ui.pane.table {
+			t.Errorf("focus is on %T after closing the dialog, want the listing", got)
+		}
+	})
+}
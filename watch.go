@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long changes to a watched directory are collected
+// before the listings are reloaded, so a burst of them causes one reload
+const watchDelay = 250 * time.Millisecond
+
+// startWatcher watches the directories shown in the panes and reloads the
+// listings when entries are created, removed or renamed in them, replacing
+// any previously running watcher. It does nothing unless Watch is set and
+// the local disk is browsed.
+func (ui *FileExplorerUI) startWatcher() {
+	if ui.watchCancel != nil {
+		ui.watchCancel()
+		ui.watchCancel = nil
+	}
+	ui.watcher = nil
+	ui.watched = nil
+	if !ui.config.Watch || !ui.local {
+		return
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		// The listings can still be refreshed by hand or periodically
+		ui.showError(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	ui.watchCancel = cancel
+	ui.watcher = w
+	ui.watched = map[string]bool{}
+
+	ui.goBackground(func() {
+		defer w.Close()
+		var timer <-chan time.Time
+		retry := make(chan struct{}, 1)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if timer == nil && event.Has(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) {
+					timer = time.After(watchDelay)
+				}
+			case <-w.Errors:
+				// An overflowed event queue loses changes the next one catches up on
+			case <-retry:
+				if timer == nil {
+					timer = time.After(watchDelay)
+				}
+			case <-timer:
+				timer = nil
+				ui.queueUpdateDraw(func() {
+					if ctx.Err() != nil {
+						return
+					}
+					// Listings aren't reloaded under a dialog; try again once it's closed
+					if front, _ := ui.pages.GetFrontPage(); front != mainPage {
+						select {
+						case retry <- struct{}{}:
+						default:
+						}
+						return
+					}
+					ui.autoRefresh()
+				})
+			}
+		}
+	})
+	ui.watchPanes()
+}
+
+// watchPanes points the watcher at the directories the panes show, leaving
+// out archives and path lists
+func (ui *FileExplorerUI) watchPanes() {
+	if ui.watcher == nil {
+		return
+	}
+	want := map[string]bool{}
+	for _, p := range ui.panes {
+		if p != nil && !p.inPathList() && !inArchive(ui.fsys, p.path) {
+			want[p.path] = true
+		}
+	}
+	for dir := range ui.watched {
+		if !want[dir] {
+			// Fails for directories that are gone, which are no longer watched anyway
+			ui.watcher.Remove(dir)
+			delete(ui.watched, dir)
+		}
+	}
+	for dir := range want {
+		if !ui.watched[dir] && ui.watcher.Add(dir) == nil {
+			ui.watched[dir] = true
+		}
+	}
+}